@@ -2,16 +2,163 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type APP struct {
 	Name string
 	Host string
 	Port string
+	// DrainTimeout - how long the shutdown waits for the queued events to reach
+	// the leaderboards, the rest is replayed from the event log on the next start.
+	DrainTimeout time.Duration
+}
+
+type Ingest struct {
+	// BatchMaxEvents, BatchMaxBytes - limits of one POST /events:batch.
+	BatchMaxEvents int
+	BatchMaxBytes  int64
+	// Skills - allowed skills comma separated, empty - any.
+	Skills string
+	// MetricRanges - raw metric range per skill "skill=min:max,...", "*" for the rest, empty - any.
+	MetricRanges string
+	// MaxClockSkew - how far in the future ts may be, 0 - any.
+	MaxClockSkew time.Duration
+	// TalentIDPattern - regexp of talent IDs, empty - any.
+	TalentIDPattern string
+}
+
+type Leaderboard struct {
+	// Aggregation - max, min, sum, latest, avg(of the last AvgLast scores) or decay.
+	Aggregation string
+	AvgLast     int
+	// DecayHalfLife - a score loses half of its weight each half-life.
+	DecayHalfLife time.Duration
+	// TieBreak - talent_id or earliest(the first to achieve the score ranks higher).
+	TieBreak string
+	// RankType - ordinal(1234), dense(1223) or competition(1224) ranks of the ties.
+	RankType string
+	// Timezone - IANA name, calendar windows(day, week, month) start at its midnight.
+	Timezone string
+	// RollingDays - length of the rolling window in days including today.
+	RollingDays int
+	// SnapshotDir - directory of the board snapshots, empty - no snapshots.
+	SnapshotDir      string
+	SnapshotInterval time.Duration
+	// StreamBuffer - updates queued per stream subscriber, a slower one is disconnected.
+	StreamBuffer int
+	// StreamHistory - the last changes kept for the reconnecting subscribers(Last-Event-ID).
+	StreamHistory   int
+	StreamHeartbeat time.Duration
+	// SeasonStart - RFC 3339 start of the first season, a season is SeasonLength
+	// long and one starts every SeasonEvery(SeasonLength by default). 0 length - no seasons.
+	SeasonStart  string
+	SeasonLength time.Duration
+	SeasonEvery  time.Duration
+}
+
+type Cache struct {
+	// Mode - "memory" or "redis"(events are restored from Redis on start).
+	Mode           string
+	BackupInterval time.Duration
+	// Retention - how long an event ID is remembered, 0 - forever.
+	Retention time.Duration
+	// RetentionBy - "arrival" or "event_ts".
+	RetentionBy string
+	// MaxEntries - cap of IDs in memory, 0 - unlimited.
+	MaxEntries int
+	// Overflow - "lru" or "bloom".
+	Overflow    string
+	BloomFPRate float64
+}
+
+type WAL struct {
+	// Dir - directory of the event log segments, empty - no event log.
+	Dir         string
+	SegmentSize int64
+	// Sync - "always", "interval" or "never".
+	Sync         string
+	SyncInterval time.Duration
+	// Compact - remove the events that are in a snapshot, false keeps the full
+	// history, a rebuild of the leaderboards needs it.
+	Compact bool
+}
+
+type Scorer struct {
+	// ModelsFile - per skill scoring models, empty - the score is the raw metric.
+	// With the remote backend they are the fallback.
+	ModelsFile string
+	// ModelsDir - versions of the models "<dir>/<version>/models.json", polled every
+	// ModelsPoll, the greatest valid version is active. Takes precedence over ModelsFile.
+	ModelsDir  string
+	ModelsPoll time.Duration
+	// Workers - scorer pool size, a worker scores up to BatchSize events
+	// at once and waits up to BatchLinger for a batch to fill.
+	Workers     int
+	BatchSize   int
+	BatchLinger time.Duration
+	// QueueSize - capacity of the scorer queues. With QueuePolicy "wait" a new event
+	// waits up to QueueMaxWait for room(then 503), with "reject" it's refused at once(429).
+	// Above QueueShedAt(a share of QueueSize, 0 - off) new events are refused by both.
+	QueueSize    int
+	QueuePolicy  string
+	QueueMaxWait time.Duration
+	QueueShedAt  float64
+	// Backend - "local" or "remote"(the model server at RemoteURL).
+	Backend         string
+	RemoteURL       string
+	RemoteTimeout   time.Duration
+	RemoteRetries   int
+	RemoteBatchSize int
+	RemoteLinger    time.Duration
+	// BreakerFailures - failed requests in a row that switch to the fallback for BreakerCooldown.
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+type Webhooks struct {
+	// File - the registered webhooks and their milestone rules, empty - no webhooks.
+	File string
+	// Dir - the durable delivery queue, empty - the undelivered ones are lost on restart.
+	Dir     string
+	Timeout time.Duration
+	// MaxAttempts - a delivery is dead-lettered after it, the n-th retry waits
+	// Backoff*2^(n-1) up to MaxBackoff.
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Concurrency int
+	// Buffer - milestones waiting for the queue, the overflow is dropped.
+	Buffer int
+}
+
+type Boards struct {
+	// Dir - the definitions and the data of the named boards, empty - they are
+	// kept in memory only.
+	Dir string
+	// Workers - the scorer pool of every board.
+	Workers int
+}
+
+type Redis struct {
+	Addr     string
+	Password string
+	DB       int
+	// EventsKey - Redis set with the IDs of accepted events.
+	EventsKey string
 }
 
 type Config struct {
-	App APP
+	App         APP
+	Ingest      Ingest
+	Leaderboard Leaderboard
+	Cache       Cache
+	WAL         WAL
+	Scorer      Scorer
+	Webhooks    Webhooks
+	Boards      Boards
+	Redis       Redis
 }
 
 func getEnv(key, def string) string {
@@ -21,14 +168,146 @@ func getEnv(key, def string) string {
 	return def
 }
 
+func getEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(getEnv(key, ""), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getEnvBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
 func Load() Config {
 	app := APP{
 		Name: getEnv("SERVICE_NAME", ""),
 		Host: getEnv("SERVICE_HOST", ""),
 		Port: getEnv("SERVICE_PORT", ""),
+
+		DrainTimeout: getEnvDuration("SERVICE_DRAIN_TIMEOUT", 10*time.Second),
+	}
+
+	ingest := Ingest{
+		BatchMaxEvents: getEnvInt("INGEST_BATCH_MAX_EVENTS", 1000),
+		BatchMaxBytes:  int64(getEnvInt("INGEST_BATCH_MAX_BYTES", 1<<20)),
+
+		Skills:          getEnv("INGEST_SKILLS", ""),
+		MetricRanges:    getEnv("INGEST_METRIC_RANGES", ""),
+		MaxClockSkew:    getEnvDuration("INGEST_MAX_CLOCK_SKEW", 5*time.Minute),
+		TalentIDPattern: getEnv("INGEST_TALENT_ID_PATTERN", ""),
+	}
+
+	lb := Leaderboard{
+		Aggregation:      getEnv("LEADERBOARD_AGGREGATION", "max"),
+		AvgLast:          getEnvInt("LEADERBOARD_AVG_LAST", 5),
+		DecayHalfLife:    getEnvDuration("LEADERBOARD_DECAY_HALF_LIFE", 7*24*time.Hour),
+		TieBreak:         getEnv("LEADERBOARD_TIE_BREAK", "talent_id"),
+		RankType:         getEnv("LEADERBOARD_RANK_TYPE", "ordinal"),
+		Timezone:         getEnv("LEADERBOARD_TIMEZONE", "UTC"),
+		RollingDays:      getEnvInt("LEADERBOARD_ROLLING_DAYS", 7),
+		SnapshotDir:      getEnv("LEADERBOARD_SNAPSHOT_DIR", ""),
+		SnapshotInterval: getEnvDuration("LEADERBOARD_SNAPSHOT_INTERVAL", 5*time.Minute),
+		StreamBuffer:     getEnvInt("LEADERBOARD_STREAM_BUFFER", 256),
+		StreamHistory:    getEnvInt("LEADERBOARD_STREAM_HISTORY", 10000),
+		StreamHeartbeat:  getEnvDuration("LEADERBOARD_STREAM_HEARTBEAT", 15*time.Second),
+		SeasonStart:      getEnv("LEADERBOARD_SEASON_START", ""),
+		SeasonLength:     getEnvDuration("LEADERBOARD_SEASON_LENGTH", 0),
+		SeasonEvery:      getEnvDuration("LEADERBOARD_SEASON_EVERY", 0),
+	}
+
+	cache := Cache{
+		Mode:           getEnv("CACHE_MODE", "memory"),
+		BackupInterval: getEnvDuration("CACHE_BACKUP_INTERVAL", 10*time.Minute),
+		Retention:      getEnvDuration("CACHE_RETENTION", 72*time.Hour),
+		RetentionBy:    getEnv("CACHE_RETENTION_BY", "arrival"),
+		MaxEntries:     getEnvInt("CACHE_MAX_ENTRIES", 0),
+		Overflow:       getEnv("CACHE_OVERFLOW", "lru"),
+		BloomFPRate:    getEnvFloat("CACHE_BLOOM_FP_RATE", 0.001),
+	}
+
+	wal := WAL{
+		Dir:          getEnv("WAL_DIR", ""),
+		SegmentSize:  int64(getEnvInt("WAL_SEGMENT_SIZE", 64<<20)),
+		Sync:         getEnv("WAL_SYNC", "always"),
+		SyncInterval: getEnvDuration("WAL_SYNC_INTERVAL", 100*time.Millisecond),
+		Compact:      getEnvBool("WAL_COMPACT", false),
+	}
+
+	scorer := Scorer{
+		ModelsFile:      getEnv("SCORER_MODELS_FILE", ""),
+		ModelsDir:       getEnv("SCORER_MODELS_DIR", ""),
+		ModelsPoll:      getEnvDuration("SCORER_MODELS_POLL", 10*time.Second),
+		Workers:         getEnvInt("SCORER_WORKERS", 32),
+		BatchSize:       getEnvInt("SCORER_BATCH_SIZE", 32),
+		BatchLinger:     getEnvDuration("SCORER_BATCH_LINGER", 2*time.Millisecond),
+		QueueSize:       getEnvInt("SCORER_QUEUE_SIZE", 1000),
+		QueuePolicy:     getEnv("SCORER_QUEUE_POLICY", "wait"),
+		QueueMaxWait:    getEnvDuration("SCORER_QUEUE_MAX_WAIT", 100*time.Millisecond),
+		QueueShedAt:     getEnvFloat("SCORER_QUEUE_SHED_AT", 0),
+		Backend:         getEnv("SCORER_BACKEND", "local"),
+		RemoteURL:       getEnv("SCORER_REMOTE_URL", ""),
+		RemoteTimeout:   getEnvDuration("SCORER_REMOTE_TIMEOUT", time.Second),
+		RemoteRetries:   getEnvInt("SCORER_REMOTE_RETRIES", 2),
+		RemoteBatchSize: getEnvInt("SCORER_REMOTE_BATCH_SIZE", 64),
+		RemoteLinger:    getEnvDuration("SCORER_REMOTE_LINGER", 5*time.Millisecond),
+		BreakerFailures: getEnvInt("SCORER_BREAKER_FAILURES", 5),
+		BreakerCooldown: getEnvDuration("SCORER_BREAKER_COOLDOWN", 10*time.Second),
+	}
+
+	webhooks := Webhooks{
+		File:        getEnv("WEBHOOKS_FILE", ""),
+		Dir:         getEnv("WEBHOOKS_DIR", ""),
+		Timeout:     getEnvDuration("WEBHOOKS_TIMEOUT", 5*time.Second),
+		MaxAttempts: getEnvInt("WEBHOOKS_MAX_ATTEMPTS", 8),
+		Backoff:     getEnvDuration("WEBHOOKS_BACKOFF", time.Second),
+		MaxBackoff:  getEnvDuration("WEBHOOKS_MAX_BACKOFF", 10*time.Minute),
+		Concurrency: getEnvInt("WEBHOOKS_CONCURRENCY", 4),
+		Buffer:      getEnvInt("WEBHOOKS_BUFFER", 1000),
+	}
+
+	boards := Boards{
+		Dir:     getEnv("BOARDS_DIR", ""),
+		Workers: getEnvInt("BOARDS_WORKERS", 4),
+	}
+
+	redis := Redis{
+		Addr:      getEnv("REDIS_ADDR", "localhost:6379"),
+		Password:  getEnv("REDIS_PASSWORD", ""),
+		DB:        getEnvInt("REDIS_DB", 0),
+		EventsKey: getEnv("REDIS_EVENTS_KEY", "leaderboard:events"),
 	}
 
 	return Config{
-		App: app,
+		App:         app,
+		Ingest:      ingest,
+		Leaderboard: lb,
+		Cache:       cache,
+		WAL:         wal,
+		Scorer:      scorer,
+		Webhooks:    webhooks,
+		Boards:      boards,
+		Redis:       redis,
 	}
 }
@@ -3,7 +3,6 @@ module leaderboard-api
 go 1.25
 
 require (
-	github.com/google/btree v1.1.3
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	github.com/prometheus/client_golang v1.23.0
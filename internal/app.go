@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 	"time"
 
@@ -18,29 +19,39 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"leaderboard-api/config"
+	"leaderboard-api/internal/application/ports"
 	"leaderboard-api/internal/application/services"
+	"leaderboard-api/internal/domain/season"
 	"leaderboard-api/internal/infrastructure/cache"
+	"leaderboard-api/internal/infrastructure/db/redis"
 	"leaderboard-api/internal/infrastructure/leaderboard"
 	"leaderboard-api/internal/infrastructure/metrics"
 	"leaderboard-api/internal/infrastructure/ml"
+	"leaderboard-api/internal/infrastructure/tenant"
+	"leaderboard-api/internal/infrastructure/wal"
+	"leaderboard-api/internal/infrastructure/webhook"
 	"leaderboard-api/internal/interface/api/rest"
 	"leaderboard-api/internal/interface/api/rest/middleware"
+	"leaderboard-api/internal/interface/api/rest/validation"
 )
 
-// poolSize - Always better to make up a decision based on measurement traffic,
-// profiling, metrics and hardware.
-// "Rely on metrics, not guesses."
-var poolSize = 1000
-
 type App struct {
-	logger   *zap.Logger
-	cfg      config.Config
-	httpSrv  *http.Server
-	mux      *http.ServeMux
-	cache    *cache.Cache
-	scorer   *ml.Scorer
-	lbMemory *leaderboard.LBMemory
-	metrics  *prometheus.CounterVec
+	logger    *zap.Logger
+	cfg       config.Config
+	httpSrv   *http.Server
+	mux       *http.ServeMux
+	cache     *cache.Cache
+	redis     *redis.Client
+	scorer    *ml.Scorer
+	models    *ml.Registry
+	lbMemory  *leaderboard.LBMemory
+	wal       *wal.Log
+	notifier  *webhook.Notifier
+	tenants   *tenant.Registry
+	metrics   *prometheus.CounterVec
+	events    ports.EventService
+	boards    ports.BoardService
+	validator *validation.EventValidator
 }
 
 func NewApp(ctx context.Context) (*App, error) {
@@ -65,28 +76,282 @@ func NewApp(ctx context.Context) (*App, error) {
 		Handler: middleware.RequestLog(logger)(m),
 	}
 
+	// validation
+	ranges, err := validation.ParseRanges(cfg.Ingest.MetricRanges)
+	if err != nil {
+		return nil, err
+	}
+	rules := validation.Rules{
+		Skills:       validation.ParseSkills(cfg.Ingest.Skills),
+		Ranges:       ranges,
+		MaxClockSkew: cfg.Ingest.MaxClockSkew,
+	}
+	if cfg.Ingest.TalentIDPattern != "" {
+		if rules.TalentID, err = regexp.Compile(cfg.Ingest.TalentIDPattern); err != nil {
+			return nil, fmt.Errorf("talent id pattern: %w", err)
+		}
+	}
+	validator := validation.NewEventValidator(rules, metrics.NewIngestRejected())
+
 	// cache
-	c := cache.New(ctx, logger)
+	var (
+		rdb   *redis.Client
+		store cache.Store
+	)
+	switch cfg.Cache.Mode {
+	case cache.ModeMemory:
+	case cache.ModeRedis:
+		rdb = redis.New(redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		if err := rdb.Ping(ctx); err != nil {
+			return nil, fmt.Errorf("redis is not available: %w", err)
+		}
+		store = cache.NewRedisStore(rdb, cfg.Redis.EventsKey, cfg.Cache.Retention)
+	default:
+		return nil, fmt.Errorf("unknown cache mode %q", cfg.Cache.Mode)
+	}
+	cacheCfg := cache.Config{
+		BackupInterval: cfg.Cache.BackupInterval,
+		Retention:      cfg.Cache.Retention,
+		RetentionBy:    cfg.Cache.RetentionBy,
+		MaxEntries:     cfg.Cache.MaxEntries,
+		Overflow:       cfg.Cache.Overflow,
+		BloomFPRate:    cfg.Cache.BloomFPRate,
+	}
+	cacheMtr := cache.Metrics{
+		Evictions: metrics.NewCacheEvictions(),
+		Size:      metrics.NewCacheSize(),
+	}
+	c, err := cache.New(ctx, logger, store, cacheCfg, cacheMtr)
+	if err != nil {
+		return nil, fmt.Errorf("cache wake up failed: %w", err)
+	}
+	// event log
+	var eventLog *wal.Log
+	if cfg.WAL.Dir != "" {
+		eventLog, err = wal.Open(logger, wal.Config{
+			Dir:          cfg.WAL.Dir,
+			SegmentSize:  cfg.WAL.SegmentSize,
+			Sync:         cfg.WAL.Sync,
+			SyncInterval: cfg.WAL.SyncInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("event log open failed: %w", err)
+		}
+	}
 	// ml scorer
-	s := ml.New(ctx, logger)
+	var models *ml.Registry
+	modelInfo := metrics.NewScorerModelInfo()
+	switch {
+	case cfg.Scorer.ModelsDir != "":
+		if models, err = ml.OpenRegistry(logger, cfg.Scorer.ModelsDir, cfg.Scorer.ModelsPoll, modelInfo); err != nil {
+			return nil, fmt.Errorf("scoring models load failed: %w", err)
+		}
+	case cfg.Scorer.ModelsFile != "":
+		static, err := ml.LoadModels(cfg.Scorer.ModelsFile)
+		if err != nil {
+			return nil, fmt.Errorf("scoring models load failed: %w", err)
+		}
+		models = ml.NewRegistry(logger, static, modelInfo)
+	default:
+		models = ml.NewRegistry(logger, ml.IdentityModels(), modelInfo)
+	}
+	var backend ml.Backend = models
+	switch cfg.Scorer.Backend {
+	case ml.BackendLocal:
+	case ml.BackendRemote:
+		remoteCfg := ml.RemoteConfig{
+			URL:             cfg.Scorer.RemoteURL,
+			Timeout:         cfg.Scorer.RemoteTimeout,
+			Retries:         cfg.Scorer.RemoteRetries,
+			BatchSize:       cfg.Scorer.RemoteBatchSize,
+			Linger:          cfg.Scorer.RemoteLinger,
+			BreakerFailures: cfg.Scorer.BreakerFailures,
+			BreakerCooldown: cfg.Scorer.BreakerCooldown,
+		}
+		remoteMtr := ml.RemoteMetrics{
+			Requests:  metrics.NewScorerRequests(),
+			Fallbacks: metrics.NewScorerFallbacks(),
+		}
+		if backend, err = ml.NewRemote(logger, remoteCfg, models, remoteMtr); err != nil {
+			return nil, fmt.Errorf("remote scorer init failed: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown scorer backend %q", cfg.Scorer.Backend)
+	}
+	scorerCfg := ml.Config{
+		BatchSize: cfg.Scorer.BatchSize,
+		Linger:    cfg.Scorer.BatchLinger,
+		Queue: ml.QueueConfig{
+			Size:    cfg.Scorer.QueueSize,
+			Policy:  cfg.Scorer.QueuePolicy,
+			MaxWait: cfg.Scorer.QueueMaxWait,
+			ShedAt:  cfg.Scorer.QueueShedAt,
+		},
+	}
+	scorerMtr := ml.Metrics{
+		BatchSize:     metrics.NewScorerBatchSize(),
+		BatchDuration: metrics.NewScorerBatchDuration(),
+		Pool:          metrics.NewScorerPool(),
+		QueueDepth:    metrics.NewScorerQueueDepth(),
+		QueueWait:     metrics.NewScorerQueueWait(),
+		Shed:          metrics.NewScorerShed(),
+	}
+	s, err := ml.New(ctx, logger, backend, scorerCfg, scorerMtr)
+	if err != nil {
+		return nil, err
+	}
 	// metrics
 	mtr := metrics.New()
 	// leaderboard memory
-	lbMem := leaderboard.New(ctx, logger, s.GetOutChan(), mtr)
+	loc, err := time.LoadLocation(cfg.Leaderboard.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid leaderboard timezone: %w", err)
+	}
+	agg, err := leaderboard.NewAggregation(cfg.Leaderboard.Aggregation, cfg.Leaderboard.AvgLast, cfg.Leaderboard.DecayHalfLife)
+	if err != nil {
+		return nil, fmt.Errorf("invalid leaderboard aggregation: %w", err)
+	}
+	ranking, err := leaderboard.NewRanking(cfg.Leaderboard.TieBreak, cfg.Leaderboard.RankType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid leaderboard ranking: %w", err)
+	}
+	schedule := season.Schedule{Length: cfg.Leaderboard.SeasonLength, Every: cfg.Leaderboard.SeasonEvery}
+	if cfg.Leaderboard.SeasonStart != "" {
+		if schedule.Start, err = time.Parse(time.RFC3339, cfg.Leaderboard.SeasonStart); err != nil {
+			return nil, fmt.Errorf("invalid season start: %w", err)
+		}
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	lbCfg := leaderboard.Config{
+		Aggregation: agg,
+		Ranking:     ranking,
+		Season:      schedule,
+		Location:    loc,
+		RollingDays: cfg.Leaderboard.RollingDays,
+
+		SnapshotDir:      cfg.Leaderboard.SnapshotDir,
+		SnapshotInterval: cfg.Leaderboard.SnapshotInterval,
+
+		StreamBuffer:  cfg.Leaderboard.StreamBuffer,
+		StreamHistory: cfg.Leaderboard.StreamHistory,
+	}
+	if eventLog != nil && cfg.WAL.Compact {
+		lbCfg.Log = eventLog
+	}
+	// webhooks
+	var notifier *webhook.Notifier
+	if cfg.Webhooks.File != "" {
+		hooks, err := webhook.LoadHooks(cfg.Webhooks.File)
+		if err != nil {
+			return nil, err
+		}
+		webhookCfg := webhook.Config{
+			Dir:         cfg.Webhooks.Dir,
+			Timeout:     cfg.Webhooks.Timeout,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Backoff:     cfg.Webhooks.Backoff,
+			MaxBackoff:  cfg.Webhooks.MaxBackoff,
+			Concurrency: cfg.Webhooks.Concurrency,
+			Buffer:      cfg.Webhooks.Buffer,
+		}
+		webhookMtr := webhook.Metrics{
+			Deliveries: metrics.NewWebhookDeliveries(),
+			Pending:    metrics.NewWebhookPending(),
+		}
+		if notifier, err = webhook.New(logger, hooks, webhookCfg, webhookMtr); err != nil {
+			return nil, fmt.Errorf("webhooks init failed: %w", err)
+		}
+		lbCfg.Notify = notifier.Notify
+	}
+	// named boards, the pipeline above per board
+	boardsCfg := tenant.Config{
+		Dir:           cfg.Boards.Dir,
+		Aggregation:   cfg.Leaderboard.Aggregation,
+		AvgLast:       cfg.Leaderboard.AvgLast,
+		DecayHalfLife: cfg.Leaderboard.DecayHalfLife,
+		TieBreak:      cfg.Leaderboard.TieBreak,
+		RankType:      cfg.Leaderboard.RankType,
+		Leaderboard: leaderboard.Config{
+			Location:         loc,
+			RollingDays:      cfg.Leaderboard.RollingDays,
+			SnapshotInterval: cfg.Leaderboard.SnapshotInterval,
+			StreamBuffer:     cfg.Leaderboard.StreamBuffer,
+			StreamHistory:    cfg.Leaderboard.StreamHistory,
+		},
+		Cache: cacheCfg,
+		WAL: wal.Config{
+			Dir:          cfg.WAL.Dir,
+			SegmentSize:  cfg.WAL.SegmentSize,
+			Sync:         cfg.WAL.Sync,
+			SyncInterval: cfg.WAL.SyncInterval,
+		},
+		Compact: cfg.WAL.Compact,
+		Scorer:  scorerCfg,
+		Workers: cfg.Boards.Workers,
+	}
+	if rdb != nil {
+		boardsCfg.NewStore = func(board string) cache.Store {
+			return cache.NewRedisStore(rdb, cfg.Redis.EventsKey+":board:"+board, cfg.Cache.Retention)
+		}
+	}
+	boardsMtr := tenant.Metrics{
+		Events:              metrics.NewBoardEvents(),
+		Skill:               metrics.NewBoardSkill(),
+		CacheEvictions:      metrics.NewBoardCacheEvictions(),
+		CacheSize:           metrics.NewBoardCacheSize(),
+		ModelInfo:           metrics.NewBoardModelInfo(),
+		ScorerBatchSize:     metrics.NewBoardScorerBatchSize(),
+		ScorerBatchDuration: metrics.NewBoardScorerBatchDuration(),
+		ScorerPool:          metrics.NewBoardScorerPool(),
+		ScorerQueueDepth:    metrics.NewBoardScorerQueueDepth(),
+		ScorerQueueWait:     metrics.NewBoardScorerQueueWait(),
+		ScorerShed:          metrics.NewBoardScorerShed(),
+	}
+	tenants, err := tenant.Open(logger, boardsCfg, boardsMtr)
+	if err != nil {
+		return nil, fmt.Errorf("boards load failed: %w", err)
+	}
+	// restored before the http server accepts any traffic
+	lbMem, err := leaderboard.New(ctx, logger, s.GetOutChan(), lbCfg, mtr, metrics.NewSkill())
+	if err != nil {
+		return nil, fmt.Errorf("leaderboard wake up failed: %w", err)
+	}
+	// the streams never end by themselves, Shutdown would wait for them
+	httpSrv.RegisterOnShutdown(lbMem.CloseStreams)
 
 	return &App{
-		logger:   logger,
-		cfg:      cfg,
-		httpSrv:  httpSrv,
-		mux:      m,
-		cache:    c,
-		scorer:   s,
-		lbMemory: lbMem,
-		metrics:  mtr,
+		logger:    logger,
+		cfg:       cfg,
+		httpSrv:   httpSrv,
+		mux:       m,
+		cache:     c,
+		redis:     rdb,
+		scorer:    s,
+		models:    models,
+		lbMemory:  lbMem,
+		wal:       eventLog,
+		notifier:  notifier,
+		tenants:   tenants,
+		metrics:   mtr,
+		validator: validator,
 	}, nil
 }
 
 func (a *App) Close() {
+	if a.wal != nil {
+		if err := a.wal.Close(); err != nil {
+			a.logger.Error("event log close failed", zap.Error(err))
+		}
+	}
+	if a.redis != nil {
+		_ = a.redis.Close()
+	}
 	if a.logger != nil {
 		_ = a.logger.Sync()
 	}
@@ -105,59 +370,146 @@ func (a *App) Run(ctx context.Context) error {
 	// - wg.Add(1), wg.Done() - automatically under the hood, so never catch deadlock if you forget something ;-)
 	// - allows orchestration of parallel processes through the context.Context(gracefull shut down)
 	g, ctx := errgroup.WithContext(ctx)
+	// the event IDs deduped while the requests and the scorer pool drain
+	// are flushed too, it's stopped after them
+	cacheCtx, cacheStop := context.WithCancel(context.WithoutCancel(ctx))
+	defer cacheStop()
 	g.Go(func() error {
-		a.logger.Info("starting "+a.cfg.App.Name, zap.String("addr", a.cfg.App.Host+":"+a.cfg.App.Port))
-		if err := a.httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			return fmt.Errorf("http server "+a.cfg.App.Name+" error: %w", err)
-		}
+		a.cache.BackupWorker(cacheCtx)
+		return nil
+	})
 
+	// Workers - Always better to make up a decision based on measurement traffic,
+	// profiling, metrics and hardware.
+	// "Rely on metrics, not guesses."
+	a.scorer.RunScorerPool(ctx, a.cfg.Scorer.Workers)
+
+	g.Go(func() error {
+		a.models.Watch(ctx)
 		return nil
 	})
 
 	g.Go(func() error {
-		a.cache.BackupWorker(ctx)
+		a.lbMemory.RunLBWorker(ctx)
 		return nil
 	})
 
-	a.scorer.RunScorerPool(ctx, poolSize)
+	g.Go(func() error {
+		a.lbMemory.SnapshotWorker(ctx)
+		return nil
+	})
+
+	if a.wal != nil {
+		g.Go(func() error {
+			a.wal.SyncWorker(ctx)
+			return nil
+		})
+	}
+
+	if a.notifier != nil {
+		g.Go(func() error {
+			a.notifier.Run(ctx)
+			return nil
+		})
+	}
+
+	// events acknowledged before the last stop, but not in the snapshot yet
+	n, err := a.events.Replay(ctx, a.lbMemory.Applied)
+	if err != nil {
+		a.logger.Error("event log replay failed", zap.Error(err))
+	}
+	a.logger.Info("event log replayed", zap.Int("events", n))
+
+	if err := a.tenants.Start(ctx); err != nil {
+		a.logger.Error("boards wake up failed", zap.Error(err))
+	}
+	if n, err = a.boards.Replay(ctx); err != nil {
+		a.logger.Error("boards event log replay failed", zap.Error(err))
+	}
+	a.logger.Info("boards event log replayed", zap.Int("events", n))
 
+	// only now, the replayed events are ahead of the new ones
 	g.Go(func() error {
-		a.lbMemory.RunLBWorker(ctx)
+		a.logger.Info("starting "+a.cfg.App.Name, zap.String("addr", a.cfg.App.Host+":"+a.cfg.App.Port))
+		if err := a.httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("http server "+a.cfg.App.Name+" error: %w", err)
+		}
+
 		return nil
 	})
 
 	<-ctx.Done()
 
-	a.scorer.ClosePool(ctx)
-
+	// Ordered shutdown, every stage drains into the next one:
+	// HTTP(no new events) -> scorer pool -> leaderboard worker(the final snapshot),
+	// the named boards the same way, then the final flush of the dedup cache.
 	a.logger.Info("shutting down " + a.cfg.App.Name + " gracefully...")
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
+	var shutdownErr error
 	if a.httpSrv != nil {
-		if err := a.httpSrv.Shutdown(shutdownCtx); err != nil {
-			a.logger.Error("http server shutdown "+a.cfg.App.Name+" error", zap.Error(err))
-			return err
+		if shutdownErr = a.httpSrv.Shutdown(shutdownCtx); shutdownErr != nil {
+			// the late requests get 503, the pipeline is drained anyway
+			a.logger.Error("http server shutdown "+a.cfg.App.Name+" error", zap.Error(shutdownErr))
 		}
 	}
 
-	if err := g.Wait(); err != nil {
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), a.cfg.App.DrainTimeout)
+	defer drainCancel()
+	if err := a.scorer.ClosePool(drainCtx); err != nil {
+		a.logger.Error("scorer pool drain error", zap.Error(err))
+	}
+	a.tenants.Stop(drainCtx)
+	cacheStop()
+
+	// the leaderboard worker stops once the scored events are applied
+	err = g.Wait()
+	if a.notifier != nil {
+		// the milestones of the drained events are sent on the next start
+		a.notifier.Close()
+	}
+	if err != nil {
 		a.logger.Error(a.cfg.App.Name+" returning an error", zap.Error(err))
 		return err
 	}
+	if shutdownErr != nil {
+		return shutdownErr
+	}
 
 	a.logger.Info(a.cfg.App.Name + " gracefully stopped")
 
 	return nil
 }
 
-func (a *App) InitControllers(_ context.Context) {
+func (a *App) InitControllers(ctx context.Context) {
 	// services
-	eventService := services.NewEventService(a.cache, a.scorer, a.metrics)
+	var eventLog ports.EventLog
+	if a.wal != nil {
+		eventLog = a.wal
+	}
+	eventService := services.NewEventService(a.cache, eventLog, a.scorer, a.lbMemory, a.metrics)
+	a.events = eventService
 	lbService := services.NewLeaderboardService(a.lbMemory)
+	seasonService := services.NewSeasonService(a.lbMemory)
+	adminService := services.NewAdminService(ctx, a.lbMemory, a.models, eventLog, a.scorer)
+	var webhooks ports.Webhooks
+	if a.notifier != nil {
+		webhooks = a.notifier
+	}
+	webhookService := services.NewWebhookService(webhooks)
+	boardService := services.NewBoardService(a.tenants)
+	a.boards = boardService
 
 	// controllers
-	rest.NewEventController(a.mux, eventService)
-	rest.NewLeaderboardController(a.mux, lbService)
+	rest.NewEventController(a.mux, eventService, boardService, a.validator, rest.BatchConfig{
+		MaxEvents: a.cfg.Ingest.BatchMaxEvents,
+		MaxBytes:  a.cfg.Ingest.BatchMaxBytes,
+	})
+	rest.NewLeaderboardController(a.mux, lbService, boardService, rest.StreamConfig{Heartbeat: a.cfg.Leaderboard.StreamHeartbeat})
+	rest.NewAdminController(a.mux, adminService)
+	rest.NewWebhookController(a.mux, webhookService)
+	rest.NewBoardController(a.mux, boardService)
+	rest.NewSeasonController(a.mux, seasonService, boardService)
 
 	// ops
 	a.mux.HandleFunc(http.MethodGet+rest.Space+rest.RouteHealth, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/model"
+)
+
+type AdminService interface {
+	Snapshot(ctx context.Context) (leader.Snapshot, error)
+	Model(ctx context.Context) model.Version
+	// Rebuild - starts rebuilding the boards from the event history by the current models.
+	Rebuild(ctx context.Context) (leader.Rebuild, error)
+	RebuildStatus(ctx context.Context) leader.Rebuild
+}
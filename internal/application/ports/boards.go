@@ -0,0 +1,49 @@
+package ports
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"leaderboard-api/internal/domain/board"
+)
+
+// Boards - the named leaderboards, every one runs its own pipeline.
+type Boards interface {
+	Create(b board.Board) (board.Board, error)
+	// SetModels - the new events of the board are scored by the models.
+	SetModels(name string, models json.RawMessage) (board.Board, error)
+	// Delete - stops the pipeline of the board and removes its data.
+	Delete(ctx context.Context, name string) error
+	List() []board.Board
+	Get(name string) (board.Board, error)
+	Pipeline(name string) (BoardPipeline, error)
+}
+
+// BoardPipeline - the parts of a live board.
+type BoardPipeline struct {
+	Cache Cache
+	// Log - nil without the event log.
+	Log    EventLog
+	Scorer Scorer
+	LB     LBMemory
+	// Seasons - nil without the seasons.
+	Seasons Seasons
+	// Metrics - events by result, the board label is set.
+	Metrics *prometheus.CounterVec
+}
+
+type BoardService interface {
+	Create(ctx context.Context, b board.Board) (board.Board, error)
+	SetModels(ctx context.Context, name string, models json.RawMessage) (board.Board, error)
+	Delete(ctx context.Context, name string) error
+	List(ctx context.Context) []board.Board
+	Get(ctx context.Context, name string) (board.Board, error)
+	// Events, Leaderboard, Seasons - the services of a board.
+	Events(ctx context.Context, name string) (EventService, error)
+	Leaderboard(ctx context.Context, name string) (LeaderboardService, error)
+	Seasons(ctx context.Context, name string) (SeasonService, error)
+	// Replay - every board from its event log, on start.
+	Replay(ctx context.Context) (int, error)
+}
@@ -1,10 +1,16 @@
 package ports
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Cache interface {
-	Set(eventID uuid.UUID)
+	// Set - ts is the event time, the cache may count the retention from it.
+	Set(eventID uuid.UUID, ts time.Time)
 	IsSet(id uuid.UUID) bool
+	// SetIfAbsent - atomic check-and-set, true if the ID was not set before.
+	SetIfAbsent(eventID uuid.UUID, ts time.Time) bool
+	Delete(eventID uuid.UUID)
 }
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+// EventLog - durable log of accepted events.
+type EventLog interface {
+	// Append - returns the sequence number of the event.
+	Append(e event.Event) (uint64, error)
+	// Replay - every logged event in the sequence order.
+	Replay(ctx context.Context, fn func(e event.Event) error) error
+	// ReplayFrom - the events from the seq `from`, returns the seq after the last one.
+	ReplayFrom(ctx context.Context, from uint64, fn func(e event.Event) error) (uint64, error)
+	// Bounds - the first seq still in the log and the seq of the next append.
+	Bounds() (first, next uint64)
+}
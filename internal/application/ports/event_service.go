@@ -8,5 +8,9 @@ import (
 
 type EventService interface {
 	Create(ctx context.Context, event *event.Event) (bool, error)
+	// CreateBatch - a result per event in the same order.
+	CreateBatch(ctx context.Context, events []*event.Event) []event.Result
 	Seed(ctx context.Context, cnt int)
+	// Replay - sends the logged events to the scorer again, except the skipped ones.
+	Replay(ctx context.Context, skip func(seq uint64) bool) (int, error)
 }
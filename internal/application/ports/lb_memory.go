@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 
+	"leaderboard-api/internal/domain/event"
 	"leaderboard-api/internal/domain/leader"
 )
 
@@ -10,6 +11,39 @@ type LBMemory interface {
 	RunLBWorker(ctx context.Context)
 	StopRankWorker(ctx context.Context)
 	TopN(n int) leader.Leaders
-	RankOf(talentID string) (l leader.Leader, ok bool)
+	Range(s leader.Scope, offset, n int) leader.Page
+	After(s leader.Scope, c leader.Cursor, n int) leader.Page
+	RankOf(s leader.Scope, talentID string) (l leader.Leader, ok bool)
+	Around(s leader.Scope, talentID string, radius int) (leader.Leaders, bool)
 	All() leader.Leaders
+	// RankType - how the tied talents are ranked by Range, After, RankOf and Around.
+	RankType() leader.RankType
+	Snapshot(ctx context.Context) (leader.Snapshot, error)
+	// Applied - whether the logged event is on the boards already.
+	Applied(seq uint64) bool
+	// NewShadow - empty boards with the same settings, not live until swapped in.
+	NewShadow() LBShadow
+	// Swap - replaces the live boards by the shadow ones. catchUp runs right
+	// before it under the write lock, no event is applied in between.
+	Swap(shadow LBShadow, catchUp func() error) error
+	// Subscribe - the stream of the all-time board changes of the watch, resumed
+	// after lastID when possible, otherwise it starts from a snapshot.
+	Subscribe(w leader.Watch, lastID uint64) (LBSubscription, error)
+	// CloseStreams - ends every stream, no new ones are accepted.
+	CloseStreams()
+}
+
+// LBSubscription - updates of one stream subscriber.
+type LBSubscription interface {
+	Updates() <-chan leader.Update
+	// Done - closed when the stream ends: closed, too slow or the shutdown, Err tells which.
+	Done() <-chan struct{}
+	Err() error
+	Close()
+}
+
+// LBShadow - boards built aside of the live ones, e.g. by a rebuild.
+type LBShadow interface {
+	// Apply - the events must be scored already.
+	Apply(events []event.Event)
 }
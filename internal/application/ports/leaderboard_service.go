@@ -7,6 +7,9 @@ import (
 )
 
 type LeaderboardService interface {
-	GetBboard(ctx context.Context, limit int) (leader.Leaders, error)
-	GetRankByID(ctx context.Context, id string) (leader.Leader, error)
+	GetBboard(ctx context.Context, q leader.Query) (leader.Page, error)
+	GetRankByID(ctx context.Context, s leader.Scope, id string) (leader.Leader, error)
+	GetAround(ctx context.Context, s leader.Scope, id string, radius int) (leader.Leaders, error)
+	Subscribe(ctx context.Context, w leader.Watch, lastID uint64) (LBSubscription, error)
+	RankType(ctx context.Context) leader.RankType
 }
@@ -4,11 +4,25 @@ import (
 	"context"
 
 	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/model"
 )
 
 type Scorer interface {
 	RunScorerPool(ctx context.Context, size int)
-	ClosePool(ctx context.Context)
-	GetInputChan() chan event.Event
+	// ClosePool - drains the queued events into the output until ctx is done, then closes it.
+	ClosePool(ctx context.Context) error
+	// Submit - queues a new event by the shedding policy, accept is called once
+	// there is room for it(e.g. to log it), event.ErrQueueFull or
+	// event.ErrQueueTimeout if it's shed.
+	Submit(ctx context.Context, e event.Event, accept func(*event.Event) error) error
+	// Push - queues an event that can't be shed, waits for room as long as ctx lives.
+	Push(ctx context.Context, e event.Event) error
 	GetOutChan() chan event.Event
+	// Score - scores the events right away, aside of the pool.
+	Score(ctx context.Context, events []event.Event) error
+}
+
+// ModelRegistry - the scoring models in use.
+type ModelRegistry interface {
+	Active() model.Version
 }
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+// Seasons - the season lifecycle of the boards.
+type Seasons interface {
+	// Admit - nil if an event of ts belongs to the running season, otherwise a *season.Rejection.
+	Admit(ts time.Time) error
+	Seasons() ([]season.Info, error)
+	// Standings - the final standings of an archived season.
+	Standings(id int, skill string, offset, n int) (leader.Page, error)
+}
+
+type SeasonService interface {
+	Seasons(ctx context.Context) ([]season.Info, error)
+	Standings(ctx context.Context, id int, q leader.Query) (leader.Page, error)
+}
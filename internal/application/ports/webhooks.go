@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+
+	"leaderboard-api/internal/domain/milestone"
+)
+
+// Webhooks - the delivery queue of the milestone notifications.
+type Webhooks interface {
+	Deliveries(st milestone.Status) []milestone.Delivery
+	Delivery(id string) (milestone.Delivery, error)
+	// Redrive - sends a dead-lettered delivery again.
+	Redrive(id string) (milestone.Delivery, error)
+}
+
+type WebhookService interface {
+	Deliveries(ctx context.Context, st milestone.Status) ([]milestone.Delivery, error)
+	Delivery(ctx context.Context, id string) (milestone.Delivery, error)
+	Redrive(ctx context.Context, id string) (milestone.Delivery, error)
+}
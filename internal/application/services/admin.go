@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/model"
+)
+
+// AdminService - operations on the service itself, not on the leaderboard data.
+type AdminService struct {
+	// ctx - of the app, a rebuild outlives the request that started it.
+	ctx    context.Context
+	memory ports.LBMemory
+	models ports.ModelRegistry
+	// log - optional, the history of a rebuild.
+	log    ports.EventLog
+	scorer ports.Scorer
+
+	mu      sync.Mutex
+	rebuild leader.Rebuild
+}
+
+func NewAdminService(
+	ctx context.Context,
+	memory ports.LBMemory,
+	models ports.ModelRegistry,
+	log ports.EventLog,
+	scorer ports.Scorer,
+) ports.AdminService {
+	return &AdminService{
+		ctx:     ctx,
+		memory:  memory,
+		models:  models,
+		log:     log,
+		scorer:  scorer,
+		rebuild: leader.Rebuild{State: leader.RebuildIdle},
+	}
+}
+
+// Snapshot - persists all boards right away, without waiting for the snapshot worker.
+func (as *AdminService) Snapshot(ctx context.Context) (leader.Snapshot, error) {
+	return as.memory.Snapshot(ctx)
+}
+
+// Model - the active version of the scoring models.
+func (as *AdminService) Model(_ context.Context) model.Version {
+	return as.models.Active()
+}
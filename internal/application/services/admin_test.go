@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/model"
+)
+
+func TestAdminService_Snapshot(t *testing.T) {
+	taken := leader.Snapshot{Path: "/data/leaderboard.snap", TakenAt: time.Now(), Boards: 2, Talents: 10, Size: 512}
+	failure := errors.New("disk is full")
+
+	tests := []struct {
+		name     string
+		snapshot func(context.Context) (leader.Snapshot, error)
+		want     leader.Snapshot
+		wantErr  error
+	}{
+		{"Taken", func(context.Context) (leader.Snapshot, error) { return taken, nil }, taken, nil},
+		{"Disabled", nil, leader.Snapshot{}, leader.ErrSnapshotDisabled},
+		{"Failed", func(context.Context) (leader.Snapshot, error) { return leader.Snapshot{}, failure }, leader.Snapshot{}, failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAdminService(context.Background(), &mockLBMemory{snapshot: tt.snapshot}, nil, nil, nil)
+
+			got, err := svc.Snapshot(context.Background())
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type mockModelRegistry struct {
+	active model.Version
+}
+
+func (m *mockModelRegistry) Active() model.Version { return m.active }
+
+func TestAdminService_Model(t *testing.T) {
+	active := model.Version{Name: "v2", LoadedAt: time.Now(), Default: "linear", Skills: map[string]string{"pass": "gbt"}}
+	svc := NewAdminService(context.Background(), &mockLBMemory{}, &mockModelRegistry{active: active}, nil, nil)
+
+	require.Equal(t, active, svc.Model(context.Background()))
+}
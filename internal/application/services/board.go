@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/board"
+	"leaderboard-api/internal/domain/season"
+)
+
+// BoardService - the named boards, every one is served by the same services
+// as the default board over its own pipeline.
+type BoardService struct {
+	boards ports.Boards
+}
+
+func NewBoardService(boards ports.Boards) ports.BoardService {
+	return &BoardService{
+		boards: boards,
+	}
+}
+
+func (bs *BoardService) Create(ctx context.Context, b board.Board) (board.Board, error) {
+	if err := b.Validate(); err != nil {
+		return board.Board{}, err
+	}
+
+	return bs.boards.Create(b)
+}
+
+func (bs *BoardService) SetModels(ctx context.Context, name string, models json.RawMessage) (board.Board, error) {
+	return bs.boards.SetModels(name, models)
+}
+
+func (bs *BoardService) Delete(ctx context.Context, name string) error {
+	return bs.boards.Delete(ctx, name)
+}
+
+func (bs *BoardService) List(ctx context.Context) []board.Board {
+	return bs.boards.List()
+}
+
+func (bs *BoardService) Get(ctx context.Context, name string) (board.Board, error) {
+	return bs.boards.Get(name)
+}
+
+func (bs *BoardService) Events(ctx context.Context, name string) (ports.EventService, error) {
+	p, err := bs.boards.Pipeline(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEventService(p.Cache, p.Log, p.Scorer, p.Seasons, p.Metrics), nil
+}
+
+func (bs *BoardService) Leaderboard(ctx context.Context, name string) (ports.LeaderboardService, error) {
+	p, err := bs.boards.Pipeline(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLeaderboardService(p.LB), nil
+}
+
+func (bs *BoardService) Seasons(ctx context.Context, name string) (ports.SeasonService, error) {
+	p, err := bs.boards.Pipeline(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.Seasons == nil {
+		return nil, season.ErrSeasonsDisabled
+	}
+
+	return NewSeasonService(p.Seasons), nil
+}
+
+// Replay - a board that fails doesn't stop the others.
+func (bs *BoardService) Replay(ctx context.Context) (int, error) {
+	var (
+		total int
+		errs  []error
+	)
+	for _, b := range bs.boards.List() {
+		p, err := bs.boards.Pipeline(b.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("board %s: %w", b.Name, err))
+			continue
+		}
+		n, err := NewEventService(p.Cache, p.Log, p.Scorer, p.Seasons, p.Metrics).Replay(ctx, p.LB.Applied)
+		total += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("board %s: %w", b.Name, err))
+		}
+	}
+
+	return total, errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/board"
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/season"
+)
+
+type mockBoards struct {
+	defs      []board.Board
+	pipelines map[string]ports.BoardPipeline
+}
+
+func (m *mockBoards) Create(b board.Board) (board.Board, error) {
+	m.defs = append(m.defs, b)
+	return b, nil
+}
+
+func (m *mockBoards) SetModels(name string, models json.RawMessage) (board.Board, error) {
+	return board.Board{}, board.ErrBoardNotFound
+}
+
+func (m *mockBoards) Delete(ctx context.Context, name string) error { return board.ErrBoardNotFound }
+func (m *mockBoards) List() []board.Board                           { return m.defs }
+
+func (m *mockBoards) Get(name string) (board.Board, error) {
+	for _, b := range m.defs {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+	return board.Board{}, board.ErrBoardNotFound
+}
+
+func (m *mockBoards) Pipeline(name string) (ports.BoardPipeline, error) {
+	p, ok := m.pipelines[name]
+	if !ok {
+		return p, board.ErrBoardNotFound
+	}
+	return p, nil
+}
+
+func newMockPipeline(events int, inCh chan event.Event) ports.BoardPipeline {
+	log := &mockEventLog{}
+	for i := 0; i < events; i++ {
+		_, _ = log.Append(event.Event{EventID: uuid.New(), TalentID: "t1"})
+	}
+	return ports.BoardPipeline{
+		Cache:   &mockCache{},
+		Log:     log,
+		Scorer:  &mockScorer{ch: inCh},
+		LB:      &mockLBMemory{},
+		Metrics: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"result"}),
+	}
+}
+
+func TestBoardService(t *testing.T) {
+	ctx := context.Background()
+	ch1, ch2 := make(chan event.Event, 10), make(chan event.Event, 10)
+	boards := &mockBoards{
+		defs: []board.Board{{Name: "b1"}, {Name: "b2"}, {Name: "gone"}},
+		pipelines: map[string]ports.BoardPipeline{
+			"b1": newMockPipeline(3, ch1),
+			"b2": newMockPipeline(1, ch2),
+		},
+	}
+	svc := NewBoardService(boards)
+
+	_, err := svc.Create(ctx, board.Board{Name: "Bad name"})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+	require.Len(t, boards.defs, 3)
+
+	_, err = svc.Events(ctx, "unknown")
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+	_, err = svc.Leaderboard(ctx, "unknown")
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+	lb, err := svc.Leaderboard(ctx, "b1")
+	require.NoError(t, err)
+	require.NotNil(t, lb)
+	_, err = svc.Seasons(ctx, "b1")
+	require.ErrorIs(t, err, season.ErrSeasonsDisabled)
+
+	// every board into its own scorer, a missing one doesn't stop the rest
+	n, err := svc.Replay(ctx)
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+	require.Equal(t, 4, n)
+	require.Len(t, ch1, 3)
+	require.Len(t, ch2, 1)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -12,42 +13,142 @@ import (
 
 	"leaderboard-api/internal/application/ports"
 	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/season"
 )
 
 type EventService struct {
-	cache   ports.Cache
-	scorer  ports.Scorer
+	cache ports.Cache
+	// log - optional, without it an accepted event lives only in memory
+	// until it reaches the leaderboard.
+	log    ports.EventLog
+	scorer ports.Scorer
+	// seasons - optional, without it an event of any TS is accepted.
+	seasons ports.Seasons
 	metrics *prometheus.CounterVec
 }
 
 func NewEventService(
 	cache ports.Cache,
+	log ports.EventLog,
 	scorer ports.Scorer,
+	seasons ports.Seasons,
 	metrics *prometheus.CounterVec,
 
 ) ports.EventService {
 	return &EventService{
 		cache:   cache,
+		log:     log,
 		scorer:  scorer,
+		seasons: seasons,
 		metrics: metrics,
 	}
 }
 
 func (es *EventService) Create(ctx context.Context, e *event.Event) (bool, error) {
-	duplicate := es.cache.IsSet(e.EventID)
+	// before the dedup, the producer may send it again to the next season
+	if es.seasons != nil {
+		if err := es.seasons.Admit(e.TS); err != nil {
+			es.metrics.WithLabelValues("out_of_season").Inc()
+			return false, err
+		}
+	}
+	// one atomic call, concurrent requests with the same ID can't both pass
+	duplicate := !es.cache.SetIfAbsent(e.EventID, e.TS)
 	if !duplicate {
-		es.cache.Set(e.EventID)
-		es.scorer.GetInputChan() <- *e
+		// shed before it's logged, a shed event is not in the log
+		err := es.scorer.Submit(ctx, *e, es.logEvent)
+		if err != nil {
+			// let the producer retry it
+			es.cache.Delete(e.EventID)
+			return false, err
+		}
 	}
 
 	es.metrics.WithLabelValues("duplicate").Inc()
 	return duplicate, nil
 }
 
+// CreateBatch - every event is created on its own, once the pipeline refuses one
+// the rest of the batch isn't tried, they would wait and be refused as well.
+func (es *EventService) CreateBatch(ctx context.Context, events []*event.Event) []event.Result {
+	results := make([]event.Result, len(events))
+	var refused error
+	for i, e := range events {
+		if refused != nil {
+			results[i] = event.Result{Status: event.StatusRetry, Reason: refused.Error()}
+			continue
+		}
+
+		duplicate, err := es.Create(ctx, e)
+		switch {
+		case errors.Is(err, season.ErrOutsideSeason):
+			results[i] = event.Result{Status: event.StatusInvalid, Reason: err.Error(), Err: err}
+		case err != nil:
+			results[i] = event.Result{Status: event.StatusRetry, Reason: err.Error()}
+			if overloaded(err) {
+				refused = err
+			}
+		case duplicate:
+			results[i] = event.Result{Status: event.StatusDuplicate}
+		default:
+			results[i] = event.Result{Status: event.StatusAccepted}
+		}
+	}
+
+	return results
+}
+
+func overloaded(err error) bool {
+	return errors.Is(err, event.ErrQueueFull) ||
+		errors.Is(err, event.ErrQueueTimeout) ||
+		errors.Is(err, event.ErrQueueClosed) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// logEvent - logged before it's acknowledged.
+func (es *EventService) logEvent(e *event.Event) error {
+	if es.log == nil {
+		return nil
+	}
+	seq, err := es.log.Append(*e)
+	if err != nil {
+		return fmt.Errorf("log event: %w", err)
+	}
+	e.Seq = seq
+
+	return nil
+}
+
 func (es *EventService) Seed(ctx context.Context, cnt int) {
 	for _, val := range generateRandomEvents(cnt) {
-		es.scorer.GetInputChan() <- val
+		if es.scorer.Push(ctx, val) != nil {
+			return
+		}
+	}
+}
+
+// Replay - on start, before any new event is accepted. The replayed IDs
+// are set in the cache, so a retry of such an event is a duplicate.
+func (es *EventService) Replay(ctx context.Context, skip func(seq uint64) bool) (int, error) {
+	if es.log == nil {
+		return 0, nil
 	}
+
+	n := 0
+	err := es.log.Replay(ctx, func(e event.Event) error {
+		if skip(e.Seq) {
+			return nil
+		}
+		es.cache.Set(e.EventID, e.TS)
+		if err := es.scorer.Push(ctx, e); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+
+	return n, err
 }
 
 func generateRandomEvents(n int) []event.Event {
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"strconv"
 	"testing"
 	"time"
@@ -12,11 +14,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/season"
 )
 
 type mockCache struct {
 	isSetFunc func(id uuid.UUID) bool
 	setCalls  map[uuid.UUID]int
+	deleted   []uuid.UUID
 }
 
 func (m *mockCache) IsSet(id uuid.UUID) bool {
@@ -26,21 +30,105 @@ func (m *mockCache) IsSet(id uuid.UUID) bool {
 	return false
 }
 
-func (m *mockCache) Set(id uuid.UUID) {
+func (m *mockCache) Set(id uuid.UUID, _ time.Time) {
 	if m.setCalls == nil {
 		m.setCalls = make(map[uuid.UUID]int)
 	}
 	m.setCalls[id]++
 }
 
+func (m *mockCache) SetIfAbsent(id uuid.UUID, ts time.Time) bool {
+	if m.IsSet(id) {
+		return false
+	}
+	m.Set(id, ts)
+	return true
+}
+
+func (m *mockCache) Delete(id uuid.UUID) {
+	m.deleted = append(m.deleted, id)
+}
+
+type mockEventLog struct {
+	events []event.Event
+	err    error
+	// first - the first seq in the log, 0 - 1
+	first uint64
+}
+
+func (m *mockEventLog) Append(e event.Event) (uint64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.events = append(m.events, e)
+	return uint64(len(m.events)), nil
+}
+
+func (m *mockEventLog) Replay(ctx context.Context, fn func(e event.Event) error) error {
+	_, err := m.ReplayFrom(ctx, 1, fn)
+	return err
+}
+
+func (m *mockEventLog) ReplayFrom(_ context.Context, from uint64, fn func(e event.Event) error) (uint64, error) {
+	next := max(from, 1)
+	for i, e := range m.events {
+		e.Seq = uint64(i + 1)
+		if e.Seq < from {
+			continue
+		}
+		if err := fn(e); err != nil {
+			return next, err
+		}
+		next = e.Seq + 1
+	}
+	return next, nil
+}
+
+func (m *mockEventLog) Bounds() (uint64, uint64) {
+	return max(m.first, 1), uint64(len(m.events)) + 1
+}
+
 type mockScorer struct {
-	ch chan event.Event
+	ch  chan event.Event
+	err error
+	// shed - Submit refuses the events with it
+	shed error
 }
 
 func (m *mockScorer) RunScorerPool(ctx context.Context, size int) {}
 func (m *mockScorer) GetOutChan() chan event.Event                { return make(chan event.Event) }
-func (m *mockScorer) GetInputChan() chan event.Event              { return m.ch }
-func (m *mockScorer) ClosePool(ctx context.Context)               {}
+func (m *mockScorer) ClosePool(ctx context.Context) error         { return nil }
+
+func (m *mockScorer) Submit(ctx context.Context, e event.Event, accept func(*event.Event) error) error {
+	if m.shed != nil {
+		return m.shed
+	}
+	if err := accept(&e); err != nil {
+		return err
+	}
+	return m.Push(ctx, e)
+}
+
+func (m *mockScorer) Push(ctx context.Context, e event.Event) error {
+	select {
+	case m.ch <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Score - 2*raw by the "mock" model.
+func (m *mockScorer) Score(_ context.Context, events []event.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range events {
+		events[i].Score = 2 * events[i].RawMetric
+		events[i].ModelVersion = "mock"
+	}
+	return nil
+}
 
 func TestEventService_Create(t *testing.T) {
 	t.Parallel()
@@ -99,7 +187,7 @@ func TestEventService_Create(t *testing.T) {
 			reg := prometheus.NewRegistry()
 			require.NoError(t, reg.Register(metrics))
 
-			svc := NewEventService(cache, sc, metrics)
+			svc := NewEventService(cache, nil, sc, nil, metrics)
 
 			dup, err := svc.Create(context.Background(), ev)
 
@@ -133,6 +221,144 @@ func TestEventService_Create(t *testing.T) {
 	}
 }
 
+func TestEventService_Create_EventLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		logErr     error
+		shed       error
+		wantSeq    uint64
+		wantErr    bool
+		wantSent   bool
+		wantDelete bool
+	}{
+		{name: "Logged before sent", wantSeq: 1, wantSent: true},
+		{name: "Log failure, the producer retries", logErr: errors.New("disk is full"), wantErr: true, wantDelete: true},
+		{name: "Shed before logged, the producer retries", shed: event.ErrQueueFull, wantErr: true, wantDelete: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mockCache{}
+			log := &mockEventLog{err: tt.logErr}
+			inCh := make(chan event.Event, 1)
+			metrics := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"result"})
+			svc := NewEventService(cache, log, &mockScorer{ch: inCh, shed: tt.shed}, nil, metrics)
+
+			ev := &event.Event{EventID: uuid.New(), TalentID: "t-001", TS: time.Now().UTC()}
+			dup, err := svc.Create(context.Background(), ev)
+			require.False(t, dup)
+			require.Equal(t, tt.wantErr, err != nil)
+			if tt.shed != nil {
+				require.ErrorIs(t, err, tt.shed)
+				require.Empty(t, log.events)
+			}
+			require.Equal(t, tt.wantDelete, slices.Contains(cache.deleted, ev.EventID))
+
+			if tt.wantSent {
+				got := <-inCh
+				require.Equal(t, tt.wantSeq, got.Seq)
+				require.Len(t, log.events, 1)
+			} else {
+				require.Empty(t, inCh)
+			}
+		})
+	}
+}
+
+func TestEventService_CreateBatch(t *testing.T) {
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+	batch := func() []*event.Event {
+		return []*event.Event{{EventID: id1}, {EventID: id2}, {EventID: id1}, {EventID: id3}}
+	}
+
+	tests := []struct {
+		name     string
+		shed     error
+		want     []event.Result
+		wantSent int
+	}{
+		{"Dedup inside the batch", nil, []event.Result{
+			{Status: event.StatusAccepted},
+			{Status: event.StatusAccepted},
+			{Status: event.StatusDuplicate},
+			{Status: event.StatusAccepted},
+		}, 3},
+		{"Overload refuses the rest", event.ErrQueueFull, []event.Result{
+			{Status: event.StatusRetry, Reason: event.ErrQueueFull.Error()},
+			{Status: event.StatusRetry, Reason: event.ErrQueueFull.Error()},
+			{Status: event.StatusRetry, Reason: event.ErrQueueFull.Error()},
+			{Status: event.StatusRetry, Reason: event.ErrQueueFull.Error()},
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mockCache{}
+			cache.isSetFunc = func(id uuid.UUID) bool { return cache.setCalls[id] > 0 }
+			inCh := make(chan event.Event, 10)
+			metrics := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"result"})
+			svc := NewEventService(cache, nil, &mockScorer{ch: inCh, shed: tt.shed}, nil, metrics)
+
+			require.Equal(t, tt.want, svc.CreateBatch(context.Background(), batch()))
+			require.Len(t, inCh, tt.wantSent)
+			if tt.shed != nil {
+				// only the first one was tried, it's forgotten for the retry
+				require.Equal(t, []uuid.UUID{id1}, cache.deleted)
+			}
+		})
+	}
+}
+
+func TestEventService_OutOfSeason(t *testing.T) {
+	start := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	seasons := &mockSeasons{schedule: season.Schedule{Start: start, Length: 24 * time.Hour}, now: start.Add(time.Hour)}
+	cache := &mockCache{}
+	inCh := make(chan event.Event, 10)
+	metrics := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"result"})
+	svc := NewEventService(cache, nil, &mockScorer{ch: inCh}, seasons, metrics)
+
+	_, err := svc.Create(context.Background(), &event.Event{EventID: uuid.New(), TS: start.Add(-time.Second)})
+	var rejected *season.Rejection
+	require.ErrorAs(t, err, &rejected)
+	require.Equal(t, season.ReasonBeforeSeason, rejected.Reason)
+	require.Empty(t, cache.setCalls, "a rejected event is not deduplicated")
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.WithLabelValues("out_of_season")))
+
+	results := svc.CreateBatch(context.Background(), []*event.Event{
+		{EventID: uuid.New(), TS: start},
+		{EventID: uuid.New(), TS: start.Add(24 * time.Hour)},
+	})
+	require.Equal(t, event.StatusAccepted, results[0].Status)
+	require.Equal(t, event.StatusInvalid, results[1].Status)
+	require.ErrorIs(t, results[1].Err, season.ErrOutsideSeason)
+	require.Contains(t, results[1].Reason, "after the end of season 1")
+	require.Len(t, inCh, 1)
+}
+
+func TestEventService_Replay(t *testing.T) {
+	log := &mockEventLog{}
+	for i := 0; i < 4; i++ {
+		_, _ = log.Append(event.Event{EventID: uuid.New(), TalentID: "t-" + strconv.Itoa(i)})
+	}
+	cache := &mockCache{}
+	inCh := make(chan event.Event, 10)
+	metrics := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"result"})
+	svc := NewEventService(cache, log, &mockScorer{ch: inCh}, nil, metrics)
+
+	// 1 and 3 are on the boards already
+	n, err := svc.Replay(context.Background(), func(seq uint64) bool { return seq%2 == 1 })
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	close(inCh)
+
+	var seqs []uint64
+	for e := range inCh {
+		seqs = append(seqs, e.Seq)
+		require.Equal(t, 1, cache.setCalls[e.EventID], "replayed IDs are duplicates from now on")
+	}
+	require.Equal(t, []uint64{2, 4}, seqs)
+}
+
 func TestRound(t *testing.T) {
 	t.Parallel()
 
@@ -19,15 +19,45 @@ func NewLeaderboardService(
 	}
 }
 
-func (ls *LeaderboardService) GetBboard(ctx context.Context, limit int) (leader.Leaders, error) {
-	return ls.memory.TopN(limit), nil
+func (ls *LeaderboardService) GetBboard(ctx context.Context, q leader.Query) (leader.Page, error) {
+	if q.Limit <= 0 {
+		return leader.Page{Leaders: leader.Leaders{}}, nil
+	}
+	if q.After != nil {
+		return ls.memory.After(q.Scope, *q.After, q.Limit), nil
+	}
+
+	return ls.memory.Range(q.Scope, q.Offset, q.Limit), nil
 }
 
-func (ls *LeaderboardService) GetRankByID(ctx context.Context, id string) (leader.Leader, error) {
-	l, ok := ls.memory.RankOf(id)
+func (ls *LeaderboardService) GetRankByID(ctx context.Context, s leader.Scope, id string) (leader.Leader, error) {
+	l, ok := ls.memory.RankOf(s, id)
 	if !ok {
 		return leader.Leader{}, nil
 	}
 
 	return l, nil
 }
+
+// GetAround - empty result means the talent is not on the board.
+func (ls *LeaderboardService) GetAround(ctx context.Context, s leader.Scope, id string, radius int) (leader.Leaders, error) {
+	leaders, ok := ls.memory.Around(s, id, radius)
+	if !ok {
+		return leader.Leaders{}, nil
+	}
+
+	return leaders, nil
+}
+
+// Subscribe - lastID 0 starts from a snapshot.
+func (ls *LeaderboardService) Subscribe(ctx context.Context, w leader.Watch, lastID uint64) (ports.LBSubscription, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	return ls.memory.Subscribe(w, lastID)
+}
+
+func (ls *LeaderboardService) RankType(ctx context.Context) leader.RankType {
+	return ls.memory.RankType()
+}
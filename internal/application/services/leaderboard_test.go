@@ -4,46 +4,115 @@ import (
 	"context"
 	"testing"
 
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/event"
 	"leaderboard-api/internal/domain/leader"
 
 	"github.com/stretchr/testify/require"
 )
 
 type mockLBMemory struct {
-	topN   func(int) leader.Leaders
-	rankOf func(string) (leader.Leader, bool)
+	topN     func(int) leader.Leaders
+	rangeFn  func(leader.Scope, int, int) leader.Page
+	after    func(leader.Scope, leader.Cursor, int) leader.Page
+	rankOf   func(leader.Scope, string) (leader.Leader, bool)
+	around   func(leader.Scope, string, int) (leader.Leaders, bool)
+	snapshot func(context.Context) (leader.Snapshot, error)
+	swap     func(ports.LBShadow, func() error) error
+	// watches - the watches subscribed to
+	watches []leader.Watch
 }
 
 func (m *mockLBMemory) TopN(n int) leader.Leaders {
 	return m.topN(n)
 }
 
-func (m *mockLBMemory) RankOf(id string) (leader.Leader, bool) {
-	return m.rankOf(id)
+func (m *mockLBMemory) Range(s leader.Scope, offset, n int) leader.Page {
+	return m.rangeFn(s, offset, n)
+}
+
+func (m *mockLBMemory) After(s leader.Scope, c leader.Cursor, n int) leader.Page {
+	return m.after(s, c, n)
+}
+
+func (m *mockLBMemory) RankOf(s leader.Scope, id string) (leader.Leader, bool) {
+	return m.rankOf(s, id)
+}
+
+func (m *mockLBMemory) Around(s leader.Scope, id string, radius int) (leader.Leaders, bool) {
+	return m.around(s, id, radius)
 }
 
 func (m *mockLBMemory) RunLBWorker(_ context.Context)    {}
 func (m *mockLBMemory) StopRankWorker(_ context.Context) {}
 func (m *mockLBMemory) All() leader.Leaders              { return make(leader.Leaders, 0) }
+func (m *mockLBMemory) RankType() leader.RankType        { return leader.RankOrdinal }
+
+func (m *mockLBMemory) Snapshot(ctx context.Context) (leader.Snapshot, error) {
+	if m.snapshot == nil {
+		return leader.Snapshot{}, leader.ErrSnapshotDisabled
+	}
+	return m.snapshot(ctx)
+}
+
+func (m *mockLBMemory) NewShadow() ports.LBShadow { return &mockShadow{} }
+
+func (m *mockLBMemory) Swap(shadow ports.LBShadow, catchUp func() error) error {
+	return m.swap(shadow, catchUp)
+}
+
+func (m *mockLBMemory) Subscribe(w leader.Watch, lastID uint64) (ports.LBSubscription, error) {
+	m.watches = append(m.watches, w)
+	return nil, nil
+}
+
+func (m *mockLBMemory) CloseStreams()           {}
+func (m *mockLBMemory) Applied(seq uint64) bool { return false }
+
+type mockShadow struct {
+	events []event.Event
+}
+
+func (m *mockShadow) Apply(events []event.Event) { m.events = append(m.events, events...) }
 
 func TestLeaderboardService_GetBboard(t *testing.T) {
+	rows := leader.Leaders{
+		{Rank: 1, TalentID: "t-1", Score: 100},
+		{Rank: 2, TalentID: "t-2", Score: 90},
+		{Rank: 3, TalentID: "t-3", Score: 80},
+	}
+
 	tests := []struct {
-		name     string
-		limit    int
-		expected leader.Leaders
+		name       string
+		query      leader.Query
+		wantOffset int
+		wantAfter  *leader.Cursor
+		expected   leader.Page
 	}{
 		{
-			name:  "Returns Top 2",
-			limit: 2,
-			expected: leader.Leaders{
-				{TalentID: "t-1", Score: 100},
-				{TalentID: "t-2", Score: 90},
+			name:  "Returns Top 2 with next cursor",
+			query: leader.Query{Limit: 2},
+			expected: leader.Page{
+				Leaders: rows[:2],
+				Next:    &leader.Cursor{Key: 90, TalentID: "t-2"},
 			},
 		},
+		{
+			name:       "Last page by offset",
+			query:      leader.Query{Scope: leader.Scope{Skill: "pass"}, Limit: 2, Offset: 2},
+			wantOffset: 2,
+			expected:   leader.Page{Leaders: rows[2:]},
+		},
+		{
+			name:      "Page after cursor",
+			query:     leader.Query{Limit: 5, After: &leader.Cursor{Key: 100, TalentID: "t-1"}},
+			wantAfter: &leader.Cursor{Key: 100, TalentID: "t-1"},
+			expected:  leader.Page{Leaders: rows[1:]},
+		},
 		{
 			name:     "Returns Empty",
-			limit:    0,
-			expected: leader.Leaders{},
+			query:    leader.Query{Limit: 0},
+			expected: leader.Page{Leaders: leader.Leaders{}},
 		},
 	}
 
@@ -51,13 +120,23 @@ func TestLeaderboardService_GetBboard(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			mock := &mockLBMemory{
-				topN: func(n int) leader.Leaders {
+				rangeFn: func(s leader.Scope, offset, n int) leader.Page {
+					require.Equal(t, tt.query.Scope, s)
+					require.Nil(t, tt.wantAfter)
+					require.Equal(t, tt.wantOffset, offset)
+					require.Equal(t, tt.query.Limit, n)
+					return tt.expected
+				},
+				after: func(s leader.Scope, c leader.Cursor, n int) leader.Page {
+					require.Equal(t, tt.query.Scope, s)
+					require.Equal(t, *tt.wantAfter, c)
+					require.Equal(t, tt.query.Limit, n)
 					return tt.expected
 				},
 			}
 
 			svc := NewLeaderboardService(mock)
-			got, err := svc.GetBboard(context.Background(), tt.limit)
+			got, err := svc.GetBboard(context.Background(), tt.query)
 			require.NoError(t, err)
 			require.Equal(t, tt.expected, got)
 		})
@@ -92,16 +171,95 @@ func TestLeaderboardService_GetRankByID(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			mock := &mockLBMemory{
-				rankOf: func(id string) (leader.Leader, bool) {
+				rankOf: func(s leader.Scope, id string) (leader.Leader, bool) {
+					require.Equal(t, leader.Scope{Skill: "pass"}, s)
 					require.Equal(t, tt.id, id)
 					return tt.mockOut, tt.mockOk
 				},
 			}
 
 			svc := NewLeaderboardService(mock)
-			got, err := svc.GetRankByID(context.Background(), tt.id)
+			got, err := svc.GetRankByID(context.Background(), leader.Scope{Skill: "pass"}, tt.id)
 			require.NoError(t, err)
 			require.Equal(t, tt.expected, got)
 		})
 	}
 }
+
+func TestLeaderboardService_GetAround(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		mockOut  leader.Leaders
+		mockOk   bool
+		expected leader.Leaders
+	}{
+		{
+			name: "Found",
+			id:   "t-2",
+			mockOut: leader.Leaders{
+				{Rank: 1, TalentID: "t-1", Score: 95},
+				{Rank: 2, TalentID: "t-2", Score: 90},
+			},
+			mockOk: true,
+			expected: leader.Leaders{
+				{Rank: 1, TalentID: "t-1", Score: 95},
+				{Rank: 2, TalentID: "t-2", Score: 90},
+			},
+		},
+		{
+			name:     "Not found",
+			id:       "t-999",
+			mockOk:   false,
+			expected: leader.Leaders{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockLBMemory{
+				around: func(s leader.Scope, id string, radius int) (leader.Leaders, bool) {
+					require.Equal(t, leader.Scope{}, s)
+					require.Equal(t, tt.id, id)
+					require.Equal(t, 1, radius)
+					return tt.mockOut, tt.mockOk
+				},
+			}
+
+			svc := NewLeaderboardService(mock)
+			got, err := svc.GetAround(context.Background(), leader.Scope{}, tt.id, 1)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLeaderboardService_Subscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		watch   leader.Watch
+		wantErr error
+	}{
+		{"Top N", leader.Watch{TopN: 10}, nil},
+		{"Talents of a skill", leader.Watch{Skill: "pass", Talents: []string{"t-1", "t-2"}}, nil},
+		{"Nothing to watch", leader.Watch{Skill: "pass"}, leader.ErrInvalidWatch},
+		{"Both", leader.Watch{TopN: 10, Talents: []string{"t-1"}}, leader.ErrInvalidWatch},
+		{"Top too long", leader.Watch{TopN: leader.MaxWatchTop + 1}, leader.ErrInvalidWatch},
+		{"Too many talents", leader.Watch{Talents: make([]string, leader.MaxWatchTalents+1)}, leader.ErrInvalidWatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockLBMemory{}
+			svc := NewLeaderboardService(mock)
+			_, err := svc.Subscribe(context.Background(), tt.watch, 0)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				require.Empty(t, mock.watches)
+				return
+			}
+			require.Equal(t, []leader.Watch{tt.watch}, mock.watches)
+		})
+	}
+}
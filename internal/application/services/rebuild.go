@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+const (
+	// rebuildBatch - events scored in one call.
+	rebuildBatch = 256
+	// catchUpPasses, catchUpEvents - the history is replayed again while the
+	// pass has more than catchUpEvents new events, so the last pass under the
+	// leaderboard lock is short.
+	catchUpPasses = 5
+	catchUpEvents = 1000
+)
+
+// Rebuild - replays the event log through the scorer into shadow boards
+// and swaps them in when they have caught up with the log. The live boards
+// keep serving and accepting events meanwhile.
+func (as *AdminService) Rebuild(_ context.Context) (leader.Rebuild, error) {
+	if as.log == nil {
+		return leader.Rebuild{}, fmt.Errorf("%w: the event log is disabled", leader.ErrNoHistory)
+	}
+	first, next := as.log.Bounds()
+	if first > 1 {
+		return leader.Rebuild{}, fmt.Errorf("%w: events before %d are compacted", leader.ErrNoHistory, first)
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.rebuild.State == leader.RebuildRunning {
+		return as.rebuild, leader.ErrRebuildRunning
+	}
+	as.rebuild = leader.Rebuild{
+		State:     leader.RebuildRunning,
+		StartedAt: time.Now(),
+		Total:     next - 1,
+	}
+	go as.runRebuild(as.ctx)
+
+	return as.rebuild, nil
+}
+
+func (as *AdminService) RebuildStatus(_ context.Context) leader.Rebuild {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.rebuild
+}
+
+func (as *AdminService) runRebuild(ctx context.Context) {
+	err := as.rebuildBoards(ctx)
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.rebuild.FinishedAt = time.Now()
+	as.rebuild.State = leader.RebuildDone
+	if err != nil {
+		as.rebuild.State = leader.RebuildFailed
+		as.rebuild.Error = err.Error()
+	}
+}
+
+func (as *AdminService) rebuildBoards(ctx context.Context) error {
+	shadow := as.memory.NewShadow()
+
+	from := uint64(1)
+	for range catchUpPasses {
+		n, next, err := as.replayInto(ctx, shadow, from)
+		if err != nil {
+			return err
+		}
+		from = next
+		if n <= catchUpEvents {
+			break
+		}
+	}
+
+	// the events logged after the last pass
+	return as.memory.Swap(shadow, func() error {
+		_, _, err := as.replayInto(ctx, shadow, from)
+		return err
+	})
+}
+
+// replayInto - scores the logged events from the seq `from` and applies them to the shadow.
+func (as *AdminService) replayInto(ctx context.Context, shadow ports.LBShadow, from uint64) (int, uint64, error) {
+	batch := make([]event.Event, 0, rebuildBatch)
+	n := 0
+	flush := func() error {
+		if err := as.scorer.Score(ctx, batch); err != nil {
+			return fmt.Errorf("rebuild scoring: %w", err)
+		}
+		shadow.Apply(batch)
+		n += len(batch)
+
+		as.mu.Lock()
+		as.rebuild.Processed += uint64(len(batch))
+		as.mu.Unlock()
+
+		batch = batch[:0]
+		return nil
+	}
+
+	next, err := as.log.ReplayFrom(ctx, from, func(e event.Event) error {
+		batch = append(batch, e)
+		if len(batch) == rebuildBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return n, next, fmt.Errorf("rebuild replay: %w", err)
+	}
+
+	return n, next, flush()
+}
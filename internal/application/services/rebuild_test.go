@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+func waitRebuild(t *testing.T, svc ports.AdminService) leader.Rebuild {
+	t.Helper()
+	var status leader.Rebuild
+	require.Eventually(t, func() bool {
+		status = svc.RebuildStatus(context.Background())
+		return status.State != leader.RebuildRunning
+	}, time.Second, time.Millisecond)
+
+	return status
+}
+
+func loggedEvents(n int) []event.Event {
+	events := make([]event.Event, n)
+	for i := range events {
+		events[i] = event.Event{EventID: uuid.New(), TalentID: "t-1", RawMetric: float64(i + 1)}
+	}
+	return events
+}
+
+func TestAdminService_Rebuild(t *testing.T) {
+	log := &mockEventLog{events: loggedEvents(600)}
+	var swapped *mockShadow
+	memory := &mockLBMemory{swap: func(shadow ports.LBShadow, catchUp func() error) error {
+		// accepted while the shadow was built
+		log.events = append(log.events, event.Event{EventID: uuid.New(), TalentID: "t-2", RawMetric: 1000})
+		if err := catchUp(); err != nil {
+			return err
+		}
+		swapped = shadow.(*mockShadow)
+		return nil
+	}}
+	svc := NewAdminService(context.Background(), memory, nil, log, &mockScorer{})
+
+	require.Equal(t, leader.RebuildIdle, svc.RebuildStatus(context.Background()).State)
+	started, err := svc.Rebuild(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, leader.RebuildRunning, started.State)
+	require.Equal(t, uint64(600), started.Total)
+
+	status := waitRebuild(t, svc)
+	require.Equal(t, leader.RebuildDone, status.State, status.Error)
+	require.Equal(t, uint64(601), status.Processed)
+	require.False(t, status.FinishedAt.IsZero())
+
+	// every logged event is rescored by the current model, in the log order
+	require.Len(t, swapped.events, 601)
+	for i, e := range swapped.events {
+		require.Equal(t, uint64(i+1), e.Seq)
+		require.Equal(t, 2*e.RawMetric, e.Score)
+		require.Equal(t, "mock", e.ModelVersion)
+	}
+
+	// can run again when finished
+	_, err = svc.Rebuild(context.Background())
+	require.NoError(t, err)
+	waitRebuild(t, svc)
+}
+
+func TestAdminService_RebuildErrors(t *testing.T) {
+	failure := errors.New("model server is down")
+
+	tests := []struct {
+		name    string
+		log     ports.EventLog
+		scorer  *mockScorer
+		wantErr error
+		// wantFailed - the job starts and fails
+		wantFailed string
+	}{
+		{"No event log", nil, &mockScorer{}, leader.ErrNoHistory, ""},
+		{"Compacted history", &mockEventLog{events: loggedEvents(3), first: 2}, &mockScorer{}, leader.ErrNoHistory, ""},
+		{"Scoring fails", &mockEventLog{events: loggedEvents(3)}, &mockScorer{err: failure}, nil, failure.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := &mockLBMemory{swap: func(ports.LBShadow, func() error) error {
+				t.Fatal("must not be swapped")
+				return nil
+			}}
+			svc := NewAdminService(context.Background(), memory, nil, tt.log, tt.scorer)
+
+			_, err := svc.Rebuild(context.Background())
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantFailed == "" {
+				require.Equal(t, leader.RebuildIdle, svc.RebuildStatus(context.Background()).State)
+				return
+			}
+			status := waitRebuild(t, svc)
+			require.Equal(t, leader.RebuildFailed, status.State)
+			require.Contains(t, status.Error, tt.wantFailed)
+		})
+	}
+}
+
+func TestAdminService_RebuildRunning(t *testing.T) {
+	release := make(chan struct{})
+	memory := &mockLBMemory{swap: func(_ ports.LBShadow, catchUp func() error) error {
+		<-release
+		return catchUp()
+	}}
+	svc := NewAdminService(context.Background(), memory, nil, &mockEventLog{events: loggedEvents(3)}, &mockScorer{})
+
+	_, err := svc.Rebuild(context.Background())
+	require.NoError(t, err)
+	status, err := svc.Rebuild(context.Background())
+	require.ErrorIs(t, err, leader.ErrRebuildRunning)
+	require.Equal(t, leader.RebuildRunning, status.State)
+
+	close(release)
+	require.Equal(t, leader.RebuildDone, waitRebuild(t, svc).State)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+type SeasonService struct {
+	seasons ports.Seasons
+}
+
+func NewSeasonService(seasons ports.Seasons) ports.SeasonService {
+	return &SeasonService{
+		seasons: seasons,
+	}
+}
+
+func (ss *SeasonService) Seasons(ctx context.Context) ([]season.Info, error) {
+	return ss.seasons.Seasons()
+}
+
+// Standings - the archived all-time boards by offset, no cursor.
+func (ss *SeasonService) Standings(ctx context.Context, id int, q leader.Query) (leader.Page, error) {
+	page, err := ss.seasons.Standings(id, q.Scope.Skill, q.Offset, q.Limit)
+	if err != nil {
+		return leader.Page{}, err
+	}
+	if page.Leaders == nil {
+		page.Leaders = leader.Leaders{}
+	}
+
+	return page, nil
+}
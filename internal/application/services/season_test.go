@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+type mockSeasons struct {
+	schedule  season.Schedule
+	now       time.Time
+	archives  map[int]leader.Leaders
+	lastSkill string
+}
+
+func (m *mockSeasons) Admit(ts time.Time) error {
+	return m.schedule.Admit(ts, m.now)
+}
+
+func (m *mockSeasons) Seasons() ([]season.Info, error) {
+	var infos []season.Info
+	for id := range m.archives {
+		infos = append(infos, season.Info{Season: m.schedule.Nth(id), State: season.StateArchived})
+	}
+	return infos, nil
+}
+
+func (m *mockSeasons) Standings(id int, skill string, offset, n int) (leader.Page, error) {
+	ls, ok := m.archives[id]
+	if !ok {
+		return leader.Page{}, season.ErrSeasonNotFound
+	}
+	m.lastSkill = skill
+	if offset >= len(ls) {
+		return leader.Page{}, nil
+	}
+	return leader.Page{Leaders: ls[offset:min(offset+n, len(ls))]}, nil
+}
+
+func TestSeasonService_Standings(t *testing.T) {
+	ctx := context.Background()
+	seasons := &mockSeasons{archives: map[int]leader.Leaders{
+		1: {{Rank: 1, TalentID: "t1"}, {Rank: 2, TalentID: "t2"}},
+	}}
+	svc := NewSeasonService(seasons)
+
+	page, err := svc.Standings(ctx, 1, leader.Query{Scope: leader.Scope{Skill: "pass"}, Offset: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, leader.Leaders{{Rank: 2, TalentID: "t2"}}, page.Leaders)
+	require.Equal(t, "pass", seasons.lastSkill)
+
+	page, err = svc.Standings(ctx, 1, leader.Query{Offset: 5, Limit: 10})
+	require.NoError(t, err)
+	require.NotNil(t, page.Leaders, "an empty page is an empty list")
+
+	_, err = svc.Standings(ctx, 2, leader.Query{Limit: 10})
+	require.ErrorIs(t, err, season.ErrSeasonNotFound)
+}
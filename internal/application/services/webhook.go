@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/milestone"
+)
+
+type WebhookService struct {
+	// webhooks - nil when no webhook is registered.
+	webhooks ports.Webhooks
+}
+
+func NewWebhookService(webhooks ports.Webhooks) ports.WebhookService {
+	return &WebhookService{
+		webhooks: webhooks,
+	}
+}
+
+func (ws *WebhookService) Deliveries(ctx context.Context, st milestone.Status) ([]milestone.Delivery, error) {
+	if ws.webhooks == nil {
+		return nil, milestone.ErrWebhooksDisabled
+	}
+
+	return ws.webhooks.Deliveries(st), nil
+}
+
+func (ws *WebhookService) Delivery(ctx context.Context, id string) (milestone.Delivery, error) {
+	if ws.webhooks == nil {
+		return milestone.Delivery{}, milestone.ErrWebhooksDisabled
+	}
+
+	return ws.webhooks.Delivery(id)
+}
+
+func (ws *WebhookService) Redrive(ctx context.Context, id string) (milestone.Delivery, error) {
+	if ws.webhooks == nil {
+		return milestone.Delivery{}, milestone.ErrWebhooksDisabled
+	}
+
+	return ws.webhooks.Redrive(id)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/milestone"
+)
+
+type mockWebhooks struct {
+	deliveries map[string]milestone.Delivery
+}
+
+func (m *mockWebhooks) Deliveries(st milestone.Status) []milestone.Delivery {
+	var ds []milestone.Delivery
+	for _, d := range m.deliveries {
+		if d.Status == st {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+func (m *mockWebhooks) Delivery(id string) (milestone.Delivery, error) {
+	d, ok := m.deliveries[id]
+	if !ok {
+		return d, milestone.ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (m *mockWebhooks) Redrive(id string) (milestone.Delivery, error) {
+	d, err := m.Delivery(id)
+	if err != nil {
+		return d, err
+	}
+	if d.Status != milestone.StatusDead {
+		return d, milestone.ErrNotDead
+	}
+	d.Status = milestone.StatusPending
+	m.deliveries[id] = d
+	return d, nil
+}
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+	svc := NewWebhookService(&mockWebhooks{deliveries: map[string]milestone.Delivery{
+		"d1": {ID: "d1", Status: milestone.StatusDead},
+		"d2": {ID: "d2", Status: milestone.StatusDelivered},
+	}})
+
+	dead, err := svc.Deliveries(ctx, milestone.StatusDead)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+
+	d, err := svc.Redrive(ctx, "d1")
+	require.NoError(t, err)
+	require.Equal(t, milestone.StatusPending, d.Status)
+	_, err = svc.Redrive(ctx, "d2")
+	require.ErrorIs(t, err, milestone.ErrNotDead)
+	_, err = svc.Delivery(ctx, "d3")
+	require.ErrorIs(t, err, milestone.ErrDeliveryNotFound)
+}
+
+func TestWebhookService_Disabled(t *testing.T) {
+	ctx := context.Background()
+	svc := NewWebhookService(nil)
+
+	_, err := svc.Deliveries(ctx, milestone.StatusDead)
+	require.ErrorIs(t, err, milestone.ErrWebhooksDisabled)
+	_, err = svc.Delivery(ctx, "d1")
+	require.ErrorIs(t, err, milestone.ErrWebhooksDisabled)
+	_, err = svc.Redrive(ctx, "d1")
+	require.ErrorIs(t, err, milestone.ErrWebhooksDisabled)
+}
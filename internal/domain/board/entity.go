@@ -0,0 +1,58 @@
+package board
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"leaderboard-api/internal/domain/season"
+)
+
+var (
+	ErrBoardNotFound = errors.New("board not found")
+	ErrBoardExists   = errors.New("board already exists")
+	ErrInvalidBoard  = errors.New("invalid board")
+	// ErrBoardsClosed - the service is shutting down.
+	ErrBoardsClosed = errors.New("boards are closed")
+)
+
+var name = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Board - a named leaderboard with its own events, dedup namespace, scoring and aggregation.
+type Board struct {
+	Name string
+	// Aggregation - max, min, sum, latest, avg(of the last AvgLast scores) or decay,
+	// empty - the one of the default board.
+	Aggregation   string
+	AvgLast       int
+	DecayHalfLife time.Duration
+	// TieBreak - talent_id or earliest, RankType - ordinal, dense or competition,
+	// empty - the ones of the default board.
+	TieBreak string
+	RankType string
+	// Models - the scoring models in the models file format, empty - the score is the raw metric.
+	Models json.RawMessage
+	// Season - the season schedule of the board, the zero one - no seasons.
+	Season    season.Schedule
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (b Board) Validate() error {
+	if !name.MatchString(b.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidBoard, name)
+	}
+	if b.AvgLast < 0 {
+		return fmt.Errorf("%w: avg_last must be >= 0", ErrInvalidBoard)
+	}
+	if b.DecayHalfLife < 0 {
+		return fmt.Errorf("%w: decay_half_life must be >= 0", ErrInvalidBoard)
+	}
+	if err := b.Season.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBoard, err)
+	}
+
+	return nil
+}
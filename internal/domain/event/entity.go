@@ -1,6 +1,7 @@
 package event
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,4 +14,36 @@ type Event struct {
 	Skill     string
 	TS        time.Time
 	Score     float64
+	// ModelVersion - version of the scoring models that produced Score.
+	ModelVersion string
+	// Seq - position in the event log, 0 if the event is not logged.
+	Seq uint64
+}
+
+var (
+	// ErrQueueFull - the event is shed, the ingest queue is (nearly) full.
+	ErrQueueFull = errors.New("ingest queue is full")
+	// ErrQueueTimeout - no room in the ingest queue within the max wait.
+	ErrQueueTimeout = errors.New("ingest queue wait timed out")
+	// ErrQueueClosed - the service is shutting down.
+	ErrQueueClosed = errors.New("ingest queue is closed")
+)
+
+// Status - outcome of an event of a batch.
+type Status string
+
+const (
+	StatusAccepted  Status = "accepted"
+	StatusDuplicate Status = "duplicate"
+	StatusInvalid   Status = "invalid"
+	// StatusRetry - not accepted(overload, shutdown, log failure), the producer should send it again.
+	StatusRetry Status = "retry"
+)
+
+type Result struct {
+	Status Status
+	// Reason - why it's invalid or should be retried.
+	Reason string
+	// Err - of an event refused by the pipeline as invalid, e.g. outside of the season.
+	Err error
 }
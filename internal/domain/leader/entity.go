@@ -1,5 +1,28 @@
 package leader
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSnapshotDisabled = errors.New("leaderboard snapshots are disabled")
+	ErrRebuildRunning   = errors.New("a rebuild is already running")
+	// ErrNoHistory - the event log is disabled or its oldest events are compacted.
+	ErrNoHistory = errors.New("the full event history is not available")
+	// ErrInvalidWatch - a stream needs either a top N or watched talents, within the limits.
+	ErrInvalidWatch = errors.New("invalid stream subscription")
+	// ErrSlowConsumer - the subscriber didn't keep up with its stream and was dropped.
+	ErrSlowConsumer = errors.New("stream subscriber is too slow")
+	ErrStreamClosed = errors.New("stream is closed")
+)
+
+// Stream limits of one subscription.
+const (
+	MaxWatchTop     = 100
+	MaxWatchTalents = 100
+)
+
 type (
 	Leader struct {
 		Rank     int
@@ -7,4 +30,170 @@ type (
 		Score    float64
 	}
 	Leaders []*Leader
+
+	// Cursor - position of a row in the leaderboard.
+	// Key(ranking key of the aggregation) + Achieved + TalentID is the key of the row,
+	// so a cursor stays valid even when the ranks around it are changing.
+	Cursor struct {
+		Key float64
+		// Achieved - unix nanoseconds of the key, set by the earliest tie-break only.
+		Achieved int64
+		TalentID string
+	}
+
+	// RankType - how the talents with the same ranking key are numbered,
+	// they are always listed in the tie-break order.
+	RankType string
+
+	// Scope - which leaderboard to use.
+	// The zero value is the global all-time board, Skill narrows it down
+	// to the events of one skill and Window to the events of a time window.
+	Scope struct {
+		Skill  string
+		Window Window
+	}
+
+	// Window - time window of a leaderboard, based on Event.TS.
+	Window string
+
+	// Query - which part of the leaderboard to return.
+	// Offset and After are mutually exclusive.
+	Query struct {
+		Scope  Scope
+		Limit  int
+		Offset int
+		After  *Cursor
+	}
+
+	// Page - a slice of the leaderboard and the cursor of the following one.
+	// Next is nil on the last page.
+	Page struct {
+		Leaders Leaders
+		Next    *Cursor
+	}
+
+	// Snapshot - a persisted copy of all boards.
+	Snapshot struct {
+		Path    string
+		TakenAt time.Time
+		Boards  int
+		// Talents - talents on the global all-time board.
+		Talents int
+		Size    int64
+		// Seq - every logged event up to Seq is in the snapshot.
+		Seq uint64
+	}
+
+	// Rebuild - progress of rebuilding the boards from the event history.
+	Rebuild struct {
+		State      RebuildState
+		StartedAt  time.Time
+		FinishedAt time.Time
+		// Total - logged events when it started, Processed may exceed it
+		// by the events accepted meanwhile.
+		Total     uint64
+		Processed uint64
+		Error     string
+	}
+
+	RebuildState string
+
+	// Watch - what a stream subscriber follows on the all-time board of Skill
+	// (empty - the global one): the top N or a set of talents.
+	Watch struct {
+		Skill   string
+		TopN    int
+		Talents []string
+	}
+
+	// Change - the rank or the score of a talent changed, PrevRank 0 - it's new
+	// on the board. A move from PrevRank to Rank shifts every talent in between
+	// by one place, a top N subscriber gets the moves that touch its top only.
+	Change struct {
+		Skill     string
+		TalentID  string
+		Score     float64
+		PrevScore float64
+		Rank      int
+		PrevRank  int
+		// Improved - the talent ranks better by the aggregation than before(e.g. a lower
+		// score by min), not set for a new talent and for the others shifted by its move.
+		Improved bool
+	}
+
+	// Update - a message of the stream. ID grows with every change of the boards,
+	// a subscriber resumes after the last ID it has seen.
+	// Snapshot is not nil when the subscriber has to replace its view: on subscribe,
+	// when the missed changes are gone or the boards were rebuilt.
+	Update struct {
+		ID       uint64
+		Changes  []Change
+		Snapshot Leaders
+	}
+)
+
+// Validate - exactly one of TopN and Talents.
+func (w Watch) Validate() error {
+	switch {
+	case w.TopN > 0 && len(w.Talents) > 0, w.TopN <= 0 && len(w.Talents) == 0:
+		return ErrInvalidWatch
+	case w.TopN > MaxWatchTop, len(w.Talents) > MaxWatchTalents:
+		return ErrInvalidWatch
+	}
+
+	return nil
+}
+
+const (
+	RebuildIdle    RebuildState = "idle"
+	RebuildRunning RebuildState = "running"
+	RebuildDone    RebuildState = "done"
+	RebuildFailed  RebuildState = "failed"
+)
+
+const (
+	// RankOrdinal - every row has its own rank, 1234(default).
+	RankOrdinal RankType = "ordinal"
+	// RankDense - ties share a rank, the next key gets the next one, 1223.
+	RankDense RankType = "dense"
+	// RankCompetition - ties share a rank, the next key skips the shared ones, 1224.
+	RankCompetition RankType = "competition"
+)
+
+// ParseRankType - "" is the ordinal one.
+func ParseRankType(s string) (RankType, bool) {
+	switch rt := RankType(s); rt {
+	case RankOrdinal, RankDense, RankCompetition:
+		return rt, true
+	case "":
+		return RankOrdinal, true
+	}
+
+	return RankOrdinal, false
+}
+
+const (
+	WindowAll     Window = ""
+	WindowDaily   Window = "daily"
+	WindowWeekly  Window = "weekly"
+	WindowMonthly Window = "monthly"
+	// WindowRolling - the last N days including today.
+	WindowRolling Window = "rolling"
 )
+
+// Windows - every window maintained for each event.
+var Windows = []Window{WindowAll, WindowDaily, WindowWeekly, WindowMonthly, WindowRolling}
+
+// ParseWindow - "all" and "" are the all-time board.
+func ParseWindow(s string) (Window, bool) {
+	if s == "all" {
+		return WindowAll, true
+	}
+	for _, w := range Windows {
+		if Window(s) == w {
+			return w, true
+		}
+	}
+
+	return WindowAll, false
+}
@@ -0,0 +1,75 @@
+package milestone
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhooksDisabled = errors.New("webhooks are disabled")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrNotDead - only a dead-lettered delivery can be sent again.
+	ErrNotDead = errors.New("the delivery is not dead-lettered")
+)
+
+// Type - what a talent has reached on a board.
+type Type string
+
+const (
+	// TopN - the talent entered the top N by its own score.
+	TopN Type = "top_n"
+	// FirstPlace - the talent took the first place.
+	FirstPlace Type = "first_place"
+	// PersonalBest - the talent ranks better by its new score than by the previous one.
+	PersonalBest Type = "personal_best"
+)
+
+type (
+	// Milestone - a talent reached it on the all-time board of Skill(empty - the global one).
+	Milestone struct {
+		Type Type
+		// N - the size of the top, TopN only.
+		N         int
+		Skill     string
+		TalentID  string
+		Score     float64
+		PrevScore float64
+		Rank      int
+		// PrevRank - 0 if the talent was not on the board.
+		PrevRank int
+		// Seq - of the event that made it, 0 if it's not logged.
+		Seq uint64
+		At  time.Time
+	}
+
+	// Delivery - a milestone posted to one webhook.
+	Delivery struct {
+		ID          string
+		Webhook     string
+		Milestone   Milestone
+		Status      Status
+		Attempts    int
+		NextAttempt time.Time
+		LastError   string
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+	}
+
+	Status string
+)
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	// StatusDead - every attempt failed, it waits for a manual retry.
+	StatusDead Status = "dead"
+)
+
+func ParseStatus(s string) (Status, bool) {
+	switch st := Status(s); st {
+	case StatusPending, StatusDelivered, StatusDead:
+		return st, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// Version - the set of scoring models in use.
+type Version struct {
+	Name     string
+	LoadedAt time.Time
+	// Default - type of the default model.
+	Default string
+	// Skills - type of the model of every skill with its own model.
+	Skills map[string]string
+}
@@ -0,0 +1,124 @@
+package season
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSeasonsDisabled = errors.New("seasons are disabled")
+	// ErrSeasonNotFound - no such season, or it's not archived yet.
+	ErrSeasonNotFound  = errors.New("season not found")
+	ErrInvalidSchedule = errors.New("invalid season schedule")
+	// ErrOutsideSeason - the event is not of the running season, the error is a *Rejection.
+	ErrOutsideSeason = errors.New("event is outside of the season")
+	// ErrSeasonChanged - a new season started while the boards were rebuilt.
+	ErrSeasonChanged = errors.New("the season changed during the rebuild")
+)
+
+// Rejection reasons of the events outside of the season.
+const (
+	// ReasonNoSeason - no season is running: before the first one or between two of them.
+	ReasonNoSeason     = "no_season"
+	ReasonBeforeSeason = "before_season"
+	ReasonAfterSeason  = "after_season"
+)
+
+// Rejection - why an event is not admitted by the board.
+type Rejection struct {
+	Reason  string
+	Message string
+}
+
+func (r *Rejection) Error() string        { return r.Message }
+func (r *Rejection) Is(target error) bool { return target == ErrOutsideSeason }
+
+// Schedule - seasons of Length, one every Every(Length by default) from Start.
+// Between two seasons(Every - Length) the board is frozen. The zero value - no seasons.
+type Schedule struct {
+	Start  time.Time
+	Length time.Duration
+	Every  time.Duration
+}
+
+func (s Schedule) Enabled() bool { return s.Length > 0 }
+
+func (s Schedule) Validate() error {
+	switch {
+	case !s.Enabled():
+		return nil
+	case s.Start.IsZero():
+		return fmt.Errorf("%w: the start of the first season is required", ErrInvalidSchedule)
+	case s.Every != 0 && s.Every < s.Length:
+		return fmt.Errorf("%w: seasons can't overlap, every %s is shorter than the length %s", ErrInvalidSchedule, s.Every, s.Length)
+	}
+
+	return nil
+}
+
+// Nth - the n-th season, from 1.
+func (s Schedule) Nth(n int) Season {
+	start := s.Start.Add(time.Duration(n-1) * s.every())
+
+	return Season{ID: n, Start: start, End: start.Add(s.Length)}
+}
+
+// Latest - the last season started at t or before, it may be over already.
+func (s Schedule) Latest(t time.Time) (Season, bool) {
+	if !s.Enabled() || t.Before(s.Start) {
+		return Season{}, false
+	}
+
+	return s.Nth(int(t.Sub(s.Start)/s.every()) + 1), true
+}
+
+// Admit - nil if an event of ts belongs to the season running at now.
+func (s Schedule) Admit(ts, now time.Time) error {
+	if !s.Enabled() {
+		return nil
+	}
+	cur, ok := s.Latest(now)
+	switch {
+	case !ok:
+		return &Rejection{ReasonNoSeason, "the first season starts at " + s.Start.Format(time.RFC3339)}
+	case !cur.Running(now):
+		next := s.Nth(cur.ID + 1)
+		return &Rejection{ReasonNoSeason, fmt.Sprintf("season %d is over, season %d starts at %s", cur.ID, next.ID, next.Start.Format(time.RFC3339))}
+	case ts.Before(cur.Start):
+		return &Rejection{ReasonBeforeSeason, fmt.Sprintf("ts is before the start of season %d at %s", cur.ID, cur.Start.Format(time.RFC3339))}
+	case !ts.Before(cur.End):
+		return &Rejection{ReasonAfterSeason, fmt.Sprintf("ts is after the end of season %d at %s", cur.ID, cur.End.Format(time.RFC3339))}
+	}
+
+	return nil
+}
+
+func (s Schedule) every() time.Duration { return cmp.Or(s.Every, s.Length) }
+
+// Season - [Start, End) by Event.TS.
+type Season struct {
+	ID    int
+	Start time.Time
+	End   time.Time
+}
+
+func (s Season) Running(t time.Time) bool { return !t.Before(s.Start) && t.Before(s.End) }
+
+type State string
+
+const (
+	StateRunning = State("running")
+	// StateEnded - over, the final standings are not archived yet.
+	StateEnded    = State("ended")
+	StateArchived = State("archived")
+)
+
+// Info - a season of a board, Talents - on its global board.
+type Info struct {
+	Season
+	State      State
+	ArchivedAt time.Time
+	Talents    int
+}
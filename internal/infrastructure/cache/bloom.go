@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// bloom - Bloom filter for event IDs: no false negatives,
+// false positives with the configured probability.
+type bloom struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+	n    int
+}
+
+// newBloom - sized for n items with the false positive rate p.
+func newBloom(n int, p float64) *bloom {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(max(math.Round(float64(m)/float64(n)*math.Ln2), 1))
+
+	return &bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+func (b *bloom) add(id uuid.UUID) {
+	h1, h2 := bloomHash(id)
+	for i := uint64(0); i < b.k; i++ {
+		pos := (h1 + i*h2) % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+	b.n++
+}
+
+func (b *bloom) has(id uuid.UUID) bool {
+	h1, h2 := bloomHash(id)
+	for i := uint64(0); i < b.k; i++ {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHash - double hashing, IDs come from producers so we don't rely on them being random.
+func bloomHash(id uuid.UUID) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write(id[:])
+	h1 := h.Sum64()
+	h = fnv.New64()
+	_, _ = h.Write(id[:])
+
+	return h1, h.Sum64() | 1
+}
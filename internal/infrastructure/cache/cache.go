@@ -1,15 +1,50 @@
 package cache
 
 import (
+	"container/list"
 	"context"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
-const defaultUpdateTime = time.Minute * 10
+const (
+	defaultUpdateTime = time.Minute * 10
+	// flushTimeout - the last flush on shutdown, when the worker context is already canceled.
+	flushTimeout = 5 * time.Second
+
+	RetentionByArrival = "arrival"
+	RetentionByEventTS = "event_ts"
+
+	OverflowLRU   = "lru"
+	OverflowBloom = "bloom"
+)
+
+type Config struct {
+	BackupInterval time.Duration
+	// Retention - how long an event ID is remembered, 0 - forever.
+	Retention time.Duration
+	// RetentionBy - age of an ID is counted from its arrival(default) or from the event TS.
+	RetentionBy string
+	// MaxEntries - cap of IDs kept in memory, 0 - unlimited.
+	MaxEntries int
+	// Overflow - what happens above MaxEntries: "lru" forgets the least
+	// recently used IDs, "bloom" moves the oldest ones to a Bloom filter.
+	Overflow string
+	// BloomFPRate - false positive rate of the Bloom filter.
+	BloomFPRate float64
+}
+
+// Metrics - evictions by reason("expired", "capacity") and the number of IDs in memory.
+type Metrics struct {
+	Evictions *prometheus.CounterVec
+	Size      prometheus.Gauge
+}
 
 type Cache struct {
 	// sync.RWMutex because we assume that there will be more readers.
@@ -17,46 +52,196 @@ type Cache struct {
 	// sync.Map also solving the problem of atomic increment of readers between big amount of cores.
 	mu  sync.RWMutex
 	log *zap.Logger
+	cfg Config
 	// I used "set" as a datastructure just for the current performance.
 	// Guess we need to keep whole event in hashmap.
-	events       map[uuid.UUID]struct{}
-	backupTicker *time.Ticker
+	events map[uuid.UUID]entry
+	// buckets - time buckets of IDs, a whole bucket expires at once.
+	// Every event is appended to one bucket only, so eviction is O(1) per ID.
+	buckets     map[int64][]uuid.UUID
+	bucketWidth time.Duration
+	// minBucket - the oldest bucket that may still have IDs.
+	minBucket int64
+	// lru - IDs from the least to the most recently used, only in the lru overflow mode.
+	lru *list.List
+	// filters - evicted IDs in the bloom overflow mode: the active generation
+	// and the previous one, they rotate every retention period.
+	filters    [2][]*bloom
+	rotateAt   time.Time
+	now        func() time.Time
+	metrics    Metrics
+	store      Store
+	pending    []Entry
+	backupTick *time.Ticker
+	evictTick  *time.Ticker
+}
+
+type entry struct {
+	bucket int64
+	elem   *list.Element
 }
 
-func New(ctx context.Context, log *zap.Logger) *Cache {
+// New - store is optional, with a store the cache restores
+// its events on start and flushes new ones periodically.
+func New(ctx context.Context, log *zap.Logger, store Store, cfg Config, metrics Metrics) (*Cache, error) {
+	if cfg.BackupInterval <= 0 {
+		cfg.BackupInterval = defaultUpdateTime
+	}
+	if cfg.RetentionBy == "" {
+		cfg.RetentionBy = RetentionByArrival
+	}
+	if cfg.RetentionBy != RetentionByArrival && cfg.RetentionBy != RetentionByEventTS {
+		return nil, fmt.Errorf("unknown cache retention mode %q", cfg.RetentionBy)
+	}
+	if cfg.MaxEntries > 0 && cfg.Overflow != OverflowLRU && cfg.Overflow != OverflowBloom {
+		return nil, fmt.Errorf("unknown cache overflow policy %q", cfg.Overflow)
+	}
+	if cfg.Overflow == OverflowBloom && (cfg.BloomFPRate <= 0 || cfg.BloomFPRate >= 1) {
+		return nil, fmt.Errorf("cache bloom false positive rate must be in (0, 1)")
+	}
+
 	c := &Cache{
-		backupTicker: time.NewTicker(defaultUpdateTime),
-		log:          log,
-		events:       make(map[uuid.UUID]struct{}),
+		log:         log,
+		cfg:         cfg,
+		events:      make(map[uuid.UUID]entry),
+		buckets:     make(map[int64][]uuid.UUID),
+		bucketWidth: bucketWidth(cfg.Retention),
+		now:         time.Now,
+		metrics:     metrics,
+		store:       store,
+		backupTick:  time.NewTicker(cfg.BackupInterval),
+	}
+	c.evictTick = time.NewTicker(c.bucketWidth)
+	c.minBucket = c.bucketOf(c.now().Add(-max(cfg.Retention, 0)))
+	if cfg.MaxEntries > 0 && cfg.Overflow == OverflowLRU {
+		c.lru = list.New()
 	}
 
-	_ = c.wakeUp()
-	// c.backupWorker()
+	if err := c.wakeUp(ctx); err != nil {
+		c.backupTick.Stop()
+		c.evictTick.Stop()
+		return nil, err
+	}
+
+	return c, nil
+}
 
-	return c
+// Close - stops the tickers of a cache whose BackupWorker never runs.
+func (c *Cache) Close() {
+	c.backupTick.Stop()
+	c.evictTick.Stop()
 }
 
-func (c *Cache) Set(eventID uuid.UUID) {
+// Set - ts is the event TS, used when the retention is counted by event time.
+func (c *Cache) Set(eventID uuid.UUID, ts time.Time) {
 	c.mu.Lock()
-	c.events[eventID] = struct{}{}
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+
+	c.set(eventID, ts)
+}
+
+// SetIfAbsent - atomic IsSet + Set, reports whether the ID was new.
+// The store is written behind the memory, so the memory is the single source of the answer.
+func (c *Cache) SetIfAbsent(eventID uuid.UUID, ts time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.has(eventID) {
+		return false
+	}
+	c.set(eventID, ts)
+
+	return true
+}
+
+// Delete - forgets an ID that was set but its event wasn't accepted after all.
+// An ID already moved to a Bloom filter can't be forgotten.
+func (c *Cache) Delete(eventID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.events[eventID]; ok {
+		c.remove(eventID, e.bucket)
+	}
+	c.pending = slices.DeleteFunc(c.pending, func(e Entry) bool { return e.ID == eventID })
+}
+
+// set - must be called under the write lock.
+func (c *Cache) set(eventID uuid.UUID, ts time.Time) {
+	at := c.now()
+	if c.cfg.RetentionBy == RetentionByEventTS {
+		at = ts
+	}
+	if !c.add(eventID, at) {
+		return
+	}
+	if c.store != nil {
+		c.pending = append(c.pending, Entry{ID: eventID, At: at})
+	}
+	c.evictOverflow()
 }
 
 // IsSet - Over time, our table will grow therefore we will need to scale it.
 // We will use sharding (horizontal). And for searching on different shards,
 // we will use the "Distributed Search Function" and gob format for cooperation(I can explain in detail)
 func (c *Cache) IsSet(id uuid.UUID) bool {
-	c.mu.RLock()
-	_, ok := c.events[id]
-	c.mu.RUnlock()
-	return ok
+	if c.lru != nil {
+		// a hit changes the order of the lru list
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	} else {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+	}
+
+	return c.has(id)
+}
+
+// has - must be called under the lock(the write one in the lru mode).
+func (c *Cache) has(id uuid.UUID) bool {
+	if e, ok := c.events[id]; ok {
+		if c.lru != nil {
+			c.lru.MoveToBack(e.elem)
+		}
+		return true
+	}
+	for _, gen := range c.filters {
+		for _, f := range gen {
+			if f.has(id) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // wakeUp - In case of crush of our app we are able to restore Events data
-func (c *Cache) wakeUp() error {
-	// getting stored data from Redis
-	// ...
-	// c.events = dataFromRedis
+func (c *Cache) wakeUp(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+
+	entries, err := c.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.cfg.Retention <= 0 {
+		// nothing expires, keep the age order of everything restored
+		for _, e := range entries {
+			c.minBucket = min(c.minBucket, c.bucketOf(e.At))
+		}
+	}
+	for _, e := range entries {
+		c.add(e.ID, e.At)
+	}
+	c.evictExpired()
+	c.evictOverflow()
+	size := len(c.events)
+	c.mu.Unlock()
+	c.log.Info("cache restored", zap.Int("events", size))
 
 	return nil
 }
@@ -65,23 +250,54 @@ func (c *Cache) BackupWorker(ctx context.Context) {
 	c.log.Info("starting cache backup worker ")
 
 	defer func() {
-		c.backupTicker.Stop()
+		c.backupTick.Stop()
+		c.evictTick.Stop()
+		// whatever came after the last tick
+		flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+		if err := c.toRedis(flushCtx); err != nil {
+			c.log.Error("cache final flush failed", zap.Error(err))
+		}
 		c.log.Info("cache backup worker gracefully stopped")
 	}()
 
 	for {
 		select {
-		case <-c.backupTicker.C:
-			_ = c.toRedis()
+		case <-c.backupTick.C:
+			if err := c.toRedis(ctx); err != nil {
+				c.log.Error("cache flush failed", zap.Error(err))
+			}
+		case <-c.evictTick.C:
+			c.mu.Lock()
+			c.evictExpired()
+			c.mu.Unlock()
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (c *Cache) toRedis() error {
-	// write c.events data to redis
-	// ...
+// toRedis - flushes the pending IDs to the store.
+// On failure they are kept for the next attempt, the store set is idempotent.
+func (c *Cache) toRedis(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := c.store.Save(ctx, pending); err != nil {
+		c.mu.Lock()
+		c.pending = append(pending, c.pending...)
+		c.mu.Unlock()
+		return err
+	}
 
 	// Also we need to have a constant storage beside Redis
 	// so we can have a worker that taking from redis and store to Postgres.
@@ -2,17 +2,66 @@ package cache
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+
+	"leaderboard-api/internal/infrastructure/db/redis"
+	"leaderboard-api/internal/infrastructure/db/redis/redistest"
 )
 
+const testKey = "test:events"
+
+var testCacheConfig = Config{BackupInterval: time.Hour}
+
+func newTestCache(t *testing.T, store Store) *Cache {
+	t.Helper()
+	return newTestCacheWith(t, store, testCacheConfig)
+}
+
+func newTestCacheWith(t *testing.T, store Store, cfg Config) *Cache {
+	t.Helper()
+	c, err := New(context.Background(), zap.NewNop(), store, cfg, newTestMetrics())
+	require.NoError(t, err)
+	return c
+}
+
+func newTestMetrics() Metrics {
+	return Metrics{
+		Evictions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_evictions_total"}, []string{"reason"}),
+		Size:      prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_size"}),
+	}
+}
+
+// fakeClock - starts at the real time, New computes the live buckets from it.
+type fakeClock struct{ t time.Time }
+
+func (fc *fakeClock) now() time.Time          { return fc.t }
+func (fc *fakeClock) advance(d time.Duration) { fc.t = fc.t.Add(d) }
+
+func withClock(c *Cache) *fakeClock {
+	fc := &fakeClock{t: time.Now()}
+	c.now = fc.now
+	return fc
+}
+
+func newTestRedisStore(t *testing.T, retention time.Duration) (*RedisStore, *redistest.Server) {
+	t.Helper()
+	srv := redistest.NewServer(t)
+	client := redis.New(redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisStore(client, testKey, retention), srv
+}
+
 func TestCache_SetAndIsSet(t *testing.T) {
-	logger := zap.NewNop()
-	cache := New(context.Background(), logger)
+	cache := newTestCache(t, nil)
 
 	id := uuid.New()
 
@@ -25,7 +74,7 @@ func TestCache_SetAndIsSet(t *testing.T) {
 		{"Set UUID", id, true},
 	}
 
-	cache.Set(id)
+	cache.Set(id, time.Now())
 
 	for _, tt := range tests {
 		tt := tt
@@ -36,26 +85,99 @@ func TestCache_SetAndIsSet(t *testing.T) {
 	}
 }
 
+// TestCache_SetIfAbsent_Concurrent - the same ID from many goroutines is new exactly once.
+func TestCache_SetIfAbsent_Concurrent(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		// a Bloom false positive may reject a new ID, but never accepts it twice
+		maybeRejected bool
+	}{
+		{"Unbounded", testCacheConfig, false},
+		{"LRU - all IDs fit", Config{BackupInterval: time.Hour, MaxEntries: 100, Overflow: OverflowLRU}, false},
+		{"Bloom - most IDs overflow", Config{BackupInterval: time.Hour, MaxEntries: 10, Overflow: OverflowBloom, BloomFPRate: 0.001}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCacheWith(t, nil, tt.cfg)
+
+			const goroutines, ids = 64, 50
+			var wg sync.WaitGroup
+			var added [ids]atomic.Int32
+			eventIDs := make([]uuid.UUID, ids)
+			for i := range eventIDs {
+				eventIDs[i] = uuid.New()
+			}
+			start := make(chan struct{})
+			for range goroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					for i, id := range eventIDs {
+						if c.SetIfAbsent(id, time.Now()) {
+							added[i].Add(1)
+						}
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			for i := range added {
+				if tt.maybeRejected {
+					require.LessOrEqual(t, added[i].Load(), int32(1), "event %d", i)
+					continue
+				}
+				require.Equal(t, int32(1), added[i].Load(), "event %d", i)
+			}
+		})
+	}
+}
+
+func TestCache_SetIfAbsent(t *testing.T) {
+	c := newTestCache(t, nil)
+	id := uuid.New()
+
+	require.True(t, c.SetIfAbsent(id, time.Now()))
+	require.False(t, c.SetIfAbsent(id, time.Now()))
+	require.True(t, c.IsSet(id))
+}
+
+func TestCache_Delete(t *testing.T) {
+	store, _ := newTestRedisStore(t, 0)
+	c := newTestCache(t, store)
+	id, kept := uuid.New(), uuid.New()
+	c.Set(id, time.Now())
+	c.Set(kept, time.Now())
+
+	c.Delete(id)
+	require.False(t, c.IsSet(id))
+	require.True(t, c.IsSet(kept))
+	// never flushed to the store either
+	require.Len(t, c.pending, 1)
+	require.Equal(t, kept, c.pending[0].ID)
+	require.True(t, c.SetIfAbsent(id, time.Now()))
+}
+
 func TestCache_wakeUp(t *testing.T) {
-	logger := zap.NewNop()
-	cache := New(context.Background(), logger)
+	cache := newTestCache(t, nil)
 
-	err := cache.wakeUp()
+	err := cache.wakeUp(context.Background())
 	require.NoError(t, err)
 }
 
 func TestCache_toRedis(t *testing.T) {
-	logger := zap.NewNop()
-	cache := New(context.Background(), logger)
+	cache := newTestCache(t, nil)
 
-	err := cache.toRedis()
+	err := cache.toRedis(context.Background())
 	require.NoError(t, err)
 }
 
 func TestCache_BackupWorker_Cancel(t *testing.T) {
-	logger := zap.NewNop()
 	ctx, cancel := context.WithCancel(context.Background())
-	cache := New(ctx, logger)
+	cache := newTestCache(t, nil)
 
 	done := make(chan struct{})
 	go func() {
@@ -70,3 +192,264 @@ func TestCache_BackupWorker_Cancel(t *testing.T) {
 		t.Fatal("backup worker did not stop on context cancel")
 	}
 }
+
+func TestCache_Redis_RestoreAfterRestart(t *testing.T) {
+	store, srv := newTestRedisStore(t, 0)
+
+	first := newTestCache(t, store)
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, id := range ids {
+		first.Set(id, time.Now())
+	}
+	// a repeated Set is not flushed twice
+	first.Set(ids[0], time.Now())
+	require.Len(t, first.pending, len(ids))
+	require.NoError(t, first.toRedis(context.Background()))
+	require.Empty(t, first.pending)
+	keys := srv.Keys()
+	require.Len(t, keys, 1)
+	require.Len(t, srv.Members(keys[0]), len(ids))
+
+	// "restart"
+	second := newTestCache(t, store)
+	for _, id := range ids {
+		require.True(t, second.IsSet(id))
+	}
+	require.False(t, second.IsSet(uuid.New()))
+}
+
+func TestRedisStore_Drop(t *testing.T) {
+	store, srv := newTestRedisStore(t, 0)
+	other := NewRedisStore(store.client, testKey+":other", 0)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, store.Save(ctx, []Entry{{ID: uuid.New(), At: now}, {ID: uuid.New(), At: now.Add(-2 * time.Hour)}}))
+	require.NoError(t, other.Save(ctx, []Entry{{ID: uuid.New(), At: now}}))
+	require.Len(t, srv.Keys(), 3)
+
+	require.NoError(t, store.Drop(ctx))
+	entries, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	entries, err = other.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestCache_Redis_FlushOnStop(t *testing.T) {
+	store, srv := newTestRedisStore(t, 0)
+	cache := newTestCache(t, store)
+
+	id := uuid.New()
+	cache.Set(id, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cache.BackupWorker(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	keys := srv.Keys()
+	require.Len(t, keys, 1)
+	require.Equal(t, []string{id.String()}, srv.Members(keys[0]))
+}
+
+func TestCache_Redis_FlushFailureKeepsPending(t *testing.T) {
+	store, srv := newTestRedisStore(t, 0)
+	cache := newTestCache(t, store)
+
+	id := uuid.New()
+	cache.Set(id, time.Now())
+	srv.Close()
+
+	require.Error(t, cache.toRedis(context.Background()))
+	require.Len(t, cache.pending, 1)
+	require.Equal(t, id, cache.pending[0].ID)
+}
+
+func TestCache_Redis_WakeUpFailure(t *testing.T) {
+	store, srv := newTestRedisStore(t, 0)
+	srv.Close()
+
+	_, err := New(context.Background(), zap.NewNop(), store, testCacheConfig, newTestMetrics())
+	require.Error(t, err)
+}
+
+func TestCache_Retention(t *testing.T) {
+	tests := []struct {
+		name        string
+		retentionBy string
+		// age of the event TS when it arrives
+		eventAge time.Duration
+		// time passed after the arrival
+		passed  time.Duration
+		wantSet bool
+	}{
+		{"Arrival - fresh", RetentionByArrival, 0, time.Hour, true},
+		{"Arrival - expired", RetentionByArrival, 0, 4 * time.Hour, false},
+		{"Arrival - old event TS doesn't matter", RetentionByArrival, 10 * time.Hour, time.Hour, true},
+		{"Event TS - fresh", RetentionByEventTS, time.Hour, time.Hour, true},
+		{"Event TS - expired by its age", RetentionByEventTS, 2 * time.Hour, 2 * time.Hour, false},
+		{"Event TS - older than retention is dropped by the next sweep", RetentionByEventTS, 10 * time.Hour, time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testCacheConfig
+			cfg.Retention = 3 * time.Hour
+			cfg.RetentionBy = tt.retentionBy
+			c := newTestCacheWith(t, nil, cfg)
+			clock := withClock(c)
+
+			id := uuid.New()
+			c.Set(id, clock.now().Add(-tt.eventAge))
+			clock.advance(tt.passed)
+			c.mu.Lock()
+			c.evictExpired()
+			c.mu.Unlock()
+
+			require.Equal(t, tt.wantSet, c.IsSet(id))
+			if !tt.wantSet {
+				require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.Evictions.WithLabelValues(reasonExpired)))
+				require.Equal(t, 0.0, testutil.ToFloat64(c.metrics.Size))
+			}
+		})
+	}
+}
+
+func TestCache_Overflow_LRU(t *testing.T) {
+	cfg := testCacheConfig
+	cfg.MaxEntries = 3
+	cfg.Overflow = OverflowLRU
+	c := newTestCacheWith(t, nil, cfg)
+
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, id := range ids {
+		c.Set(id, time.Now())
+	}
+	// ids[0] becomes the most recently used
+	require.True(t, c.IsSet(ids[0]))
+
+	c.Set(uuid.New(), time.Now())
+	require.True(t, c.IsSet(ids[0]))
+	require.False(t, c.IsSet(ids[1]))
+	require.True(t, c.IsSet(ids[2]))
+	require.Len(t, c.events, 3)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.Evictions.WithLabelValues(reasonCapacity)))
+	require.Equal(t, 3.0, testutil.ToFloat64(c.metrics.Size))
+}
+
+func TestCache_Overflow_Bloom(t *testing.T) {
+	cfg := testCacheConfig
+	cfg.Retention = time.Hour
+	cfg.MaxEntries = 100
+	cfg.Overflow = OverflowBloom
+	cfg.BloomFPRate = 0.001
+	c := newTestCacheWith(t, nil, cfg)
+	clock := withClock(c)
+
+	ids := make([]uuid.UUID, 1000)
+	for i := range ids {
+		ids[i] = uuid.New()
+		c.Set(ids[i], clock.now())
+	}
+	require.LessOrEqual(t, len(c.events), cfg.MaxEntries)
+
+	// evicted IDs are still duplicates, no false negatives
+	for _, id := range ids {
+		require.True(t, c.IsSet(id))
+	}
+	falsePositives := 0
+	for range 1000 {
+		if c.IsSet(uuid.New()) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 20)
+
+	// the filters forget after two rotations
+	for range 2 {
+		clock.advance(cfg.Retention + time.Minute)
+		c.mu.Lock()
+		c.evictExpired()
+		c.mu.Unlock()
+	}
+	require.False(t, c.IsSet(ids[0]))
+}
+
+// TestCache_Overflow_BloomDrainedBuckets - the overflow starts from the oldest bucket with IDs.
+func TestCache_Overflow_BloomDrainedBuckets(t *testing.T) {
+	cfg := testCacheConfig
+	cfg.Retention = time.Hour
+	cfg.MaxEntries = 100
+	cfg.Overflow = OverflowBloom
+	cfg.BloomFPRate = 0.001
+	c := newTestCacheWith(t, nil, cfg)
+	clock := withClock(c)
+
+	// 50 IDs in each of 6 buckets, only the last two fit
+	for range 6 {
+		for range 50 {
+			c.Set(uuid.New(), clock.now())
+		}
+		clock.advance(c.bucketWidth)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	require.LessOrEqual(t, len(c.events), cfg.MaxEntries)
+	oldest := c.minBucket
+	for b := range c.buckets {
+		oldest = min(oldest, b)
+	}
+	require.Equal(t, oldest, c.minBucket)
+	require.Equal(t, c.bucketOf(clock.now())-2, c.minBucket)
+}
+
+func TestCache_Redis_HourlyKeysExpire(t *testing.T) {
+	store, srv := newTestRedisStore(t, 3*time.Hour)
+	cfg := testCacheConfig
+	cfg.Retention = 3 * time.Hour
+	cfg.RetentionBy = RetentionByEventTS
+	c := newTestCacheWith(t, store, cfg)
+
+	now := time.Now()
+	oldID, newID := uuid.New(), uuid.New()
+	c.Set(oldID, now.Add(-2*time.Hour))
+	c.Set(newID, now)
+	require.NoError(t, c.toRedis(context.Background()))
+
+	keys := srv.Keys()
+	require.Len(t, keys, 2)
+	for _, k := range keys {
+		ttl := srv.TTL(k)
+		require.Greater(t, ttl, time.Duration(0))
+		require.LessOrEqual(t, ttl, 4*time.Hour)
+	}
+
+	// Redis drops the old hour, the restored cache only has the new ID
+	srv.FastForward(2*time.Hour + 30*time.Minute)
+	restored := newTestCacheWith(t, store, cfg)
+	require.False(t, restored.IsSet(oldID))
+	require.True(t, restored.IsSet(newID))
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"Unknown retention mode", Config{RetentionBy: "processed"}},
+		{"Unknown overflow policy", Config{MaxEntries: 10, Overflow: "fifo"}},
+		{"Bloom without FP rate", Config{MaxEntries: 10, Overflow: OverflowBloom}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(context.Background(), zap.NewNop(), nil, tt.cfg, newTestMetrics())
+			require.Error(t, err)
+		})
+	}
+}
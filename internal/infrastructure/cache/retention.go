@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// bucketsPerRetention - granularity of the expiry, an ID lives
+	// from Retention to Retention + Retention/bucketsPerRetention.
+	bucketsPerRetention = 24
+	// defaultBucketWidth - without retention buckets only keep the age order for the overflow.
+	defaultBucketWidth = time.Hour
+	// overflowChunk - share of MaxEntries evicted at once, so the eviction
+	// doesn't run on every Set once the cache is full.
+	overflowChunk = 100
+
+	reasonExpired  = "expired"
+	reasonCapacity = "capacity"
+)
+
+func bucketWidth(retention time.Duration) time.Duration {
+	if retention <= 0 {
+		return defaultBucketWidth
+	}
+
+	return max(retention/bucketsPerRetention, time.Second)
+}
+
+func (c *Cache) bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(c.bucketWidth)
+}
+
+// add - must be called under the write lock. Reports whether the ID is new.
+// Too old IDs go to the oldest live bucket and IDs from the future to
+// the current one, so the buckets always stay within the retention.
+func (c *Cache) add(id uuid.UUID, at time.Time) bool {
+	if _, ok := c.events[id]; ok {
+		return false
+	}
+
+	b := min(max(c.bucketOf(at), c.minBucket), c.bucketOf(c.now()))
+	e := entry{bucket: b}
+	if c.lru != nil {
+		e.elem = c.lru.PushBack(id)
+	}
+	c.events[id] = e
+	if c.cfg.Retention > 0 || c.cfg.Overflow == OverflowBloom {
+		// the lru mode without retention never walks the buckets
+		c.buckets[b] = append(c.buckets[b], id)
+	}
+	c.updateSize()
+
+	return true
+}
+
+// evictExpired - drops the buckets older than the retention,
+// must be called under the write lock.
+func (c *Cache) evictExpired() {
+	now := c.now()
+	c.rotateFilters(now)
+	if c.cfg.Retention <= 0 {
+		return
+	}
+
+	cutoff := c.bucketOf(now.Add(-c.cfg.Retention))
+	evicted := 0
+	for ; c.minBucket < cutoff; c.minBucket++ {
+		for _, id := range c.buckets[c.minBucket] {
+			if c.remove(id, c.minBucket) {
+				evicted++
+			}
+		}
+		delete(c.buckets, c.minBucket)
+	}
+	c.countEvictions(reasonExpired, evicted)
+}
+
+// evictOverflow - keeps the cache under MaxEntries,
+// must be called under the write lock.
+func (c *Cache) evictOverflow() {
+	if c.cfg.MaxEntries <= 0 || len(c.events) <= c.cfg.MaxEntries {
+		return
+	}
+
+	limit := c.cfg.MaxEntries - c.cfg.MaxEntries/overflowChunk
+	evicted := 0
+	switch c.cfg.Overflow {
+	case OverflowLRU:
+		for len(c.events) > limit {
+			id := c.lru.Front().Value.(uuid.UUID)
+			c.remove(id, c.events[id].bucket)
+			evicted++
+		}
+	case OverflowBloom:
+		if c.rotateAt.IsZero() {
+			c.rotateAt = c.now().Add(c.filterLifetime())
+		}
+		// the oldest IDs first
+		current := c.bucketOf(c.now())
+		for b := c.minBucket; len(c.events) > limit; b++ {
+			ids := c.buckets[b]
+			for len(ids) > 0 && len(c.events) > limit {
+				if c.remove(ids[0], b) {
+					c.toFilter(ids[0])
+					evicted++
+				}
+				ids = ids[1:]
+			}
+			if len(ids) == 0 {
+				delete(c.buckets, b)
+				// a drained bucket is never walked again, the current one takes the new IDs
+				if b == c.minBucket && b < current {
+					c.minBucket++
+				}
+			} else {
+				c.buckets[b] = ids
+			}
+		}
+	}
+	c.countEvictions(reasonCapacity, evicted)
+}
+
+// toFilter - a generation grows by one more filter of MaxEntries capacity
+// once the last one is full, so the false positive rate stays bounded per filter.
+func (c *Cache) toFilter(id uuid.UUID) {
+	gen := c.filters[0]
+	if len(gen) == 0 || gen[len(gen)-1].n >= c.cfg.MaxEntries {
+		gen = append(gen, newBloom(c.cfg.MaxEntries, c.cfg.BloomFPRate))
+		c.filters[0] = gen
+	}
+	gen[len(gen)-1].add(id)
+}
+
+// rotateFilters - an ID stays in the filters at least for one retention
+// period: first in the active generation, then in the previous one.
+func (c *Cache) rotateFilters(now time.Time) {
+	if c.rotateAt.IsZero() || now.Before(c.rotateAt) {
+		return
+	}
+	c.filters[1], c.filters[0] = c.filters[0], nil
+	c.rotateAt = now.Add(c.filterLifetime())
+}
+
+func (c *Cache) filterLifetime() time.Duration {
+	if c.cfg.Retention <= 0 {
+		// never rotate, forget nothing
+		return time.Duration(1<<63 - 1)
+	}
+
+	return c.cfg.Retention
+}
+
+// remove - removes the ID if it still belongs to the bucket.
+func (c *Cache) remove(id uuid.UUID, bucket int64) bool {
+	e, ok := c.events[id]
+	if !ok || e.bucket != bucket {
+		return false
+	}
+	if e.elem != nil {
+		c.lru.Remove(e.elem)
+	}
+	delete(c.events, id)
+	c.updateSize()
+
+	return true
+}
+
+func (c *Cache) updateSize() {
+	if c.metrics.Size != nil {
+		c.metrics.Size.Set(float64(len(c.events)))
+	}
+}
+
+func (c *Cache) countEvictions(reason string, n int) {
+	if n > 0 && c.metrics.Evictions != nil {
+		c.metrics.Evictions.WithLabelValues(reason).Add(float64(n))
+	}
+}
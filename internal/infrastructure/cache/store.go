@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"leaderboard-api/internal/infrastructure/db/redis"
+)
+
+const (
+	ModeMemory = "memory"
+	ModeRedis  = "redis"
+
+	// scanCount - keys/members per SCAN/SSCAN step while restoring.
+	scanCount = 1000
+	// saveChunk - members per SADD while flushing.
+	saveChunk = 1000
+	// storeBucket - IDs are kept in one Redis set per hour, so
+	// Redis expires them by whole sets without any cleanup on our side.
+	storeBucket = time.Hour
+)
+
+// Entry - event ID with the time its retention is counted from.
+type Entry struct {
+	ID uuid.UUID
+	At time.Time
+}
+
+// Store - durable backend of the cache.
+// The cache itself stays in memory and serves every request,
+// the store is only written by BackupWorker and read on wake up.
+type Store interface {
+	Load(ctx context.Context) ([]Entry, error)
+	Save(ctx context.Context, entries []Entry) error
+}
+
+// RedisStore - keeps event IDs in hourly Redis sets "<prefix>:<unix hour>".
+type RedisStore struct {
+	client    *redis.Client
+	prefix    string
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewRedisStore - retention 0 keeps the sets forever.
+func NewRedisStore(client *redis.Client, prefix string, retention time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, retention: retention, now: time.Now}
+}
+
+func (rs *RedisStore) Load(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	cursor := "0"
+	for {
+		next, keys, err := rs.client.Scan(ctx, cursor, rs.prefix+":*", scanCount)
+		if err != nil {
+			return nil, fmt.Errorf("load event ids: %w", err)
+		}
+		for _, key := range keys {
+			at, ok := rs.bucketTime(key)
+			if !ok {
+				continue
+			}
+			if entries, err = rs.loadSet(ctx, key, at, entries); err != nil {
+				return nil, err
+			}
+		}
+		if next == "0" {
+			return entries, nil
+		}
+		cursor = next
+	}
+}
+
+func (rs *RedisStore) loadSet(ctx context.Context, key string, at time.Time, entries []Entry) ([]Entry, error) {
+	cursor := "0"
+	for {
+		next, members, err := rs.client.SScan(ctx, key, cursor, scanCount)
+		if err != nil {
+			return nil, fmt.Errorf("load event ids: %w", err)
+		}
+		for _, m := range members {
+			id, err := uuid.Parse(m)
+			if err != nil {
+				// someone else's garbage in our key, nothing to restore
+				continue
+			}
+			entries = append(entries, Entry{ID: id, At: at})
+		}
+		if next == "0" {
+			return entries, nil
+		}
+		cursor = next
+	}
+}
+
+func (rs *RedisStore) Save(ctx context.Context, entries []Entry) error {
+	byKey := make(map[string][]string)
+	for _, e := range entries {
+		key := rs.key(e.At)
+		byKey[key] = append(byKey[key], e.ID.String())
+	}
+
+	for key, members := range byKey {
+		for len(members) > 0 {
+			n := min(len(members), saveChunk)
+			if _, err := rs.client.SAdd(ctx, key, members[:n]...); err != nil {
+				return fmt.Errorf("save event ids: %w", err)
+			}
+			members = members[n:]
+		}
+		if err := rs.expire(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Drop - removes every set of the prefix, e.g. of a deleted board.
+func (rs *RedisStore) Drop(ctx context.Context) error {
+	cursor := "0"
+	for {
+		next, keys, err := rs.client.Scan(ctx, cursor, rs.prefix+":*", scanCount)
+		if err != nil {
+			return fmt.Errorf("drop event ids: %w", err)
+		}
+		// the pattern matches the longer prefixes too
+		keys = slices.DeleteFunc(keys, func(key string) bool {
+			_, ok := rs.bucketTime(key)
+			return !ok
+		})
+		if len(keys) > 0 {
+			if _, err := rs.client.Del(ctx, keys...); err != nil {
+				return fmt.Errorf("drop event ids: %w", err)
+			}
+		}
+		if next == "0" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (rs *RedisStore) expire(ctx context.Context, key string) error {
+	if rs.retention <= 0 {
+		return nil
+	}
+	at, _ := rs.bucketTime(key)
+	ttl := at.Add(storeBucket + rs.retention).Sub(rs.now())
+	if _, err := rs.client.Int(ctx, "EXPIRE", key, strconv.Itoa(max(int(ttl.Seconds()), 1))); err != nil {
+		return fmt.Errorf("expire event ids: %w", err)
+	}
+
+	return nil
+}
+
+func (rs *RedisStore) key(at time.Time) string {
+	return rs.prefix + ":" + strconv.FormatInt(at.Unix()/int64(storeBucket.Seconds()), 10)
+}
+
+func (rs *RedisStore) bucketTime(key string) (time.Time, bool) {
+	hour, err := strconv.ParseInt(strings.TrimPrefix(key, rs.prefix+":"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(hour*int64(storeBucket.Seconds()), 0), true
+}
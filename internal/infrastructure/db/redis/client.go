@@ -0,0 +1,306 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultPoolSize    = 8
+)
+
+var (
+	ErrNil    = errors.New("redis: nil reply")
+	ErrClosed = errors.New("redis: client is closed")
+)
+
+// Error - error reply of the server.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+type Options struct {
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize - max number of idle connections kept open.
+	PoolSize    int
+	DialTimeout time.Duration
+}
+
+// Client - minimal RESP2 client, only what the app needs from Redis.
+// Safe for concurrent use, every command takes a connection from the pool.
+type Client struct {
+	opts   Options
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+type conn struct {
+	nc net.Conn
+	rd *bufio.Reader
+	wr *bufio.Writer
+}
+
+func New(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultPoolSize
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+
+	return &Client{opts: opts}
+}
+
+// Do - sends a command and returns the reply:
+// string(simple and bulk strings), int64, []any(arrays) or nil(ErrNil).
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(ctx, args...)
+	var rerr Error
+	if err != nil && !errors.Is(err, ErrNil) && !errors.As(err, &rerr) {
+		// broken connection, never reuse it
+		_ = cn.nc.Close()
+		return nil, err
+	}
+	c.put(cn)
+
+	return reply, err
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// SAdd - returns the number of members that were added(not already in the set).
+func (c *Client) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	return c.Int(ctx, append([]string{"SADD", key}, members...)...)
+}
+
+// SScan - one step of the set iteration, cursor "0" starts and ends it.
+func (c *Client) SScan(ctx context.Context, key, cursor string, count int) (next string, members []string, err error) {
+	reply, err := c.Do(ctx, "SSCAN", key, cursor, "COUNT", strconv.Itoa(count))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return scanReply(reply)
+}
+
+// Scan - one step of the keyspace iteration, cursor "0" starts and ends it.
+func (c *Client) Scan(ctx context.Context, cursor, match string, count int) (next string, keys []string, err error) {
+	reply, err := c.Do(ctx, "SCAN", cursor, "MATCH", match, "COUNT", strconv.Itoa(count))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return scanReply(reply)
+}
+
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	return c.Int(ctx, append([]string{"DEL"}, keys...)...)
+}
+
+func (c *Client) Int(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %T to %s", reply, args[0])
+	}
+
+	return n, nil
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for _, cn := range c.idle {
+		_ = cn.nc.Close()
+	}
+	c.idle = nil
+
+	return nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || len(c.idle) >= c.opts.PoolSize {
+		_ = cn.nc.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: c.opts.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", c.opts.Addr, err)
+	}
+	cn := &conn{nc: nc, rd: bufio.NewReader(nc), wr: bufio.NewWriter(nc)}
+
+	if c.opts.Password != "" {
+		if _, err := cn.do(ctx, "AUTH", c.opts.Password); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do(ctx, "SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (cn *conn) do(ctx context.Context, args ...string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	if err := cn.nc.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if err := WriteCommand(cn.wr, args...); err != nil {
+		return nil, err
+	}
+	if err := cn.wr.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ReadReply(cn.rd)
+}
+
+// WriteCommand - a command is always sent as an array of bulk strings.
+func WriteCommand(w io.Writer, args ...string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, a := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(a), a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadReply - reads one RESP2 value. Error replies are returned as Error.
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			arr[i], err = ReadReply(r)
+			if err != nil && !errors.Is(err, ErrNil) {
+				return nil, err
+			}
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: malformed line")
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func scanReply(reply any) (string, []string, error) {
+	arr, ok := reply.([]any)
+	if !ok || len(arr) != 2 {
+		return "", nil, fmt.Errorf("redis: unexpected scan reply %T", reply)
+	}
+	next, _ := arr[0].(string)
+	items, _ := arr[1].([]any)
+	res := make([]string, 0, len(items))
+	for _, it := range items {
+		if s, ok := it.(string); ok {
+			res = append(res, s)
+		}
+	}
+
+	return next, res, nil
+}
@@ -0,0 +1,57 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/infrastructure/db/redis"
+	"leaderboard-api/internal/infrastructure/db/redis/redistest"
+)
+
+func TestClient_Commands(t *testing.T) {
+	srv := redistest.NewServer(t)
+	c := redis.New(redis.Options{Addr: srv.Addr(), Password: "secret", DB: 1})
+	t.Cleanup(func() { _ = c.Close() })
+	ctx := context.Background()
+
+	require.NoError(t, c.Ping(ctx))
+
+	added, err := c.SAdd(ctx, "set", "a", "b", "a")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), added)
+
+	next, members, err := c.SScan(ctx, "set", "0", 10)
+	require.NoError(t, err)
+	require.Equal(t, "0", next)
+	require.ElementsMatch(t, []string{"a", "b"}, members)
+
+	_, keys, err := c.Scan(ctx, "0", "se*", 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"set"}, keys)
+
+	_, err = c.Do(ctx, "GET", "missing")
+	require.ErrorIs(t, err, redis.ErrNil)
+
+	_, err = c.Do(ctx, "GET", "set")
+	var rerr redis.Error
+	require.ErrorAs(t, err, &rerr)
+
+	deleted, err := c.Del(ctx, "set")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+}
+
+func TestClient_ServerDown(t *testing.T) {
+	srv := redistest.NewServer(t)
+	c := redis.New(redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = c.Close() })
+
+	require.NoError(t, c.Ping(context.Background()))
+	srv.Close()
+	require.Error(t, c.Ping(context.Background()))
+
+	require.NoError(t, c.Close())
+	require.ErrorIs(t, c.Ping(context.Background()), redis.ErrClosed)
+}
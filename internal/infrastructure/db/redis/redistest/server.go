@@ -0,0 +1,405 @@
+// Package redistest - in-process Redis stand-in for tests(miniredis style).
+// Speaks RESP2 and supports the subset of commands used by the app.
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"leaderboard-api/internal/infrastructure/db/redis"
+)
+
+type entry struct {
+	set      map[string]struct{}
+	str      *string
+	expireAt time.Time
+}
+
+type Server struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	data  map[string]*entry
+	now   time.Time
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer - starts the server on a random local port, it's stopped on test cleanup.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("redistest: listen: %v", err)
+	}
+	s := &Server{
+		ln:    ln,
+		data:  make(map[string]*entry),
+		now:   time.Now(),
+		conns: make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *Server) Addr() string { return s.ln.Addr().String() }
+
+// Close - stops the server and drops all client connections.
+func (s *Server) Close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// FastForward - moves the server clock, keys with expired TTL disappear.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	s.now = s.now.Add(d)
+	s.mu.Unlock()
+}
+
+// Members - content of a set, sorted.
+func (s *Server) Members(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.get(key)
+	if e == nil {
+		return nil
+	}
+	res := make([]string, 0, len(e.set))
+	for m := range e.set {
+		res = append(res, m)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Keys - all live keys, sorted.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys("*")
+}
+
+// TTL - remaining time to live of a key, 0 without expiry.
+func (s *Server) TTL(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.get(key)
+	if e == nil || e.expireAt.IsZero() {
+		return 0
+	}
+
+	return e.expireAt.Sub(s.now)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = c.Close()
+		s.wg.Done()
+	}()
+
+	rd := bufio.NewReader(c)
+	wr := bufio.NewWriter(c)
+	for {
+		reply, err := redis.ReadReply(rd)
+		if err != nil {
+			return
+		}
+		args, ok := toArgs(reply)
+		if !ok || len(args) == 0 {
+			writeReply(wr, redis.Error("ERR protocol error"))
+		} else {
+			s.mu.Lock()
+			res := s.exec(strings.ToUpper(args[0]), args[1:])
+			s.mu.Unlock()
+			writeReply(wr, res)
+		}
+		if err := wr.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) exec(cmd string, args []string) any {
+	switch cmd {
+	case "PING":
+		return "PONG"
+	case "AUTH", "SELECT":
+		return "OK"
+	case "SADD":
+		if len(args) < 2 {
+			return errArgs(cmd)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			e = &entry{set: make(map[string]struct{})}
+			s.data[args[0]] = e
+		}
+		if e.set == nil {
+			return errType
+		}
+		added := int64(0)
+		for _, m := range args[1:] {
+			if _, ok := e.set[m]; !ok {
+				e.set[m] = struct{}{}
+				added++
+			}
+		}
+		return added
+	case "SISMEMBER":
+		if len(args) != 2 {
+			return errArgs(cmd)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return int64(0)
+		}
+		if _, ok := e.set[args[1]]; ok {
+			return int64(1)
+		}
+		return int64(0)
+	case "SREM":
+		if len(args) < 2 {
+			return errArgs(cmd)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return int64(0)
+		}
+		removed := int64(0)
+		for _, m := range args[1:] {
+			if _, ok := e.set[m]; ok {
+				delete(e.set, m)
+				removed++
+			}
+		}
+		if len(e.set) == 0 {
+			delete(s.data, args[0])
+		}
+		return removed
+	case "SCARD":
+		if len(args) != 1 {
+			return errArgs(cmd)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return int64(0)
+		}
+		return int64(len(e.set))
+	case "SMEMBERS", "SSCAN":
+		if len(args) < 1 {
+			return errArgs(cmd)
+		}
+		var members []any
+		if e := s.get(args[0]); e != nil {
+			for m := range e.set {
+				members = append(members, m)
+			}
+		}
+		if cmd == "SMEMBERS" {
+			return members
+		}
+		// the whole set in one step
+		return []any{"0", members}
+	case "SCAN":
+		match := "*"
+		for i := 1; i+1 < len(args); i += 2 {
+			if strings.EqualFold(args[i], "MATCH") {
+				match = args[i+1]
+			}
+		}
+		var keys []any
+		for _, k := range s.keys(match) {
+			keys = append(keys, k)
+		}
+		return []any{"0", keys}
+	case "KEYS":
+		if len(args) != 1 {
+			return errArgs(cmd)
+		}
+		var keys []any
+		for _, k := range s.keys(args[0]) {
+			keys = append(keys, k)
+		}
+		return keys
+	case "DEL":
+		deleted := int64(0)
+		for _, k := range args {
+			if s.get(k) != nil {
+				delete(s.data, k)
+				deleted++
+			}
+		}
+		return deleted
+	case "EXPIRE":
+		if len(args) != 2 {
+			return errArgs(cmd)
+		}
+		sec, err := strconv.Atoi(args[1])
+		if err != nil {
+			return redis.Error("ERR value is not an integer or out of range")
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return int64(0)
+		}
+		e.expireAt = s.now.Add(time.Duration(sec) * time.Second)
+		return int64(1)
+	case "SET":
+		if len(args) < 2 {
+			return errArgs(cmd)
+		}
+		nx := false
+		var ttl time.Duration
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "EX":
+				if i+1 < len(args) {
+					sec, _ := strconv.Atoi(args[i+1])
+					ttl = time.Duration(sec) * time.Second
+					i++
+				}
+			}
+		}
+		if nx && s.get(args[0]) != nil {
+			return nil
+		}
+		v := args[1]
+		e := &entry{str: &v}
+		if ttl > 0 {
+			e.expireAt = s.now.Add(ttl)
+		}
+		s.data[args[0]] = e
+		return "OK"
+	case "GET":
+		if len(args) != 1 {
+			return errArgs(cmd)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return nil
+		}
+		if e.str == nil {
+			return errType
+		}
+		return *e.str
+	default:
+		return redis.Error(fmt.Sprintf("ERR unknown command '%s'", cmd))
+	}
+}
+
+var errType = redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+func errArgs(cmd string) redis.Error {
+	return redis.Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
+
+// get - the live entry of the key, expired keys are removed lazily.
+func (s *Server) get(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !s.now.Before(e.expireAt) {
+		delete(s.data, key)
+		return nil
+	}
+
+	return e
+}
+
+func (s *Server) keys(match string) []string {
+	var res []string
+	for k := range s.data {
+		if s.get(k) == nil {
+			continue
+		}
+		if ok, _ := path.Match(match, k); ok {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func toArgs(reply any) ([]string, bool) {
+	arr, ok := reply.([]any)
+	if !ok {
+		return nil, false
+	}
+	args := make([]string, len(arr))
+	for i, a := range arr {
+		if args[i], ok = a.(string); !ok {
+			return nil, false
+		}
+	}
+
+	return args, true
+}
+
+func writeReply(w io.Writer, v any) {
+	var rerr redis.Error
+	switch r := v.(type) {
+	case nil:
+		_, _ = io.WriteString(w, "$-1\r\n")
+	case string:
+		if r == "OK" || r == "PONG" {
+			_, _ = fmt.Fprintf(w, "+%s\r\n", r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(r), r)
+	case int64:
+		_, _ = fmt.Fprintf(w, ":%d\r\n", r)
+	case []any:
+		_, _ = fmt.Fprintf(w, "*%d\r\n", len(r))
+		for _, it := range r {
+			writeReply(w, it)
+		}
+	case error:
+		if errors.As(r, &rerr) {
+			_, _ = fmt.Fprintf(w, "-%s\r\n", string(rerr))
+		}
+	}
+}
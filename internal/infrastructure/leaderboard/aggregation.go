@@ -0,0 +1,255 @@
+package leaderboard
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	AggregationMax    = "max"
+	AggregationMin    = "min"
+	AggregationSum    = "sum"
+	AggregationLatest = "latest"
+	AggregationAvg    = "avg"
+	AggregationDecay  = "decay"
+)
+
+// Aggregation - how the scores of one talent are folded into its ranking.
+// The board asks the policy for the key of the talent after every event,
+// so any policy(also non-monotonic ones) just re-keys the talent in the tree.
+type Aggregation interface {
+	Name() string
+	// Add - folds one more score into the state.
+	Add(st state, score float64, ts time.Time) state
+	// Merge - state of two consecutive periods, a is the older one.
+	// Used to rebuild the rolling window from its days.
+	Merge(a, b state) state
+	// Key - ranking key of the state, the higher the better.
+	Key(st state) float64
+	// Score - the score shown to the clients.
+	Score(st state, now time.Time) float64
+}
+
+// state - aggregated score of one talent on one board.
+type state struct {
+	Value float64
+	Count int
+	// TS - when the current Value was achieved.
+	TS time.Time
+	// Last - the last scores, oldest first(only for avg of the last K).
+	Last []float64
+}
+
+func NewAggregation(name string, avgLast int, halfLife time.Duration) (Aggregation, error) {
+	switch name {
+	case AggregationMax, "":
+		return maxAgg{}, nil
+	case AggregationMin:
+		return minAgg{}, nil
+	case AggregationSum:
+		return sumAgg{}, nil
+	case AggregationLatest:
+		return latestAgg{}, nil
+	case AggregationAvg:
+		if avgLast <= 0 {
+			return nil, fmt.Errorf("aggregation %q: the number of last scores must be > 0", name)
+		}
+		return avgAgg{k: avgLast}, nil
+	case AggregationDecay:
+		if halfLife <= 0 {
+			return nil, fmt.Errorf("aggregation %q: half-life must be > 0", name)
+		}
+		return decayAgg{lambda: math.Ln2 / halfLife.Seconds()}, nil
+	default:
+		return nil, fmt.Errorf("unknown aggregation %q", name)
+	}
+}
+
+// maxAgg - the best score wins(default).
+type maxAgg struct{}
+
+func (maxAgg) Name() string { return AggregationMax }
+
+func (maxAgg) Add(st state, score float64, ts time.Time) state {
+	if st.Count == 0 || score > st.Value {
+		st.Value, st.TS = score, ts
+	}
+	st.Count++
+	return st
+}
+
+func (maxAgg) Merge(a, b state) state {
+	if b.Count > 0 && (a.Count == 0 || b.Value > a.Value) {
+		a.Value, a.TS = b.Value, b.TS
+	}
+	a.Count += b.Count
+	return a
+}
+
+func (maxAgg) Key(st state) float64                { return st.Value }
+func (maxAgg) Score(st state, _ time.Time) float64 { return st.Value }
+
+// minAgg - the lowest score wins(time-trial style).
+type minAgg struct{}
+
+func (minAgg) Name() string { return AggregationMin }
+
+func (minAgg) Add(st state, score float64, ts time.Time) state {
+	if st.Count == 0 || score < st.Value {
+		st.Value, st.TS = score, ts
+	}
+	st.Count++
+	return st
+}
+
+func (minAgg) Merge(a, b state) state {
+	if b.Count > 0 && (a.Count == 0 || b.Value < a.Value) {
+		a.Value, a.TS = b.Value, b.TS
+	}
+	a.Count += b.Count
+	return a
+}
+
+func (minAgg) Key(st state) float64                { return -st.Value }
+func (minAgg) Score(st state, _ time.Time) float64 { return st.Value }
+
+// sumAgg - sum of all scores.
+type sumAgg struct{}
+
+func (sumAgg) Name() string { return AggregationSum }
+
+func (sumAgg) Add(st state, score float64, ts time.Time) state {
+	st.Value += score
+	st.TS = ts
+	st.Count++
+	return st
+}
+
+func (sumAgg) Merge(a, b state) state {
+	a.Value += b.Value
+	a.TS = later(a.TS, b.TS)
+	a.Count += b.Count
+	return a
+}
+
+func (sumAgg) Key(st state) float64                { return st.Value }
+func (sumAgg) Score(st state, _ time.Time) float64 { return st.Value }
+
+// latestAgg - the score of the latest event(by TS) wins,
+// late events that arrive out of order are ignored.
+type latestAgg struct{}
+
+func (latestAgg) Name() string { return AggregationLatest }
+
+func (latestAgg) Add(st state, score float64, ts time.Time) state {
+	if st.Count == 0 || !ts.Before(st.TS) {
+		st.Value, st.TS = score, ts
+	}
+	st.Count++
+	return st
+}
+
+func (latestAgg) Merge(a, b state) state {
+	if b.Count > 0 && (a.Count == 0 || !b.TS.Before(a.TS)) {
+		a.Value, a.TS = b.Value, b.TS
+	}
+	a.Count += b.Count
+	return a
+}
+
+func (latestAgg) Key(st state) float64                { return st.Value }
+func (latestAgg) Score(st state, _ time.Time) float64 { return st.Value }
+
+// avgAgg - average of the last k scores.
+type avgAgg struct {
+	k int
+}
+
+func (avgAgg) Name() string { return AggregationAvg }
+
+func (a avgAgg) Add(st state, score float64, ts time.Time) state {
+	// never share the backing array with the previous state
+	last := make([]float64, 0, min(len(st.Last)+1, a.k))
+	last = append(last, st.Last[max(len(st.Last)+1-a.k, 0):]...)
+	st.Last = append(last, score)
+	st.Value = mean(st.Last)
+	st.TS = ts
+	st.Count++
+	return st
+}
+
+func (a avgAgg) Merge(x, y state) state {
+	last := append(append([]float64(nil), x.Last...), y.Last...)
+	x.Last = last[max(len(last)-a.k, 0):]
+	x.Value = mean(x.Last)
+	x.TS = later(x.TS, y.TS)
+	x.Count += y.Count
+	return x
+}
+
+func (avgAgg) Key(st state) float64                { return st.Value }
+func (avgAgg) Score(st state, _ time.Time) float64 { return st.Value }
+
+// decayAgg - exponentially decayed sum: every score loses half of its weight
+// each half-life. Value is the decayed sum as of TS.
+// All talents decay at the same rate, so the order is kept by
+// ln(Value) + lambda*TS without touching the tree as the time goes.
+type decayAgg struct {
+	// lambda - decay rate per second.
+	lambda float64
+}
+
+func (decayAgg) Name() string { return AggregationDecay }
+
+func (d decayAgg) Add(st state, score float64, ts time.Time) state {
+	return d.Merge(st, state{Value: score, TS: ts, Count: 1})
+}
+
+func (d decayAgg) Merge(a, b state) state {
+	if a.Count == 0 {
+		return b
+	}
+	if b.Count == 0 {
+		return a
+	}
+	if b.TS.Before(a.TS) {
+		a, b = b, a
+	}
+	b.Value += d.decay(a.Value, b.TS.Sub(a.TS))
+	b.Count += a.Count
+	return b
+}
+
+func (d decayAgg) Key(st state) float64 {
+	if st.Value <= 0 {
+		return -math.MaxFloat64
+	}
+	return math.Log(st.Value) + d.lambda*float64(st.TS.Unix())
+}
+
+func (d decayAgg) Score(st state, now time.Time) float64 {
+	return d.decay(st.Value, now.Sub(st.TS))
+}
+
+func (d decayAgg) decay(v float64, age time.Duration) float64 {
+	return v * math.Exp(-d.lambda*max(age.Seconds(), 0))
+}
+
+func mean(vs []float64) float64 {
+	if len(vs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range vs {
+		sum += v
+	}
+	return sum / float64(len(vs))
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
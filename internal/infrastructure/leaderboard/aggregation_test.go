@@ -0,0 +1,165 @@
+package leaderboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+type scoreAt struct {
+	score float64
+	hours int
+}
+
+var aggEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fold(agg Aggregation, scores []scoreAt) state {
+	var st state
+	for _, s := range scores {
+		st = agg.Add(st, s.score, aggEpoch.Add(time.Duration(s.hours)*time.Hour))
+	}
+	return st
+}
+
+func TestAggregation_Table(t *testing.T) {
+	scores := []scoreAt{{10, 0}, {30, 1}, {20, 2}, {5, 3}}
+
+	tests := []struct {
+		name      string
+		agg       string
+		scores    []scoreAt
+		wantValue float64
+		wantHours int
+	}{
+		{"Max keeps the best", AggregationMax, scores, 30, 1},
+		{"Min keeps the lowest", AggregationMin, scores, 5, 3},
+		{"Sum of all", AggregationSum, scores, 65, 3},
+		{"Latest wins", AggregationLatest, scores, 5, 3},
+		{"Latest ignores late events", AggregationLatest, []scoreAt{{10, 5}, {99, 1}}, 10, 5},
+		{"Avg of the last 3", AggregationAvg, scores, (30 + 20 + 5) / 3.0, 3},
+		{"Avg with less than 3", AggregationAvg, scores[:2], 20, 1},
+		{"Decay halves in one half-life", AggregationDecay, []scoreAt{{100, 0}, {10, 1}}, 60, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg, err := NewAggregation(tt.agg, 3, time.Hour)
+			require.NoError(t, err)
+			require.Equal(t, tt.agg, agg.Name())
+
+			st := fold(agg, tt.scores)
+			require.InDelta(t, tt.wantValue, st.Value, 1e-9)
+			require.Equal(t, len(tt.scores), st.Count)
+			require.Equal(t, aggEpoch.Add(time.Duration(tt.wantHours)*time.Hour), st.TS)
+		})
+	}
+}
+
+// TestAggregation_Merge - the rolling window is rebuilt by merging days,
+// it must give the same result as adding all the scores one by one.
+func TestAggregation_Merge(t *testing.T) {
+	scores := []scoreAt{{10, 0}, {30, 1}, {20, 2}, {5, 3}, {15, 30}}
+
+	for _, name := range []string{AggregationMax, AggregationMin, AggregationSum, AggregationLatest, AggregationAvg, AggregationDecay} {
+		t.Run(name, func(t *testing.T) {
+			agg, err := NewAggregation(name, 3, time.Hour)
+			require.NoError(t, err)
+
+			want := fold(agg, scores)
+			got := agg.Merge(agg.Merge(state{}, fold(agg, scores[:2])), fold(agg, scores[2:]))
+			require.InDelta(t, want.Value, got.Value, 1e-9)
+			require.Equal(t, want.Count, got.Count)
+			require.InDelta(t, agg.Key(want), agg.Key(got), 1e-9)
+		})
+	}
+}
+
+func TestAggregation_Order(t *testing.T) {
+	tests := []struct {
+		name   string
+		agg    string
+		better []scoreAt
+		worse  []scoreAt
+	}{
+		{"Max", AggregationMax, []scoreAt{{50, 0}}, []scoreAt{{40, 0}}},
+		{"Min - lower time is better", AggregationMin, []scoreAt{{40, 0}}, []scoreAt{{50, 0}}},
+		{"Decay - fresh score beats an old bigger one", AggregationDecay, []scoreAt{{30, 10}}, []scoreAt{{100, 0}}},
+		{"Decay - same age, bigger wins", AggregationDecay, []scoreAt{{30, 10}}, []scoreAt{{20, 10}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg, err := NewAggregation(tt.agg, 3, time.Hour)
+			require.NoError(t, err)
+			better, worse := fold(agg, tt.better), fold(agg, tt.worse)
+			require.Greater(t, agg.Key(better), agg.Key(worse))
+
+			now := aggEpoch.Add(20 * time.Hour)
+			if tt.agg != AggregationMin {
+				require.Greater(t, agg.Score(better, now), agg.Score(worse, now))
+			}
+		})
+	}
+}
+
+func TestNewAggregation_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		agg      string
+		avgLast  int
+		halfLife time.Duration
+	}{
+		{"Unknown", "median", 3, time.Hour},
+		{"Avg without K", AggregationAvg, 0, time.Hour},
+		{"Decay without half-life", AggregationDecay, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAggregation(tt.agg, tt.avgLast, tt.halfLife)
+			require.Error(t, err)
+		})
+	}
+}
+
+// TestAggregation_Rekey - non-monotonic policies move talents down the board as well.
+func TestAggregation_Rekey(t *testing.T) {
+	tests := []struct {
+		name      string
+		agg       string
+		wantIDs   []string
+		wantScore float64
+	}{
+		{"Max", AggregationMax, []string{"t1", "t2"}, 90},
+		{"Latest", AggregationLatest, []string{"t2", "t1"}, 10},
+		{"Sum", AggregationSum, []string{"t1", "t2"}, 100},
+		{"Min", AggregationMin, []string{"t1", "t2"}, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg, err := NewAggregation(tt.agg, 3, time.Hour)
+			require.NoError(t, err)
+			cfg := testConfig
+			cfg.Aggregation = agg
+			lb := newTestLBWith(t, cfg)
+
+			ts := time.Now()
+			_ = lb.updateIfBetter(event.Event{TalentID: "t1", Score: 90, TS: ts})
+			_ = lb.updateIfBetter(event.Event{TalentID: "t2", Score: 50, TS: ts})
+			_ = lb.updateIfBetter(event.Event{TalentID: "t1", Score: 10, TS: ts.Add(time.Second)})
+
+			page := lb.Range(leader.Scope{}, 0, 10)
+			require.Equal(t, tt.wantIDs, ids(page.Leaders))
+
+			l, ok := lb.RankOf(leader.Scope{}, "t1")
+			require.True(t, ok)
+			require.Equal(t, tt.wantScore, l.Score)
+			require.Equal(t, 2, lb.boards[leader.Scope{}].tree.Len())
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package leaderboard
+
+// Compactor - the event log, the events of a snapshot are not needed in it anymore.
+type Compactor interface {
+	Compact(upTo uint64) error
+}
+
+// applied - which logged events(event.Seq) are already on the boards.
+// Events come out of the scorer pool in any order, so besides the
+// contiguous prefix we keep the few that are ahead of it.
+type applied struct {
+	upTo  uint64
+	ahead map[uint64]struct{}
+}
+
+func (a *applied) add(seq uint64) {
+	if seq == 0 || a.has(seq) {
+		return
+	}
+	if seq != a.upTo+1 {
+		if a.ahead == nil {
+			a.ahead = make(map[uint64]struct{})
+		}
+		a.ahead[seq] = struct{}{}
+		return
+	}
+	a.upTo = seq
+	for {
+		if _, ok := a.ahead[a.upTo+1]; !ok {
+			return
+		}
+		delete(a.ahead, a.upTo+1)
+		a.upTo++
+	}
+}
+
+func (a *applied) has(seq uint64) bool {
+	if seq <= a.upTo {
+		return true
+	}
+	_, ok := a.ahead[seq]
+	return ok
+}
+
+// Applied - whether the logged event is on the boards already, replay skips it.
+func (lbm *LBMemory) Applied(seq uint64) bool {
+	lbm.mu.RLock()
+	defer lbm.mu.RUnlock()
+
+	return lbm.applied.has(seq)
+}
@@ -0,0 +1,70 @@
+package leaderboard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+func TestApplied_Table(t *testing.T) {
+	tests := []struct {
+		name      string
+		seqs      []uint64
+		wantUpTo  uint64
+		wantAhead int
+	}{
+		{"In order", []uint64{1, 2, 3}, 3, 0},
+		{"Out of order", []uint64{3, 1, 2}, 3, 0},
+		{"Gap", []uint64{1, 3, 4}, 1, 2},
+		{"Not logged", []uint64{0, 0}, 0, 0},
+		{"Repeated", []uint64{2, 2, 1}, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a applied
+			for _, seq := range tt.seqs {
+				a.add(seq)
+			}
+			require.Equal(t, tt.wantUpTo, a.upTo)
+			require.Len(t, a.ahead, tt.wantAhead)
+			for _, seq := range tt.seqs {
+				if seq > 0 {
+					require.True(t, a.has(seq))
+				}
+			}
+		})
+	}
+}
+
+type compactorFunc func(upTo uint64) error
+
+func (f compactorFunc) Compact(upTo uint64) error { return f(upTo) }
+
+// TestSnapshot_Applied - the snapshot keeps which logged events it has,
+// the log is compacted up to the contiguous prefix only.
+func TestSnapshot_Applied(t *testing.T) {
+	dir := t.TempDir()
+	lb := newSnapshotLB(t, dir, snapshotNow)
+	var compacted []uint64
+	lb.compactor = compactorFunc(func(upTo uint64) error {
+		compacted = append(compacted, upTo)
+		return nil
+	})
+	for _, seq := range []uint64{1, 2, 4} {
+		lb.updateIfBetter(event.Event{TalentID: "t1", Score: 10, TS: snapshotNow, Seq: seq})
+	}
+
+	snap, err := lb.Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), snap.Seq)
+	require.Equal(t, []uint64{2}, compacted)
+
+	restored := newSnapshotLB(t, dir, snapshotNow)
+	for seq, want := range map[uint64]bool{1: true, 2: true, 3: false, 4: true, 5: false} {
+		require.Equal(t, want, restored.Applied(seq), "seq %d", seq)
+	}
+}
@@ -0,0 +1,212 @@
+package leaderboard
+
+import (
+	"time"
+
+	"leaderboard-api/internal/domain/leader"
+)
+
+// board - one ordered index of talents: the global board or a per-skill one.
+// Not safe for concurrent use, LBMemory guards all boards with one lock.
+type board struct {
+	agg     Aggregation
+	ranking Ranking
+	talents map[string]state
+	// Even after 100 million insertions(burst of writes), search remains
+	// almost just as fast because a B-tree is a “wide and shallow” structure with
+	// excellent cache locality and strict balancing.
+	// On top of that every node counts the items of its subtree,
+	// so rank lookups are O(log N) as well (see rankTree).
+	tree *rankTree[key]
+	// keys - the distinct ranking keys and the talents of each one,
+	// kept for the dense ranks only: a dense rank is the position of the key.
+	keys *rankTree[float64]
+	ties map[float64]int
+}
+
+// key - Score is the ranking key of the aggregation, not the shown score.
+type key struct {
+	Score float64
+	// bound - 1 is above and -1 below every talent with the Score, only to search the tree.
+	bound int8
+	// Achieved - unix nanoseconds of the Score, the earliest tie-break only.
+	Achieved int64
+	TalentID string
+}
+
+func newBoard(agg Aggregation, ranking Ranking) *board {
+	b := &board{
+		agg:     agg,
+		ranking: ranking,
+		talents: make(map[string]state),
+		tree:    newRankTree[key](defaultDegree, less),
+	}
+	if ranking.Type == leader.RankDense {
+		b.keys = newRankTree[float64](defaultDegree, func(a, b float64) bool { return a < b })
+		b.ties = make(map[float64]int)
+	}
+
+	return b
+}
+
+// add -  O(log N) folds the score into the talent's state.
+// Reports whether the ranking key of the talent has changed.
+func (b *board) add(talentID string, score float64, ts time.Time) (changed bool) {
+	old, ok := b.talents[talentID]
+	return b.set(talentID, old, ok, b.agg.Add(old, score, ts))
+}
+
+// merge -  O(log N) folds a state of a newer period into the talent's state.
+func (b *board) merge(talentID string, st state) {
+	old, ok := b.talents[talentID]
+	b.set(talentID, old, ok, b.agg.Merge(old, st))
+}
+
+func (b *board) set(talentID string, old state, existed bool, st state) (changed bool) {
+	b.talents[talentID] = st
+	oldKey, newKey := b.key(talentID, old), b.key(talentID, st)
+	if existed && oldKey == newKey {
+		return false
+	}
+	if existed {
+		b.tree.Delete(oldKey)
+		b.untie(oldKey.Score)
+	}
+	b.tree.ReplaceOrInsert(newKey)
+	b.tie(newKey.Score)
+
+	return true
+}
+
+func (b *board) key(talentID string, st state) key {
+	k := key{Score: b.agg.Key(st), TalentID: talentID}
+	if b.ranking.TieBreak == TieBreakEarliest {
+		k.Achieved = st.TS.UnixNano()
+	}
+
+	return k
+}
+
+func (b *board) tie(score float64) {
+	if b.keys == nil {
+		return
+	}
+	if b.ties[score]++; b.ties[score] == 1 {
+		b.keys.ReplaceOrInsert(score)
+	}
+}
+
+func (b *board) untie(score float64) {
+	if b.keys == nil {
+		return
+	}
+	if b.ties[score]--; b.ties[score] == 0 {
+		delete(b.ties, score)
+		b.keys.Delete(score)
+	}
+}
+
+// descend - n leaders starting from the ascending tree position "from",
+// ranked by the rank type of the board.
+func (b *board) descend(from, n int, now time.Time) leader.Leaders {
+	return b.descendBy(from, n, now, b.ranking.Type)
+}
+
+// descendBy - the streams number the rows by their position(ordinal)
+// whatever the rank type is, a move shifts the rows in between by one.
+func (b *board) descendBy(from, n int, now time.Time, rt leader.RankType) leader.Leaders {
+	if from < 0 || n <= 0 {
+		return leader.Leaders{}
+	}
+
+	ls := make(leader.Leaders, 0, min(n, from+1))
+	pos := b.tree.Len() - from
+	rank := 0
+	var prev float64
+	b.tree.DescendFrom(from, func(k key) bool {
+		switch {
+		case rt == leader.RankOrdinal:
+			rank = pos
+		case len(ls) == 0:
+			rank = b.rankOf(from, k.Score, rt)
+		case k.Score != prev && rt == leader.RankDense:
+			rank++
+		case k.Score != prev:
+			rank = pos
+		}
+		ls = append(ls, b.leader(k, rank, now))
+		prev = k.Score
+		pos++
+		return len(ls) < n
+	})
+
+	return ls
+}
+
+// rankOf - O(log N) the rank of the row at the ascending tree position idx with the key score.
+func (b *board) rankOf(idx int, score float64, rt leader.RankType) int {
+	switch rt {
+	case leader.RankCompetition:
+		// one after the talents with a higher key
+		above, _ := b.tree.IndexOf(key{Score: score, bound: 1})
+		return b.tree.Len() - above + 1
+	case leader.RankDense:
+		i, _ := b.keys.IndexOf(score)
+		return b.keys.Len() - i
+	default:
+		return b.tree.Len() - idx
+	}
+}
+
+// page - descend with the cursor of the next page, if there is one.
+func (b *board) page(from, n int, now time.Time) leader.Page {
+	p := leader.Page{Leaders: b.descend(from, n, now)}
+	last := from - len(p.Leaders) + 1
+	if len(p.Leaders) > 0 && last > 0 {
+		k, _ := b.tree.At(last)
+		p.Next = &leader.Cursor{Key: k.Score, Achieved: k.Achieved, TalentID: k.TalentID}
+	}
+
+	return p
+}
+
+func (b *board) leader(k key, rank int, now time.Time) *leader.Leader {
+	return &leader.Leader{Rank: rank, TalentID: k.TalentID, Score: b.agg.Score(b.talents[k.TalentID], now)}
+}
+
+// indexOf - ascending tree position of the talent.
+func (b *board) indexOf(talentID string) (idx int, st state, ok bool) {
+	st, ok = b.talents[talentID]
+	if !ok {
+		return 0, st, false
+	}
+	idx, ok = b.tree.IndexOf(b.key(talentID, st))
+
+	return idx, st, ok
+}
+
+// rank - the position of the talent(ordinal rank), 0 if it is not on the board.
+func (b *board) rank(talentID string) int {
+	idx, _, ok := b.indexOf(talentID)
+	if !ok {
+		return 0
+	}
+
+	return b.tree.Len() - idx
+}
+
+// less - comparator that determines the overall order of keys in the tree,
+// the tie-break of the board decides whether the keys have Achieved.
+func less(a, b key) bool {
+	switch {
+	case a.Score != b.Score:
+		return a.Score < b.Score
+	case a.bound != b.bound:
+		return a.bound < b.bound
+	case a.Achieved != b.Achieved:
+		// the earlier one ranks higher, the tree is ascending
+		return a.Achieved > b.Achieved
+	}
+
+	return a.TalentID < b.TalentID
+}
@@ -0,0 +1,385 @@
+package leaderboard
+
+import (
+	"sort"
+)
+
+// rankTree - order-statistic B-tree.
+// Same "wide and shallow" layout as google/btree, but every node also keeps
+// the number of items in its subtree. That lets us answer
+// "what is the position of the key" and "which key is at the position"
+// in O(log N) instead of walking the tree item by item.
+// Not safe for concurrent use, LBMemory guards it with its own lock.
+type rankTree[T any] struct {
+	degree int
+	less   func(a, b T) bool
+	root   *rankNode[T]
+}
+
+type rankNode[T any] struct {
+	items    []T
+	children []*rankNode[T]
+	// size - number of items in the subtree rooted at this node.
+	size int
+}
+
+func newRankTree[T any](degree int, less func(a, b T) bool) *rankTree[T] {
+	if degree < 2 {
+		panic("leaderboard: rank tree degree must be >= 2")
+	}
+
+	return &rankTree[T]{degree: degree, less: less}
+}
+
+func (t *rankTree[T]) maxItems() int { return t.degree*2 - 1 }
+func (t *rankTree[T]) minItems() int { return t.degree - 1 }
+
+// Len - O(1)
+func (t *rankTree[T]) Len() int {
+	if t.root == nil {
+		return 0
+	}
+
+	return t.root.size
+}
+
+// ReplaceOrInsert - O(log N)
+func (t *rankTree[T]) ReplaceOrInsert(item T) (old T, replaced bool) {
+	if t.root == nil {
+		t.root = &rankNode[T]{items: []T{item}, size: 1}
+		return old, false
+	}
+	if len(t.root.items) >= t.maxItems() {
+		mid, second := t.split(t.root, t.maxItems()/2)
+		first := t.root
+		t.root = &rankNode[T]{items: []T{mid}, children: []*rankNode[T]{first, second}}
+		t.root.recount()
+	}
+
+	return t.insert(t.root, item)
+}
+
+// Delete - O(log N)
+func (t *rankTree[T]) Delete(item T) (old T, deleted bool) {
+	if t.root == nil {
+		return old, false
+	}
+	old, deleted = t.remove(t.root, item)
+	if len(t.root.items) == 0 {
+		if len(t.root.children) > 0 {
+			t.root = t.root.children[0]
+		} else {
+			t.root = nil
+		}
+	}
+
+	return old, deleted
+}
+
+// IndexOf - O(log N) ascending 0-based position of the item.
+// When the item is absent it returns the position it would be inserted at.
+func (t *rankTree[T]) IndexOf(item T) (idx int, found bool) {
+	for n := t.root; n != nil; {
+		i, ok := t.find(n, item)
+		for j := 0; j < i; j++ {
+			idx++
+			if !n.leaf() {
+				idx += n.children[j].size
+			}
+		}
+		if n.leaf() {
+			return idx, ok
+		}
+		if ok {
+			return idx + n.children[i].size, true
+		}
+		n = n.children[i]
+	}
+
+	return idx, false
+}
+
+// At - O(log N) item at the ascending 0-based position.
+func (t *rankTree[T]) At(idx int) (item T, ok bool) {
+	if idx < 0 || idx >= t.Len() {
+		return item, false
+	}
+
+	n := t.root
+walk:
+	for !n.leaf() {
+		for j, c := range n.children {
+			if idx < c.size {
+				n = c
+				continue walk
+			}
+			idx -= c.size
+			if idx == 0 {
+				return n.items[j], true
+			}
+			idx--
+		}
+	}
+
+	return n.items[idx], true
+}
+
+// AscendFrom - O(log N + n) calls fn for items starting at the ascending
+// position idx until fn returns false.
+func (t *rankTree[T]) AscendFrom(idx int, fn func(T) bool) {
+	if t.root == nil || idx >= t.Len() {
+		return
+	}
+	t.ascend(t.root, idx, fn)
+}
+
+// DescendFrom - O(log N + n) calls fn for items starting at the ascending
+// position idx and moving towards the smallest one until fn returns false.
+func (t *rankTree[T]) DescendFrom(idx int, fn func(T) bool) {
+	if t.root == nil || idx < 0 {
+		return
+	}
+	t.descend(t.root, idx, fn)
+}
+
+func (t *rankTree[T]) ascend(n *rankNode[T], from int, fn func(T) bool) bool {
+	if n.leaf() {
+		for j := max(from, 0); j < len(n.items); j++ {
+			if !fn(n.items[j]) {
+				return false
+			}
+		}
+		return true
+	}
+	for j, c := range n.children {
+		if from < c.size && !t.ascend(c, from, fn) {
+			return false
+		}
+		from -= c.size
+		if j < len(n.items) {
+			if from <= 0 && !fn(n.items[j]) {
+				return false
+			}
+			from--
+		}
+	}
+
+	return true
+}
+
+func (t *rankTree[T]) descend(n *rankNode[T], from int, fn func(T) bool) bool {
+	if n.leaf() {
+		for j := min(from, len(n.items)-1); j >= 0; j-- {
+			if !fn(n.items[j]) {
+				return false
+			}
+		}
+		return true
+	}
+	// walking from the right, base is the ascending position
+	// of the first item of the current item/child
+	base := n.size
+	for j := len(n.children) - 1; j >= 0; j-- {
+		if j < len(n.items) {
+			base--
+			if base <= from && !fn(n.items[j]) {
+				return false
+			}
+		}
+		c := n.children[j]
+		base -= c.size
+		if base <= from && !t.descend(c, from-base, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// find - index of the first item greater than the given one, or the index
+// of the equal item when found.
+func (t *rankTree[T]) find(n *rankNode[T], item T) (int, bool) {
+	i := sort.Search(len(n.items), func(i int) bool { return t.less(item, n.items[i]) })
+	if i > 0 && !t.less(n.items[i-1], item) {
+		return i - 1, true
+	}
+
+	return i, false
+}
+
+func (t *rankTree[T]) insert(n *rankNode[T], item T) (old T, replaced bool) {
+	i, found := t.find(n, item)
+	if found {
+		old, n.items[i] = n.items[i], item
+		return old, true
+	}
+	if n.leaf() {
+		n.items = insertAt(n.items, i, item)
+		n.size++
+		return old, false
+	}
+	if t.maybeSplitChild(n, i) {
+		inTree := n.items[i]
+		switch {
+		case t.less(item, inTree):
+		case t.less(inTree, item):
+			i++
+		default:
+			old, n.items[i] = n.items[i], item
+			return old, true
+		}
+	}
+
+	old, replaced = t.insert(n.children[i], item)
+	if !replaced {
+		n.size++
+	}
+
+	return old, replaced
+}
+
+func (t *rankTree[T]) remove(n *rankNode[T], item T) (old T, deleted bool) {
+	i, found := t.find(n, item)
+	if n.leaf() {
+		if !found {
+			return old, false
+		}
+		old = n.items[i]
+		n.items = removeAt(n.items, i)
+		n.size--
+		return old, true
+	}
+	// make sure the child we are going to descend into can lose an item
+	if len(n.children[i].items) <= t.minItems() {
+		t.growChild(n, i)
+		return t.remove(n, item)
+	}
+	if found {
+		old = n.items[i]
+		n.items[i] = t.removeMax(n.children[i])
+		n.size--
+		return old, true
+	}
+	old, deleted = t.remove(n.children[i], item)
+	if deleted {
+		n.size--
+	}
+
+	return old, deleted
+}
+
+func (t *rankTree[T]) removeMax(n *rankNode[T]) T {
+	if n.leaf() {
+		last := n.items[len(n.items)-1]
+		n.items = n.items[:len(n.items)-1]
+		n.size--
+		return last
+	}
+	i := len(n.children) - 1
+	if len(n.children[i].items) <= t.minItems() {
+		t.growChild(n, i)
+		return t.removeMax(n)
+	}
+	n.size--
+
+	return t.removeMax(n.children[i])
+}
+
+// growChild - borrows an item from a sibling or merges with it,
+// so that the child i has more than minItems items.
+func (t *rankTree[T]) growChild(n *rankNode[T], i int) {
+	switch {
+	case i > 0 && len(n.children[i-1].items) > t.minItems():
+		child, left := n.children[i], n.children[i-1]
+		stolen := left.items[len(left.items)-1]
+		left.items = left.items[:len(left.items)-1]
+		child.items = insertAt(child.items, 0, n.items[i-1])
+		n.items[i-1] = stolen
+		if !left.leaf() {
+			moved := left.children[len(left.children)-1]
+			left.children = left.children[:len(left.children)-1]
+			child.children = insertAt(child.children, 0, moved)
+		}
+		left.recount()
+		child.recount()
+	case i < len(n.items) && len(n.children[i+1].items) > t.minItems():
+		child, right := n.children[i], n.children[i+1]
+		stolen := right.items[0]
+		right.items = removeAt(right.items, 0)
+		child.items = append(child.items, n.items[i])
+		n.items[i] = stolen
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = removeAt(right.children, 0)
+		}
+		right.recount()
+		child.recount()
+	default:
+		if i >= len(n.items) {
+			i--
+		}
+		child, merged := n.children[i], n.children[i+1]
+		child.items = append(child.items, n.items[i])
+		child.items = append(child.items, merged.items...)
+		child.children = append(child.children, merged.children...)
+		n.items = removeAt(n.items, i)
+		n.children = removeAt(n.children, i+1)
+		child.recount()
+	}
+}
+
+func (t *rankTree[T]) maybeSplitChild(n *rankNode[T], i int) bool {
+	if len(n.children[i].items) < t.maxItems() {
+		return false
+	}
+	item, second := t.split(n.children[i], t.maxItems()/2)
+	n.items = insertAt(n.items, i, item)
+	n.children = insertAt(n.children, i+1, second)
+
+	return true
+}
+
+// split - n keeps everything before i, the item at i goes up
+// and everything after i moves to the new node.
+func (t *rankTree[T]) split(n *rankNode[T], i int) (T, *rankNode[T]) {
+	item := n.items[i]
+	next := &rankNode[T]{items: append([]T(nil), n.items[i+1:]...)}
+	clear(n.items[i:])
+	n.items = n.items[:i]
+	if !n.leaf() {
+		next.children = append([]*rankNode[T](nil), n.children[i+1:]...)
+		clear(n.children[i+1:])
+		n.children = n.children[:i+1]
+	}
+	n.recount()
+	next.recount()
+
+	return item, next
+}
+
+func (n *rankNode[T]) leaf() bool { return len(n.children) == 0 }
+
+func (n *rankNode[T]) recount() {
+	size := len(n.items)
+	for _, c := range n.children {
+		size += c.size
+	}
+	n.size = size
+}
+
+func insertAt[S ~[]E, E any](s S, i int, v E) S {
+	var zero E
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+
+	return s
+}
+
+func removeAt[S ~[]E, E any](s S, i int) S {
+	copy(s[i:], s[i+1:])
+	var zero E
+	s[len(s)-1] = zero
+
+	return s[:len(s)-1]
+}
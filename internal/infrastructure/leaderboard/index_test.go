@@ -0,0 +1,102 @@
+package leaderboard
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func intLess(a, b int) bool { return a < b }
+
+func TestRankTree_Table(t *testing.T) {
+	tests := []struct {
+		name   string
+		degree int
+		ops    int
+	}{
+		{"Degree 2", 2, 5000},
+		{"Degree 3", 3, 5000},
+		{"Default degree", defaultDegree, 20000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, uint64(tt.degree)))
+			tr := newRankTree[int](tt.degree, intLess)
+			var want []int
+
+			for i := 0; i < tt.ops; i++ {
+				v := r.IntN(tt.ops / 2)
+				pos, found := slices.BinarySearch(want, v)
+				if r.IntN(3) == 0 {
+					_, deleted := tr.Delete(v)
+					require.Equal(t, found, deleted)
+					if found {
+						want = slices.Delete(want, pos, pos+1)
+					}
+				} else {
+					_, replaced := tr.ReplaceOrInsert(v)
+					require.Equal(t, found, replaced)
+					if !found {
+						want = slices.Insert(want, pos, v)
+					}
+				}
+				require.Equal(t, len(want), tr.Len())
+			}
+
+			for i, v := range want {
+				idx, found := tr.IndexOf(v)
+				require.True(t, found)
+				require.Equal(t, i, idx)
+
+				got, ok := tr.At(i)
+				require.True(t, ok)
+				require.Equal(t, v, got)
+			}
+
+			idx, found := tr.IndexOf(-1)
+			require.False(t, found)
+			require.Equal(t, 0, idx)
+			_, ok := tr.At(len(want))
+			require.False(t, ok)
+
+			from := len(want) / 3
+			var asc []int
+			tr.AscendFrom(from, func(v int) bool {
+				asc = append(asc, v)
+				return true
+			})
+			require.Equal(t, want[from:], asc)
+
+			var desc []int
+			tr.DescendFrom(from, func(v int) bool {
+				desc = append(desc, v)
+				return true
+			})
+			expected := slices.Clone(want[:from+1])
+			slices.Reverse(expected)
+			require.Equal(t, expected, desc)
+		})
+	}
+}
+
+func TestRankTree_Empty(t *testing.T) {
+	tr := newRankTree[int](defaultDegree, intLess)
+
+	_, deleted := tr.Delete(1)
+	require.False(t, deleted)
+	_, ok := tr.At(0)
+	require.False(t, ok)
+	_, found := tr.IndexOf(1)
+	require.False(t, found)
+
+	tr.ReplaceOrInsert(1)
+	tr.Delete(1)
+	require.Zero(t, tr.Len())
+	tr.DescendFrom(0, func(int) bool {
+		t.Fatal("unexpected item in an empty tree")
+		return false
+	})
+}
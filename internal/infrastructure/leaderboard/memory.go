@@ -2,13 +2,16 @@ package leaderboard
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
-	"github.com/google/btree"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	"leaderboard-api/internal/domain/event"
 	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
 	"leaderboard-api/internal/infrastructure/ml"
 )
 
@@ -19,55 +22,138 @@ import (
 // More writers 16–32
 const defaultDegree = 16
 
-type LBMemory struct {
-	mu           sync.RWMutex
-	in           ml.OutputChan
-	log          *zap.Logger
-	bestByTalent map[string]float64
-	// Even after 100 million insertions(burst of writes), search remains
-	// almost just as fast because a B-tree is a “wide and shallow” structure with
-	// excellent cache locality and strict balancing.
-	// This is why google/btree consistently provides
-	// fast Get and Ascend operations under heavy in-memory workloads.
-	tree    *btree.BTreeG[key]
-	metrics *prometheus.CounterVec
+type Config struct {
+	// Aggregation - score aggregation policy of all boards, max by default.
+	Aggregation Aggregation
+	// Ranking - the tie-break and the rank type of all boards, the zero value -
+	// the talent_id tie-break with ordinal ranks.
+	Ranking Ranking
+	// Location - timezone of the calendar windows(day, week, month).
+	Location *time.Location
+	// RollingDays - length of the rolling window including today.
+	RollingDays int
+	// SnapshotDir - where the boards are persisted, empty - no snapshots.
+	SnapshotDir      string
+	SnapshotInterval time.Duration
+	// Log - compacted after every snapshot, optional.
+	Log Compactor
+	// StreamBuffer - updates queued per stream subscriber, a slower one is dropped.
+	StreamBuffer int
+	// StreamHistory - the last changes kept for the resuming subscribers.
+	StreamHistory int
+	// Notify - gets the changes of the all-time boards made by every event,
+	// it's called under the write lock and must not block.
+	Notify func(e event.Event, changes []leader.Change)
+	// Season - the boards take the events of the running season only and
+	// start empty every season, the final standings are archived next
+	// to the snapshot. The zero value - no seasons.
+	Season season.Schedule
 }
 
-type key struct {
-	Score    float64
-	TalentID string
+type LBMemory struct {
+	mu  sync.RWMutex
+	in  ml.OutputChan
+	log *zap.Logger
+	// boards - the global board(zero Scope), one board per skill and
+	// the same pair for every time window.
+	// Every event updates the global board and the board of its skill
+	// in each window it belongs to.
+	boards       map[leader.Scope]*board
+	agg          Aggregation
+	ranking      Ranking
+	windows      *windows
+	now          func() time.Time
+	metrics      *prometheus.CounterVec
+	skillMetrics *prometheus.CounterVec
+	// hub - the stream subscribers of the all-time boards, nil in a shadow.
+	hub     *hub
+	notify  func(e event.Event, changes []leader.Change)
+	seasons *seasons
+
+	snapMu           sync.Mutex
+	snapshotDir      string
+	snapshotInterval time.Duration
+	applied          applied
+	compactor        Compactor
 }
 
-func New(ctx context.Context, log *zap.Logger, in ml.OutputChan, metrics *prometheus.CounterVec) *LBMemory {
+func New(
+	ctx context.Context,
+	log *zap.Logger,
+	in ml.OutputChan,
+	cfg Config,
+	metrics *prometheus.CounterVec,
+	skillMetrics *prometheus.CounterVec,
+) (*LBMemory, error) {
+	if cfg.Aggregation == nil {
+		cfg.Aggregation = maxAgg{}
+	}
+	if cfg.SnapshotInterval <= 0 {
+		cfg.SnapshotInterval = defaultSnapshotInterval
+	}
+	cfg.Ranking = cfg.Ranking.defaults()
 	lbm := &LBMemory{
 		log:          log,
-		bestByTalent: make(map[string]float64),
-		tree:         btree.NewG[key](defaultDegree, less),
+		agg:          cfg.Aggregation,
+		ranking:      cfg.Ranking,
+		boards:       map[leader.Scope]*board{{}: newBoard(cfg.Aggregation, cfg.Ranking)},
+		windows:      newWindows(cfg),
+		now:          time.Now,
 		in:           in,
 		metrics:      metrics,
+		skillMetrics: skillMetrics,
+		hub:          newHub(log, cfg.StreamBuffer, cfg.StreamHistory),
+		notify:       cfg.Notify,
+		seasons:      newSeasons(cfg),
+
+		snapshotDir:      cfg.SnapshotDir,
+		snapshotInterval: cfg.SnapshotInterval,
+		compactor:        cfg.Log,
 	}
+	lbm.rollover(lbm.now())
 
-	// also:
-	// lbm.wakeUp()
-	// lbm.backupWorker()
+	// In case of crush of our app we are able to restore the boards
+	if err := lbm.wakeUp(); err != nil {
+		return nil, err
+	}
+	if err := lbm.wakeUpSeasons(); err != nil {
+		return nil, err
+	}
 
-	return lbm
+	return lbm, nil
 }
 
 func (lbm *LBMemory) RunLBWorker(ctx context.Context) {
 	lbm.log.Info("starting leaderboard worker")
 
 	defer func() {
+		// every event of the closed channel is applied, nothing is lost by the last snapshot
+		if _, err := lbm.Snapshot(context.Background()); err != nil && !errors.Is(err, leader.ErrSnapshotDisabled) {
+			lbm.log.Error("leaderboard final snapshot failed", zap.Error(err))
+		}
 		lbm.log.Info("leaderboard worker gracefully stopped")
 	}()
 
-	for evnt := range lbm.in {
-		lbm.updateIfBetter(leader.Leader{
-			Rank:     0,
-			TalentID: evnt.TalentID,
-			Score:    evnt.Score,
-		})
-		lbm.metrics.WithLabelValues("accepted").Inc()
+	ticker := time.NewTicker(rolloverInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case evnt, ok := <-lbm.in:
+			if !ok {
+				return
+			}
+			if lbm.seasons.behind(evnt.TS) {
+				lbm.advanceSeason(lbm.now())
+			}
+			lbm.updateIfBetter(evnt)
+			lbm.metrics.WithLabelValues("accepted").Inc()
+		case <-ticker.C:
+			lbm.mu.Lock()
+			lbm.rollover(lbm.now())
+			lbm.mu.Unlock()
+			lbm.advanceSeason(lbm.now())
+		}
 	}
 }
 
@@ -76,23 +162,95 @@ func (lbm *LBMemory) StopRankWorker(ctx context.Context) {
 	lbm.log.Info("leaderboard rank worker gracefully stopped")
 }
 
-// updateIfBetter -  O(log N)
-func (lbm *LBMemory) updateIfBetter(l leader.Leader) (updated bool) {
+// updateIfBetter -  O(log N) per board.
+// "Better" is up to the aggregation policy: the event is folded into
+// the talent's state and the talent is re-keyed when its ranking changes.
+// Reports whether the global all-time board was updated.
+func (lbm *LBMemory) updateIfBetter(e event.Event) (updated bool) {
 	lbm.mu.Lock()
 	defer lbm.mu.Unlock()
 
-	old, ok := lbm.bestByTalent[l.TalentID]
-	if ok && l.Score <= old {
+	// already on the boards, e.g. by a rebuild that has caught up with the log
+	if e.Seq != 0 && lbm.applied.has(e.Seq) {
+		return false
+	}
+	lbm.rollover(lbm.now())
+	lbm.applied.add(e.Seq)
+	// e.g. queued when its season was archived
+	if !lbm.seasons.admits(e.TS) {
+		lbm.metrics.WithLabelValues("late").Inc()
 		return false
 	}
-	if ok {
-		lbm.tree.Delete(key{Score: old, TalentID: l.TalentID})
+
+	skills := []string{""}
+	if e.Skill != "" {
+		skills = append(skills, e.Skill)
+	}
+	var changes []leader.Change
+	for _, w := range leader.Windows {
+		if !lbm.windows.contains(w, e.TS) {
+			continue
+		}
+		for _, skill := range skills {
+			s := leader.Scope{Skill: skill, Window: w}
+			b := lbm.board(s)
+			streamed := w == leader.WindowAll && lbm.hub != nil
+			var prev int
+			var prevScore, prevKey float64
+			if streamed {
+				if prev = b.rank(e.TalentID); prev != 0 {
+					prevScore = b.agg.Score(b.talents[e.TalentID], lbm.now())
+					prevKey = b.agg.Key(b.talents[e.TalentID])
+				}
+			}
+			improved := b.add(e.TalentID, e.Score, e.TS)
+			if streamed && improved {
+				st := b.talents[e.TalentID]
+				changes = append(changes, leader.Change{
+					Skill:     skill,
+					TalentID:  e.TalentID,
+					Score:     b.agg.Score(st, lbm.now()),
+					PrevScore: prevScore,
+					Rank:      b.rank(e.TalentID),
+					PrevRank:  prev,
+					Improved:  prev != 0 && b.agg.Key(st) > prevKey,
+				})
+			}
+			if w == leader.WindowRolling {
+				lbm.windows.remember(lbm.agg, s, e)
+			}
+
+			switch {
+			case s == leader.Scope{}:
+				updated = improved
+			case w == leader.WindowAll:
+				result := "ignored"
+				if improved {
+					result = "improved"
+				}
+				lbm.skillMetrics.WithLabelValues(skill, result).Inc()
+			}
+		}
+	}
+	if len(changes) > 0 {
+		lbm.hub.publish(changes, lbm.rankAt)
+		if lbm.notify != nil {
+			lbm.notify(e, changes)
+		}
 	}
 
-	lbm.tree.ReplaceOrInsert(key{Score: l.Score, TalentID: l.TalentID})
-	lbm.bestByTalent[l.TalentID] = l.Score
+	return updated
+}
 
-	return true
+// board - must be called under the write lock.
+func (lbm *LBMemory) board(s leader.Scope) *board {
+	b, ok := lbm.boards[s]
+	if !ok {
+		b = newBoard(lbm.agg, lbm.ranking)
+		lbm.boards[s] = b
+	}
+
+	return b
 }
 
 // TopN - O(log N + n)
@@ -100,74 +258,117 @@ func (lbm *LBMemory) TopN(n int) leader.Leaders {
 	// we can also return cash of TOP10, TOP50, TOP100
 	// and update it by N time
 
+	return lbm.Range(leader.Scope{}, 0, n).Leaders
+}
+
+// Range - O(log N + n) n leaders starting from the rank offset+1.
+func (lbm *LBMemory) Range(s leader.Scope, offset, n int) leader.Page {
+	lbm.mu.RLock()
+	defer lbm.mu.RUnlock()
+
+	if n <= 0 || offset < 0 {
+		return leader.Page{}
+	}
+	b, ok := lbm.boards[s]
+	if !ok {
+		return leader.Page{Leaders: leader.Leaders{}}
+	}
+
+	return b.page(b.tree.Len()-1-offset, n, lbm.now())
+}
+
+// After - O(log N + n) n leaders ranked right below the cursor.
+// The cursor row itself may be already gone(score changed), the page
+// continues from the place where it used to be.
+func (lbm *LBMemory) After(s leader.Scope, c leader.Cursor, n int) leader.Page {
 	lbm.mu.RLock()
 	defer lbm.mu.RUnlock()
 
 	if n <= 0 {
-		return nil
-	}
-	ls := make(leader.Leaders, 0, n)
-	i := 0
-	lbm.tree.Descend(func(k key) bool {
-		i++
-		ls = append(ls, &leader.Leader{Rank: i, TalentID: k.TalentID, Score: k.Score})
-		return i < n
-	})
+		return leader.Page{}
+	}
+	b, ok := lbm.boards[s]
+	if !ok {
+		return leader.Page{Leaders: leader.Leaders{}}
+	}
+	idx, _ := b.tree.IndexOf(key{Score: c.Key, Achieved: c.Achieved, TalentID: c.TalentID})
 
-	return ls
+	return b.page(idx-1, n, lbm.now())
 }
 
-// RankOf - O(log N + rank)
-func (lbm *LBMemory) RankOf(talentID string) (l leader.Leader, ok bool) {
+// RankOf - O(log N) the rank by the rank type, ties share it unless it's ordinal.
+func (lbm *LBMemory) RankOf(s leader.Scope, talentID string) (l leader.Leader, ok bool) {
 	lbm.mu.RLock()
 	defer lbm.mu.RUnlock()
 
 	l.TalentID = talentID
-	l.Score, ok = lbm.bestByTalent[talentID]
+	b, ok := lbm.boards[s]
 	if !ok {
 		return l, false
 	}
-
-	target := key{Score: l.Score, TalentID: talentID}
-	i := 0
-	found := false
-	lbm.tree.Descend(func(k key) bool {
-		i++
-		if !found && k == target {
-			found = true
-			return false
-		}
-		return true
-	})
-	if !found {
+	idx, st, ok := b.indexOf(talentID)
+	if !ok {
 		return l, false
 	}
-	l.Rank = i
+	l.Score = b.agg.Score(st, lbm.now())
+	l.Rank = b.rankOf(idx, b.agg.Key(st), lbm.ranking.Type)
 
 	return l, true
 }
 
-// All - O(n) For possible future backups
+// RankType - of the ranks of every board.
+func (lbm *LBMemory) RankType() leader.RankType {
+	return lbm.ranking.Type
+}
+
+// rankAt - O(log N) the talent at the rank of an all-time board, under the lock.
+func (lbm *LBMemory) rankAt(skill string, rank int) (leader.Leader, bool) {
+	b, ok := lbm.boards[leader.Scope{Skill: skill}]
+	if !ok || rank <= 0 {
+		return leader.Leader{}, false
+	}
+	k, ok := b.tree.At(b.tree.Len() - rank)
+	if !ok {
+		return leader.Leader{}, false
+	}
+
+	return *b.leader(k, rank, lbm.now()), true
+}
+
+// Around - O(log N + radius) the talent with up to radius leaders above and below.
+func (lbm *LBMemory) Around(s leader.Scope, talentID string, radius int) (leader.Leaders, bool) {
+	lbm.mu.RLock()
+	defer lbm.mu.RUnlock()
+
+	b, ok := lbm.boards[s]
+	if !ok || radius < 0 {
+		return nil, false
+	}
+	idx, _, ok := b.indexOf(talentID)
+	if !ok {
+		return nil, false
+	}
+
+	// higher ranks are on the right side of the ascending tree
+	from := min(idx+radius, b.tree.Len()-1)
+
+	return b.descend(from, from-idx+radius+1, lbm.now()), true
+}
+
+// All - O(n) the global all-time board, ascending
 func (lbm *LBMemory) All() leader.Leaders {
 	lbm.mu.RLock()
 	defer lbm.mu.RUnlock()
 
-	ls := make(leader.Leaders, 0, lbm.tree.Len())
+	b := lbm.boards[leader.Scope{}]
+	ls := make(leader.Leaders, 0, b.tree.Len())
 	rank := 0
-	lbm.tree.Ascend(func(k key) bool {
+	now := lbm.now()
+	b.tree.AscendFrom(0, func(k key) bool {
 		rank++
-		ls = append(ls, &leader.Leader{Rank: rank, TalentID: k.TalentID, Score: k.Score})
+		ls = append(ls, b.leader(k, rank, now))
 		return true
 	})
 
 	return ls
 }
-
-// less - comparator that determines the overall order of keys in the tree
-func less(a, b key) bool {
-	if a.Score != b.Score {
-		return a.Score < b.Score
-	}
-
-	return a.TalentID < b.TalentID
-}
@@ -2,10 +2,14 @@ package leaderboard
 
 import (
 	"context"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 
 	"leaderboard-api/internal/domain/event"
@@ -14,8 +18,24 @@ import (
 
 func newTestLB(t *testing.T) *LBMemory {
 	t.Helper()
-	log := zaptest.NewLogger(t)
-	return New(context.Background(), log, make(chan event.Event, 10), prometheus.NewCounterVec(prometheus.CounterOpts{}, []string{}))
+	return newTestLBWith(t, testConfig)
+}
+
+func newTestLBWith(t *testing.T, cfg Config) *LBMemory {
+	t.Helper()
+	lb, err := New(context.Background(), zaptest.NewLogger(t), make(chan event.Event, 10), cfg, newTestMetrics(), newTestSkillMetrics())
+	require.NoError(t, err)
+	return lb
+}
+
+var testConfig = Config{Location: time.UTC, RollingDays: 7}
+
+func newTestMetrics() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"result"})
+}
+
+func newTestSkillMetrics() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "skill_events"}, []string{"skill", "result"})
 }
 
 func TestUpdateIfBetter_Table(t *testing.T) {
@@ -35,7 +55,7 @@ func TestUpdateIfBetter_Table(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ok := lb.updateIfBetter(leader.Leader{
+			ok := lb.updateIfBetter(event.Event{
 				TalentID: tt.talentID,
 				Score:    tt.score,
 			})
@@ -47,9 +67,9 @@ func TestUpdateIfBetter_Table(t *testing.T) {
 func TestTopN_Table(t *testing.T) {
 	lb := newTestLB(t)
 
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t1", Score: 10})
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t2", Score: 30})
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t3", Score: 20})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t1", Score: 10})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t2", Score: 30})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t3", Score: 20})
 
 	tests := []struct {
 		name       string
@@ -77,9 +97,9 @@ func TestTopN_Table(t *testing.T) {
 func TestRankOf_Table(t *testing.T) {
 	lb := newTestLB(t)
 
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t1", Score: 15})
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t2", Score: 25})
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t3", Score: 35})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t1", Score: 15})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t2", Score: 25})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t3", Score: 35})
 
 	tests := []struct {
 		name      string
@@ -94,7 +114,7 @@ func TestRankOf_Table(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r, ok := lb.RankOf(tt.talentID)
+			r, ok := lb.RankOf(leader.Scope{}, tt.talentID)
 			require.Equal(t, tt.wantFound, ok)
 			if ok {
 				require.Equal(t, tt.wantRank, r.Rank)
@@ -106,9 +126,9 @@ func TestRankOf_Table(t *testing.T) {
 func TestAll_Table(t *testing.T) {
 	lb := newTestLB(t)
 
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t1", Score: 1})
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t2", Score: 2})
-	_ = lb.updateIfBetter(leader.Leader{TalentID: "t3", Score: 3})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t1", Score: 1})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t2", Score: 2})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t3", Score: 3})
 
 	tasks := lb.All()
 
@@ -119,3 +139,234 @@ func TestAll_Table(t *testing.T) {
 		require.Equal(t, expected[i], task.TalentID)
 	}
 }
+
+func TestRange_Table(t *testing.T) {
+	lb := newTestLB(t)
+
+	for i := 1; i <= 5; i++ {
+		_ = lb.updateIfBetter(event.Event{TalentID: "t" + strconv.Itoa(i), Score: float64(i * 10)})
+	}
+
+	tests := []struct {
+		name      string
+		offset    int
+		limit     int
+		wantIDs   []string
+		wantRanks []int
+	}{
+		{"First page", 0, 2, []string{"t5", "t4"}, []int{1, 2}},
+		{"Second page", 2, 2, []string{"t3", "t2"}, []int{3, 4}},
+		{"Last page", 4, 2, []string{"t1"}, []int{5}},
+		{"Beyond the board", 5, 2, nil, nil},
+		{"Negative offset", -1, 2, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			var ranks []int
+			for _, l := range lb.Range(leader.Scope{}, tt.offset, tt.limit).Leaders {
+				ids = append(ids, l.TalentID)
+				ranks = append(ranks, l.Rank)
+			}
+			require.Equal(t, tt.wantIDs, ids)
+			require.Equal(t, tt.wantRanks, ranks)
+		})
+	}
+}
+
+func TestAfter_Table(t *testing.T) {
+	lb := newTestLB(t)
+
+	for i := 1; i <= 5; i++ {
+		_ = lb.updateIfBetter(event.Event{TalentID: "t" + strconv.Itoa(i), Score: float64(i * 10)})
+	}
+
+	tests := []struct {
+		name      string
+		cursor    leader.Cursor
+		limit     int
+		wantIDs   []string
+		wantRanks []int
+	}{
+		{"After the top", leader.Cursor{Key: 50, TalentID: "t5"}, 2, []string{"t4", "t3"}, []int{2, 3}},
+		{"After the last", leader.Cursor{Key: 10, TalentID: "t1"}, 2, nil, nil},
+		{"Cursor row has moved", leader.Cursor{Key: 35, TalentID: "gone"}, 5, []string{"t3", "t2", "t1"}, []int{3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			var ranks []int
+			for _, l := range lb.After(leader.Scope{}, tt.cursor, tt.limit).Leaders {
+				ids = append(ids, l.TalentID)
+				ranks = append(ranks, l.Rank)
+			}
+			require.Equal(t, tt.wantIDs, ids)
+			require.Equal(t, tt.wantRanks, ranks)
+		})
+	}
+}
+
+func TestAround_Table(t *testing.T) {
+	lb := newTestLB(t)
+
+	for i := 1; i <= 20; i++ {
+		_ = lb.updateIfBetter(event.Event{TalentID: "t" + strconv.Itoa(i), Score: float64(i)})
+	}
+
+	tests := []struct {
+		name      string
+		talentID  string
+		radius    int
+		wantFound bool
+		wantRanks []int
+	}{
+		{"Middle of the board", "t10", 2, true, []int{9, 10, 11, 12, 13}},
+		{"Top of the board", "t20", 2, true, []int{1, 2, 3}},
+		{"Bottom of the board", "t1", 3, true, []int{17, 18, 19, 20}},
+		{"Zero radius", "t15", 0, true, []int{6}},
+		{"Non-existing", "unknown", 5, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ls, ok := lb.Around(leader.Scope{}, tt.talentID, tt.radius)
+			require.Equal(t, tt.wantFound, ok)
+			var ranks []int
+			for _, l := range ls {
+				ranks = append(ranks, l.Rank)
+				r, _ := lb.RankOf(leader.Scope{}, l.TalentID)
+				require.Equal(t, r.Rank, l.Rank)
+			}
+			require.Equal(t, tt.wantRanks, ranks)
+		})
+	}
+}
+
+func TestSkillBoards_Table(t *testing.T) {
+	lb := newTestLB(t)
+
+	_ = lb.updateIfBetter(event.Event{TalentID: "t1", Skill: "pass", Score: 90})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t2", Skill: "pass", Score: 50})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t2", Skill: "shoot", Score: 95})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t3", Skill: "shoot", Score: 10})
+	_ = lb.updateIfBetter(event.Event{TalentID: "t3", Skill: "shoot", Score: 5})
+
+	tests := []struct {
+		name     string
+		skill    string
+		wantIDs  []string
+		wantRank map[string]int
+	}{
+		{"Global", "", []string{"t2", "t1", "t3"}, map[string]int{"t1": 2, "t2": 1, "t3": 3}},
+		{"Pass", "pass", []string{"t1", "t2"}, map[string]int{"t1": 1, "t2": 2, "t3": 0}},
+		{"Shoot", "shoot", []string{"t2", "t3"}, map[string]int{"t1": 0, "t2": 1, "t3": 2}},
+		{"Unknown skill", "jump", []string{}, map[string]int{"t1": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := leader.Scope{Skill: tt.skill}
+			ids := []string{}
+			for _, l := range lb.Range(scope, 0, 10).Leaders {
+				ids = append(ids, l.TalentID)
+			}
+			require.Equal(t, tt.wantIDs, ids)
+
+			for id, rank := range tt.wantRank {
+				l, ok := lb.RankOf(scope, id)
+				require.Equal(t, rank > 0, ok)
+				require.Equal(t, rank, l.Rank)
+			}
+		})
+	}
+
+	require.Equal(t, float64(2), testutil.ToFloat64(lb.skillMetrics.WithLabelValues("shoot", "improved")))
+	require.Equal(t, float64(1), testutil.ToFloat64(lb.skillMetrics.WithLabelValues("shoot", "ignored")))
+}
+
+// benchSizes - RankOf must stay flat while the board grows 10 000 times.
+var benchSizes = []struct {
+	name string
+	n    int
+}{
+	{"1K", 1_000},
+	{"100K", 100_000},
+	{"10M", 10_000_000},
+}
+
+var benchBoards = map[int]*LBMemory{}
+
+// benchLB - boards are expensive to build(10M talents), so they are shared between benchmarks.
+func benchLB(b *testing.B, n int) *LBMemory {
+	b.Helper()
+	if lb, ok := benchBoards[n]; ok {
+		return lb
+	}
+	lb := newBenchLB(b, n)
+	benchBoards[n] = lb
+
+	return lb
+}
+
+// newBenchLB - a board of n talents that nobody else reads, for the benchmarks that change it.
+func newBenchLB(b *testing.B, n int) *LBMemory {
+	b.Helper()
+	lb, err := New(context.Background(), zap.NewNop(), make(chan event.Event), testConfig, newTestMetrics(), newTestSkillMetrics())
+	require.NoError(b, err)
+	for i := 0; i < n; i++ {
+		lb.updateIfBetter(event.Event{TalentID: "t-" + strconv.Itoa(i), Score: float64(i)})
+	}
+
+	return lb
+}
+
+func BenchmarkRankOf(b *testing.B) {
+	for _, bs := range benchSizes {
+		b.Run(bs.name, func(b *testing.B) {
+			lb := benchLB(b, bs.n)
+			// the lowest score is the worst case for a rank lookup
+			last := "t-0"
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l, ok := lb.RankOf(leader.Scope{}, last)
+				if !ok || l.Rank != bs.n {
+					b.Fatalf("unexpected rank %d", l.Rank)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTopN(b *testing.B) {
+	for _, bs := range benchSizes {
+		b.Run(bs.name, func(b *testing.B) {
+			lb := benchLB(b, bs.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if len(lb.TopN(100)) != 100 {
+					b.Fatal("unexpected top size")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUpdateIfBetter(b *testing.B) {
+	for _, bs := range benchSizes {
+		// built once per run, every round of b.N keeps raising the scores
+		var lb *LBMemory
+		var score float64
+		b.Run(bs.name, func(b *testing.B) {
+			if lb == nil {
+				lb, score = newBenchLB(b, bs.n), float64(bs.n)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				score++
+				lb.updateIfBetter(event.Event{TalentID: "t-" + strconv.Itoa(i%bs.n), Score: score})
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/infrastructure/ml"
+)
+
+// TestPipeline_ShutdownLosesNothing - producers keep submitting while the pool
+// is closed, every accepted event reaches the leaderboard exactly once.
+func TestPipeline_ShutdownLosesNothing(t *testing.T) {
+	sc, err := ml.New(context.Background(), zap.NewNop(), nil, ml.Config{
+		BatchSize: 8,
+		Linger:    time.Millisecond,
+		Queue:     ml.QueueConfig{Size: 64, Policy: ml.PolicyWait},
+	}, ml.Metrics{
+		BatchSize:     prometheus.NewHistogram(prometheus.HistogramOpts{Name: "batch_size"}),
+		BatchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{Name: "batch_duration_seconds"}),
+		Pool:          prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pool"}, []string{"param"}),
+		QueueDepth:    prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "queue_depth"}, []string{"queue"}),
+		QueueWait:     prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "queue_wait_seconds"}, []string{"queue"}),
+		Shed:          prometheus.NewCounterVec(prometheus.CounterOpts{Name: "shed_events_total"}, []string{"reason"}),
+	})
+	require.NoError(t, err)
+
+	// every event scores 1, so a talent's sum is the number of its events
+	sum, err := NewAggregation(AggregationSum, 0, 0)
+	require.NoError(t, err)
+	lb, err := New(context.Background(), zap.NewNop(), sc.GetOutChan(),
+		Config{Aggregation: sum, Location: time.UTC, RollingDays: 7}, newTestMetrics(), newTestSkillMetrics())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sc.RunScorerPool(ctx, 4)
+	lbDone := make(chan struct{})
+	go func() {
+		lb.RunLBWorker(ctx)
+		close(lbDone)
+	}()
+
+	const producers, talents = 8, 10
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				e := event.Event{TalentID: "t-" + strconv.Itoa((p+i)%talents), RawMetric: 1, TS: time.Now()}
+				err := sc.Submit(context.Background(), e, nil)
+				if err != nil {
+					if !errors.Is(err, event.ErrQueueClosed) {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				accepted.Add(1)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	// the shutdown signal doesn't stop the pool, ClosePool drains it
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer drainCancel()
+	require.NoError(t, sc.ClosePool(drainCtx))
+	wg.Wait()
+
+	select {
+	case <-lbDone:
+	case <-time.After(time.Second):
+		t.Fatal("leaderboard worker didn't stop after the pool was closed")
+	}
+
+	var total float64
+	for _, l := range lb.All() {
+		total += l.Score
+	}
+	require.Positive(t, accepted.Load())
+	require.Equal(t, float64(accepted.Load()), total)
+}
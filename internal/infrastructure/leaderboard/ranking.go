@@ -0,0 +1,50 @@
+package leaderboard
+
+import (
+	"fmt"
+
+	"leaderboard-api/internal/domain/leader"
+)
+
+const (
+	// TieBreakTalentID - the greater TalentID ranks higher on a tie(default).
+	TieBreakTalentID = "talent_id"
+	// TieBreakEarliest - the one that achieved its key first(state.TS) ranks higher,
+	// then the TalentID order.
+	TieBreakEarliest = "earliest"
+)
+
+// Ranking - the order of the talents with the same ranking key and their ranks.
+type Ranking struct {
+	TieBreak string
+	Type     leader.RankType
+}
+
+func NewRanking(tieBreak, rankType string) (Ranking, error) {
+	r := Ranking{TieBreak: tieBreak}
+	switch tieBreak {
+	case TieBreakTalentID, TieBreakEarliest:
+	case "":
+		r.TieBreak = TieBreakTalentID
+	default:
+		return Ranking{}, fmt.Errorf("unknown tie-break %q", tieBreak)
+	}
+	var ok bool
+	if r.Type, ok = leader.ParseRankType(rankType); !ok {
+		return Ranking{}, fmt.Errorf("unknown rank type %q", rankType)
+	}
+
+	return r, nil
+}
+
+// defaults - the zero Ranking is the talent_id tie-break with ordinal ranks.
+func (r Ranking) defaults() Ranking {
+	if r.TieBreak == "" {
+		r.TieBreak = TieBreakTalentID
+	}
+	if r.Type == "" {
+		r.Type = leader.RankOrdinal
+	}
+
+	return r
+}
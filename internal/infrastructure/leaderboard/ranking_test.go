@@ -0,0 +1,124 @@
+package leaderboard
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+// rows - "talent:rank" in the board order.
+func rows(ls leader.Leaders) []string {
+	out := make([]string, len(ls))
+	for i, l := range ls {
+		out[i] = l.TalentID + ":" + string(rune('0'+l.Rank))
+	}
+	return out
+}
+
+func TestNewRanking(t *testing.T) {
+	r, err := NewRanking("", "")
+	require.NoError(t, err)
+	require.Equal(t, Ranking{TieBreak: TieBreakTalentID, Type: leader.RankOrdinal}, r)
+
+	_, err = NewRanking("random", "")
+	require.Error(t, err)
+	_, err = NewRanking(TieBreakEarliest, "olympic")
+	require.Error(t, err)
+}
+
+func TestRanking_Table(t *testing.T) {
+	ts := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	// t-500 and t-001 tie at 10, t-500 achieved it first,
+	// t-300 and t-200 tie at 5, t-200 achieved it first
+	events := []event.Event{
+		{TalentID: "t-001", Score: 10, TS: ts.Add(2 * time.Minute)},
+		{TalentID: "t-500", Score: 10, TS: ts.Add(time.Minute)},
+		{TalentID: "t-300", Score: 5, TS: ts.Add(time.Minute)},
+		{TalentID: "t-200", Score: 5, TS: ts},
+		{TalentID: "t-900", Score: 1, TS: ts},
+	}
+
+	tests := []struct {
+		name     string
+		tieBreak string
+		rankType leader.RankType
+		want     []string
+		// wantT200 - RankOf t-200
+		wantT200 int
+	}{
+		{"TalentID ordinal", TieBreakTalentID, leader.RankOrdinal, []string{"t-500:1", "t-001:2", "t-300:3", "t-200:4", "t-900:5"}, 4},
+		{"Earliest ordinal", TieBreakEarliest, leader.RankOrdinal, []string{"t-500:1", "t-001:2", "t-200:3", "t-300:4", "t-900:5"}, 3},
+		{"Earliest dense", TieBreakEarliest, leader.RankDense, []string{"t-500:1", "t-001:1", "t-200:2", "t-300:2", "t-900:3"}, 2},
+		{"Earliest competition", TieBreakEarliest, leader.RankCompetition, []string{"t-500:1", "t-001:1", "t-200:3", "t-300:3", "t-900:5"}, 3},
+		{"TalentID competition", TieBreakTalentID, leader.RankCompetition, []string{"t-500:1", "t-001:1", "t-300:3", "t-200:3", "t-900:5"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig
+			cfg.Ranking = Ranking{TieBreak: tt.tieBreak, Type: tt.rankType}
+			lb := newTestLBWith(t, cfg)
+			for _, e := range events {
+				lb.updateIfBetter(e)
+			}
+
+			require.Equal(t, tt.want, rows(lb.TopN(10)))
+			l, ok := lb.RankOf(leader.Scope{}, "t-200")
+			require.True(t, ok)
+			require.Equal(t, tt.wantT200, l.Rank)
+
+			// a page in the middle of the ties is ranked the same
+			require.Equal(t, tt.want[1:4], rows(lb.Range(leader.Scope{}, 1, 3).Leaders))
+			third, _, _ := strings.Cut(tt.want[2], ":")
+			around, ok := lb.Around(leader.Scope{}, third, 1)
+			require.True(t, ok)
+			require.Equal(t, tt.want[1:4], rows(around))
+
+			// the cursor keeps the tie-break order
+			page := lb.Range(leader.Scope{}, 0, 3)
+			require.Equal(t, tt.want[3:], rows(lb.After(leader.Scope{}, *page.Next, 10).Leaders))
+		})
+	}
+}
+
+// TestRanking_Earliest - the one that reached the score first wins the tie,
+// whatever its TalentID is.
+func TestRanking_Earliest(t *testing.T) {
+	ts := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	cfg := testConfig
+	cfg.Ranking = Ranking{TieBreak: TieBreakEarliest, Type: leader.RankOrdinal}
+	lb := newTestLBWith(t, cfg)
+
+	lb.updateIfBetter(event.Event{TalentID: "t-001", Score: 10, TS: ts})
+	lb.updateIfBetter(event.Event{TalentID: "t-500", Score: 10, TS: ts.Add(time.Minute)})
+	require.Equal(t, []string{"t-001", "t-500"}, ids(lb.TopN(10)))
+
+	// a better score moves it above, tying again later keeps the earlier one first
+	lb.updateIfBetter(event.Event{TalentID: "t-500", Score: 20, TS: ts.Add(2 * time.Minute)})
+	lb.updateIfBetter(event.Event{TalentID: "t-001", Score: 20, TS: ts.Add(3 * time.Minute)})
+	require.Equal(t, []string{"t-500", "t-001"}, ids(lb.TopN(10)))
+}
+
+// TestRanking_DenseKeys - the distinct keys follow the talents moving between them.
+func TestRanking_DenseKeys(t *testing.T) {
+	cfg := testConfig
+	cfg.Ranking = Ranking{Type: leader.RankDense}
+	lb := newTestLBWith(t, cfg)
+	now := time.Now()
+
+	lb.updateIfBetter(event.Event{TalentID: "a", Score: 10, TS: now})
+	lb.updateIfBetter(event.Event{TalentID: "b", Score: 5, TS: now})
+	lb.updateIfBetter(event.Event{TalentID: "c", Score: 5, TS: now})
+	lb.updateIfBetter(event.Event{TalentID: "c", Score: 10, TS: now})
+	lb.updateIfBetter(event.Event{TalentID: "b", Score: 7, TS: now})
+
+	require.Equal(t, []string{"c:1", "a:1", "b:2"}, rows(lb.TopN(10)))
+	b := lb.boards[leader.Scope{}]
+	require.Equal(t, 2, b.keys.Len())
+	require.Equal(t, map[float64]int{10: 2, 7: 1}, b.ties)
+}
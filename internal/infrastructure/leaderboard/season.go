@@ -0,0 +1,325 @@
+package leaderboard
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+// Season archive file layout, "<SnapshotDir>/seasons/season-<id>.snap",
+// the same encoding as the snapshot:
+//
+//	magic "LBSEASON" | version u16 | id | start | end | archived at
+//	| standings(skill, then talent, score and rank in the order) | crc32 u32
+const (
+	seasonsDir     = "seasons"
+	archiveMagic   = "LBSEASON"
+	archiveVersion = 1
+	archivePrefix  = "season-"
+	archiveExt     = ".snap"
+)
+
+// seasons - the season of the boards and the final standings of the past ones.
+// Changed by the leaderboard worker only(and on wake up) under the write lock.
+type seasons struct {
+	schedule season.Schedule
+	// dir - of the archives, empty - they are kept in memory only.
+	dir string
+	// current - the season of the boards, zero ID - none.
+	current season.Season
+	// frozen - the boards take no more events: the season is archived,
+	// or they are of no season.
+	frozen   bool
+	archives map[int]*archive
+}
+
+// archive - the final standings of a season, never changed.
+type archive struct {
+	info season.Info
+	// standings - the all-time boards by skill, "" - the global one.
+	standings map[string]leader.Leaders
+}
+
+func newSeasons(cfg Config) *seasons {
+	ss := &seasons{
+		schedule: cfg.Season,
+		archives: make(map[int]*archive),
+	}
+	if cfg.SnapshotDir != "" {
+		ss.dir = filepath.Join(cfg.SnapshotDir, seasonsDir)
+	}
+
+	return ss
+}
+
+// admits - whether an event of ts goes to the boards.
+func (ss *seasons) admits(ts time.Time) bool {
+	return !ss.schedule.Enabled() || (!ss.frozen && ss.current.Running(ts))
+}
+
+// behind - an event of ts is of a later season than the boards.
+func (ss *seasons) behind(ts time.Time) bool {
+	return ss.schedule.Enabled() && (ss.frozen || !ts.Before(ss.current.End))
+}
+
+// Admit - the events of the running season only, by their TS.
+func (lbm *LBMemory) Admit(ts time.Time) error {
+	return lbm.seasons.schedule.Admit(ts, lbm.now())
+}
+
+// Seasons - the running season and the archived ones, the latest first.
+func (lbm *LBMemory) Seasons() ([]season.Info, error) {
+	ss := lbm.seasons
+	if !ss.schedule.Enabled() {
+		return nil, season.ErrSeasonsDisabled
+	}
+
+	lbm.mu.RLock()
+	defer lbm.mu.RUnlock()
+
+	infos := make([]season.Info, 0, len(ss.archives)+1)
+	for _, a := range ss.archives {
+		infos = append(infos, a.info)
+	}
+	if ss.current.ID != 0 && !ss.frozen {
+		info := season.Info{Season: ss.current, State: season.StateRunning, Talents: len(lbm.boards[leader.Scope{}].talents)}
+		if !ss.current.Running(lbm.now()) {
+			info.State = season.StateEnded
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b season.Info) int { return cmp.Compare(b.ID, a.ID) })
+
+	return infos, nil
+}
+
+// Standings - O(n) n leaders of an archived season starting from the rank offset+1.
+func (lbm *LBMemory) Standings(id int, skill string, offset, n int) (leader.Page, error) {
+	if !lbm.seasons.schedule.Enabled() {
+		return leader.Page{}, season.ErrSeasonsDisabled
+	}
+
+	lbm.mu.RLock()
+	a, ok := lbm.seasons.archives[id]
+	lbm.mu.RUnlock()
+	if !ok {
+		return leader.Page{}, season.ErrSeasonNotFound
+	}
+	if n <= 0 || offset < 0 {
+		return leader.Page{}, nil
+	}
+	ls := a.standings[skill]
+	if offset >= len(ls) {
+		return leader.Page{Leaders: leader.Leaders{}}, nil
+	}
+
+	return leader.Page{Leaders: slices.Clone(ls[offset:min(offset+n, len(ls))])}, nil
+}
+
+// advanceSeason - archives the season of the boards once it's over and starts
+// the running one with empty boards. Called by the worker on the ticker and
+// when an event of a later season comes, so the events of the past season
+// queued before it(or replayed on start) are on the boards already.
+func (lbm *LBMemory) advanceSeason(now time.Time) {
+	ss := lbm.seasons
+	if !ss.schedule.Enabled() {
+		return
+	}
+	if !ss.frozen && !now.Before(ss.current.End) {
+		lbm.archiveSeason()
+	}
+	latest, ok := ss.schedule.Latest(now)
+	if ss.frozen && ok && latest.Running(now) && latest.ID != ss.current.ID {
+		lbm.startSeason(latest)
+	}
+}
+
+// archiveSeason - freezes the boards and keeps their final standings,
+// the archive file is written without the lock. A season that failed
+// to be written is archived again from the snapshot after a restart.
+func (lbm *LBMemory) archiveSeason() {
+	ss := lbm.seasons
+
+	lbm.mu.Lock()
+	a := &archive{
+		info: season.Info{
+			Season:     ss.current,
+			State:      season.StateArchived,
+			ArchivedAt: lbm.now(),
+		},
+		standings: make(map[string]leader.Leaders),
+	}
+	for s, b := range lbm.boards {
+		if s.Window != leader.WindowAll || b.tree.Len() == 0 {
+			continue
+		}
+		// scored at the end, e.g. the decay stops with the season
+		a.standings[s.Skill] = b.descend(b.tree.Len()-1, b.tree.Len(), ss.current.End)
+	}
+	a.info.Talents = len(a.standings[""])
+	ss.archives[a.info.ID] = a
+	ss.frozen = true
+	lbm.mu.Unlock()
+
+	if ss.dir != "" {
+		path := filepath.Join(ss.dir, archivePrefix+strconv.Itoa(a.info.ID)+archiveExt)
+		if err := writeFileAtomic(path, encodeArchive(a)); err != nil {
+			lbm.log.Error("season archive write failed", zap.Int("season", a.info.ID), zap.Error(err))
+		}
+	}
+	lbm.log.Info("season archived", zap.Int("season", a.info.ID), zap.Int("talents", a.info.Talents))
+}
+
+func (lbm *LBMemory) startSeason(s season.Season) {
+	lbm.mu.Lock()
+	defer lbm.mu.Unlock()
+
+	ss := lbm.seasons
+	ss.current, ss.frozen = s, false
+	lbm.boards = map[leader.Scope]*board{{}: newBoard(lbm.agg, lbm.ranking)}
+	lbm.windows.days = make(map[time.Time]map[leader.Scope]map[string]state)
+	if lbm.hub != nil {
+		lbm.hub.reset(lbm.view)
+	}
+	lbm.log.Info("season started", zap.Int("season", s.ID), zap.Time("end", s.End))
+}
+
+// wakeUpSeasons - the archives are loaded, the restored boards are frozen when their
+// season is archived. The boards of no season(e.g. the seasons were just turned on)
+// go to the running one, otherwise they wait frozen for the next season.
+func (lbm *LBMemory) wakeUpSeasons() error {
+	ss := lbm.seasons
+	if !ss.schedule.Enabled() {
+		return nil
+	}
+	if err := lbm.loadArchives(); err != nil {
+		return err
+	}
+
+	if ss.current.ID != 0 {
+		_, ss.frozen = ss.archives[ss.current.ID]
+		return nil
+	}
+	now := lbm.now()
+	if latest, ok := ss.schedule.Latest(now); ok && latest.Running(now) {
+		ss.current = latest
+		lbm.log.Info("the boards belong to the running season", zap.Int("season", latest.ID))
+		return nil
+	}
+	ss.frozen = true
+
+	return nil
+}
+
+func (lbm *LBMemory) loadArchives() error {
+	ss := lbm.seasons
+	if ss.dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(ss.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read season archives: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, archiveExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(ss.dir, name))
+		if err != nil {
+			return fmt.Errorf("read season archive: %w", err)
+		}
+		a, err := decodeArchive(data, lbm.windows.loc)
+		if err != nil {
+			return fmt.Errorf("restore season archive %s: %w", name, err)
+		}
+		ss.archives[a.info.ID] = a
+	}
+	lbm.log.Info("season archives loaded", zap.Int("seasons", len(ss.archives)))
+
+	return nil
+}
+
+func encodeArchive(a *archive) []byte {
+	var w snapWriter
+	w.buf.WriteString(archiveMagic)
+	w.buf.Write(binary.LittleEndian.AppendUint16(nil, archiveVersion))
+	w.uvarint(uint64(a.info.ID))
+	w.time(a.info.Start)
+	w.time(a.info.End)
+	w.time(a.info.ArchivedAt)
+
+	w.uvarint(uint64(len(a.standings)))
+	for skill, ls := range a.standings {
+		w.str(skill)
+		w.uvarint(uint64(len(ls)))
+		for _, l := range ls {
+			w.str(l.TalentID)
+			w.f64(l.Score)
+			w.uvarint(uint64(l.Rank))
+		}
+	}
+
+	w.buf.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(w.buf.Bytes())))
+
+	return w.buf.Bytes()
+}
+
+func decodeArchive(data []byte, loc *time.Location) (*archive, error) {
+	header := len(archiveMagic) + 2
+	if len(data) < header+4 || string(data[:len(archiveMagic)]) != archiveMagic {
+		return nil, ErrSnapshotCorrupted
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, ErrSnapshotCorrupted
+	}
+	version := binary.LittleEndian.Uint16(data[len(archiveMagic):])
+	if version != archiveVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+
+	r := snapReader{data: body[header:], loc: loc}
+	a := &archive{
+		info:      season.Info{State: season.StateArchived},
+		standings: make(map[string]leader.Leaders),
+	}
+	a.info.ID = int(r.uvarint())
+	a.info.Start = r.time()
+	a.info.End = r.time()
+	a.info.ArchivedAt = r.time()
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		skill := r.str()
+		ls := make(leader.Leaders, r.count())
+		for i := range ls {
+			ls[i] = &leader.Leader{TalentID: r.str(), Score: r.f64()}
+			ls[i].Rank = int(r.uvarint())
+		}
+		a.standings[skill] = ls
+	}
+	a.info.Talents = len(a.standings[""])
+	if r.err == nil && len(r.data) != 0 {
+		r.err = ErrSnapshotCorrupted
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return a, nil
+}
@@ -0,0 +1,214 @@
+package leaderboard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+// weekly seasons with a day of a break: [Aug 1, Aug 8), [Aug 9, Aug 16), ...
+var testSchedule = season.Schedule{
+	Start:  time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+	Length: 7 * 24 * time.Hour,
+	Every:  8 * 24 * time.Hour,
+}
+
+// newSeasonLB - the clock is read on every call, the tests move it.
+func newSeasonLB(t *testing.T, dir string, clock *time.Time) *LBMemory {
+	t.Helper()
+	cfg := testConfig
+	cfg.SnapshotDir = dir
+	cfg.Season = testSchedule
+
+	lb := newTestLB(t)
+	lb.snapshotDir = dir
+	lb.seasons = newSeasons(cfg)
+	lb.now = func() time.Time { return *clock }
+	lb.rollover(*clock)
+	require.NoError(t, lb.wakeUp())
+	require.NoError(t, lb.wakeUpSeasons())
+
+	return lb
+}
+
+func TestSchedule_Admit(t *testing.T) {
+	s1 := testSchedule.Nth(1)
+	s2 := testSchedule.Nth(2)
+	require.Equal(t, time.Date(2025, 8, 9, 0, 0, 0, 0, time.UTC), s2.Start)
+
+	tests := []struct {
+		name    string
+		ts, now time.Time
+		// want - the rejection reason, empty - admitted
+		want string
+	}{
+		{"Running", s1.Start, s1.Start.Add(time.Hour), ""},
+		{"Last moment", s1.End.Add(-time.Nanosecond), s1.End.Add(-time.Nanosecond), ""},
+		{"Before the first season", s1.Start, s1.Start.Add(-time.Hour), season.ReasonNoSeason},
+		{"Break", s1.End, s1.End.Add(time.Hour), season.ReasonNoSeason},
+		{"Before the season", s1.Start.Add(-time.Second), s1.Start.Add(time.Hour), season.ReasonBeforeSeason},
+		{"Of the previous season", s1.End.Add(-time.Hour), s2.Start.Add(time.Hour), season.ReasonBeforeSeason},
+		{"After the season", s2.End, s2.End.Add(-time.Hour), season.ReasonAfterSeason},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testSchedule.Admit(tt.ts, tt.now)
+			if tt.want == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, season.ErrOutsideSeason)
+			var rejected *season.Rejection
+			require.ErrorAs(t, err, &rejected)
+			require.Equal(t, tt.want, rejected.Reason)
+			require.NotEmpty(t, rejected.Message)
+		})
+	}
+
+	require.NoError(t, season.Schedule{}.Admit(time.Time{}, time.Now()), "no seasons")
+	require.ErrorIs(t, season.Schedule{Length: time.Hour}.Validate(), season.ErrInvalidSchedule)
+	require.ErrorIs(t, season.Schedule{Start: s1.Start, Length: time.Hour, Every: time.Minute}.Validate(), season.ErrInvalidSchedule)
+}
+
+func TestSeasons_Lifecycle(t *testing.T) {
+	dir := t.TempDir()
+	s1, s2 := testSchedule.Nth(1), testSchedule.Nth(2)
+	clock := s1.Start.Add(time.Hour)
+	lb := newSeasonLB(t, dir, &clock)
+
+	// the boards belong to the running season from the start
+	infos, err := lb.Seasons()
+	require.NoError(t, err)
+	require.Equal(t, []season.Info{{Season: s1, State: season.StateRunning}}, infos)
+
+	lb.updateIfBetter(event.Event{TalentID: "t1", Skill: "pass", Score: 10, TS: clock})
+	lb.updateIfBetter(event.Event{TalentID: "t2", Skill: "shoot", Score: 20, TS: clock})
+	// before the season, e.g. admitted by another replica with a wrong clock
+	lb.updateIfBetter(event.Event{TalentID: "t3", Score: 30, TS: s1.Start.Add(-time.Second)})
+	require.Equal(t, 1.0, testutil.ToFloat64(lb.metrics.WithLabelValues("late")))
+
+	// over, but not archived until the worker notices
+	clock = s1.End.Add(time.Minute)
+	infos, _ = lb.Seasons()
+	require.Equal(t, season.StateEnded, infos[0].State)
+	lb.updateIfBetter(event.Event{TalentID: "t3", Score: 5, TS: s1.End.Add(-time.Second)})
+
+	lb.advanceSeason(clock)
+	infos, _ = lb.Seasons()
+	require.Equal(t, []season.Info{{Season: s1, State: season.StateArchived, ArchivedAt: clock, Talents: 3}}, infos)
+	// frozen: the final standings stay on the boards until the next season
+	lb.updateIfBetter(event.Event{TalentID: "t4", Score: 100, TS: s1.End.Add(-time.Second)})
+	require.Equal(t, []string{"t2", "t1", "t3"}, ids(lb.Range(leader.Scope{}, 0, 10).Leaders))
+
+	page, err := lb.Standings(1, "", 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, leader.Leaders{{Rank: 2, TalentID: "t1", Score: 10}, {Rank: 3, TalentID: "t3", Score: 5}}, page.Leaders)
+	page, err = lb.Standings(1, "pass", 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, leader.Leaders{{Rank: 1, TalentID: "t1", Score: 10}}, page.Leaders)
+
+	// the next one starts empty
+	clock = s2.Start.Add(time.Minute)
+	lb.advanceSeason(clock)
+	require.Empty(t, lb.Range(leader.Scope{}, 0, 10).Leaders)
+	lb.updateIfBetter(event.Event{TalentID: "t5", Score: 1, TS: clock})
+	infos, _ = lb.Seasons()
+	require.Equal(t, []int{2, 1}, []int{infos[0].ID, infos[1].ID})
+	require.Equal(t, season.StateRunning, infos[0].State)
+
+	_, err = lb.Standings(2, "", 0, 10)
+	require.ErrorIs(t, err, season.ErrSeasonNotFound)
+
+	// restart: the archives are immutable files, the boards are of season 2
+	_, err = lb.Snapshot(context.Background())
+	require.NoError(t, err)
+	restored := newSeasonLB(t, dir, &clock)
+	page, err = restored.Standings(1, "", 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"t2", "t1", "t3"}, ids(page.Leaders))
+	require.Equal(t, []string{"t5"}, ids(restored.Range(leader.Scope{}, 0, 10).Leaders))
+	infos, _ = restored.Seasons()
+	require.Len(t, infos, 2)
+}
+
+// TestSeasons_Worker - an event of the next season archives the previous one
+// after its queued events, without waiting for the ticker.
+func TestSeasons_Worker(t *testing.T) {
+	s1, s2 := testSchedule.Nth(1), testSchedule.Nth(2)
+	clock := s2.Start.Add(time.Minute)
+	lb := newSeasonLB(t, "", &clock)
+	// restored from a snapshot of season 1
+	lb.seasons.current, lb.seasons.frozen = s1, false
+
+	in := make(chan event.Event, 10)
+	lb.in = in
+	in <- event.Event{TalentID: "t1", Score: 10, TS: s1.End.Add(-time.Hour)}
+	in <- event.Event{TalentID: "t2", Score: 20, TS: s2.Start}
+	in <- event.Event{TalentID: "t3", Score: 30, TS: s1.End.Add(-time.Hour)}
+	close(in)
+	lb.RunLBWorker(context.Background())
+
+	page, err := lb.Standings(1, "", 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"t1"}, ids(page.Leaders))
+	require.Equal(t, []string{"t2"}, ids(lb.Range(leader.Scope{}, 0, 10).Leaders))
+}
+
+func TestSeasons_NoSeasonBoards(t *testing.T) {
+	clock := testSchedule.Nth(1).End.Add(time.Hour)
+	lb := newSeasonLB(t, "", &clock)
+
+	// the break: the boards of no season take no events
+	lb.updateIfBetter(event.Event{TalentID: "t1", Score: 10, TS: clock})
+	require.Empty(t, lb.Range(leader.Scope{}, 0, 10).Leaders)
+	infos, err := lb.Seasons()
+	require.NoError(t, err)
+	require.Empty(t, infos)
+
+	clock = testSchedule.Nth(2).Start
+	lb.advanceSeason(clock)
+	lb.updateIfBetter(event.Event{TalentID: "t1", Score: 10, TS: clock})
+	require.Equal(t, []string{"t1"}, ids(lb.Range(leader.Scope{}, 0, 10).Leaders))
+}
+
+func TestSeasons_Disabled(t *testing.T) {
+	lb := newTestLB(t)
+	require.NoError(t, lb.Admit(time.Time{}))
+	_, err := lb.Seasons()
+	require.ErrorIs(t, err, season.ErrSeasonsDisabled)
+	_, err = lb.Standings(1, "", 0, 10)
+	require.ErrorIs(t, err, season.ErrSeasonsDisabled)
+}
+
+func TestSeasons_SwapAfterNewSeason(t *testing.T) {
+	clock := testSchedule.Nth(1).Start
+	lb := newSeasonLB(t, "", &clock)
+	sh := lb.NewShadow()
+
+	clock = testSchedule.Nth(2).Start
+	lb.advanceSeason(clock)
+	require.ErrorIs(t, lb.Swap(sh, nil), season.ErrSeasonChanged)
+}
+
+func TestSeasons_ArchiveKeepsRanks(t *testing.T) {
+	s1 := testSchedule.Nth(1)
+	a := &archive{
+		info: season.Info{Season: s1, State: season.StateArchived, ArchivedAt: s1.End, Talents: 3},
+		standings: map[string]leader.Leaders{
+			"": {{Rank: 1, TalentID: "t1", Score: 10}, {Rank: 1, TalentID: "t2", Score: 10}, {Rank: 3, TalentID: "t3", Score: 5}},
+		},
+	}
+
+	got, err := decodeArchive(encodeArchive(a), time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, a.standings, got.standings)
+	require.Equal(t, a.info, got.info)
+}
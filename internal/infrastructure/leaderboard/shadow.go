@@ -0,0 +1,92 @@
+package leaderboard
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+var ErrForeignShadow = errors.New("shadow of another leaderboard")
+
+// shadow - boards built aside of the live ones, e.g. by a rebuild.
+type shadow struct {
+	origin *LBMemory
+	lbm    *LBMemory
+}
+
+// NewShadow - empty boards with the same aggregation, windows and season,
+// they are not seen by anyone until Swap.
+func (lbm *LBMemory) NewShadow() ports.LBShadow {
+	lbm.mu.RLock()
+	cfg := Config{Location: lbm.windows.loc, RollingDays: lbm.windows.rollingDays}
+	ss := seasons{
+		schedule: lbm.seasons.schedule,
+		current:  lbm.seasons.current,
+		frozen:   lbm.seasons.frozen,
+	}
+	lbm.mu.RUnlock()
+
+	sh := &LBMemory{
+		log:     lbm.log,
+		agg:     lbm.agg,
+		ranking: lbm.ranking,
+		boards:  map[leader.Scope]*board{{}: newBoard(lbm.agg, lbm.ranking)},
+		now:     lbm.now,
+		// the live per-skill metrics are not touched by the shadow
+		metrics:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "shadow_events_total"}, []string{"result"}),
+		skillMetrics: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "shadow_skill_events_total"}, []string{"skill", "result"}),
+		seasons:      &ss,
+	}
+	sh.windows = newWindows(cfg)
+	sh.rollover(sh.now())
+
+	return &shadow{origin: lbm, lbm: sh}
+}
+
+// Apply - the events must be scored already.
+func (sh *shadow) Apply(events []event.Event) {
+	for _, e := range events {
+		sh.lbm.updateIfBetter(e)
+	}
+}
+
+// Swap - replaces the live boards by the shadow ones. catchUp is called right
+// before it under the write lock, so no event can be applied in between:
+// the events that are in the shadow already are skipped when they come from
+// the scorer pool later(by their seq), the rest are applied to the new boards.
+func (lbm *LBMemory) Swap(s ports.LBShadow, catchUp func() error) error {
+	sh, ok := s.(*shadow)
+	if !ok || sh.origin != lbm {
+		return ErrForeignShadow
+	}
+
+	lbm.mu.Lock()
+	defer lbm.mu.Unlock()
+
+	if catchUp != nil {
+		if err := catchUp(); err != nil {
+			return err
+		}
+	}
+
+	sh.lbm.mu.Lock()
+	defer sh.lbm.mu.Unlock()
+
+	// the shadow has the events of the season it started in only
+	if sh.lbm.seasons.current.ID != lbm.seasons.current.ID || sh.lbm.seasons.frozen != lbm.seasons.frozen {
+		return season.ErrSeasonChanged
+	}
+
+	sh.lbm.rollover(lbm.now())
+	lbm.boards = sh.lbm.boards
+	lbm.windows = sh.lbm.windows
+	lbm.applied = sh.lbm.applied
+	lbm.hub.reset(lbm.view)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package leaderboard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+func TestShadow_Swap(t *testing.T) {
+	lb := newTestLB(t)
+	require.True(t, lb.updateIfBetter(event.Event{Seq: 1, TalentID: "t1", Score: 10}))
+	require.True(t, lb.updateIfBetter(event.Event{Seq: 2, TalentID: "t2", Score: 20}))
+
+	// rescored by another model, not seen until the swap
+	sh := lb.NewShadow()
+	sh.Apply([]event.Event{
+		{Seq: 1, TalentID: "t1", Score: 100},
+		{Seq: 2, TalentID: "t2", Score: 5},
+	})
+	require.Equal(t, "t2", lb.TopN(1)[0].TalentID)
+
+	// accepted meanwhile, applied by the catch up
+	require.NoError(t, lb.Swap(sh, func() error {
+		sh.Apply([]event.Event{{Seq: 3, TalentID: "t3", Score: 50}})
+		return nil
+	}))
+
+	require.Equal(t, leader.Leaders{
+		{TalentID: "t1", Score: 100, Rank: 1},
+		{TalentID: "t3", Score: 50, Rank: 2},
+		{TalentID: "t2", Score: 5, Rank: 3},
+	}, lb.TopN(3))
+
+	// the scorer pool delivers seq 3 after the swap - applied already
+	require.False(t, lb.updateIfBetter(event.Event{Seq: 3, TalentID: "t3", Score: 500}))
+	require.True(t, lb.updateIfBetter(event.Event{Seq: 4, TalentID: "t3", Score: 500}))
+	require.Equal(t, "t3", lb.TopN(1)[0].TalentID)
+}
+
+func TestShadow_SwapErrors(t *testing.T) {
+	lb := newTestLB(t)
+	require.True(t, lb.updateIfBetter(event.Event{Seq: 1, TalentID: "t1", Score: 10}))
+
+	err := lb.Swap(newTestLB(t).NewShadow(), nil)
+	require.ErrorIs(t, err, ErrForeignShadow)
+
+	// a failed catch up keeps the live boards
+	failure := errors.New("replay failed")
+	sh := lb.NewShadow()
+	require.ErrorIs(t, lb.Swap(sh, func() error { return failure }), failure)
+	require.Len(t, lb.All(), 1)
+}
@@ -0,0 +1,446 @@
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+)
+
+// Snapshot file layout(all numbers little endian, ints as varints):
+//
+//	magic "LBSNAP" | version u16 | aggregation | taken at
+//	| periods | rolling from | boards | rolling window days
+//	| applied events | season id, start, end | crc32 u32
+//
+// The checksum(IEEE) covers everything before it. A new layout gets a new
+// version, unknown versions are refused instead of being misread.
+const (
+	snapshotMagic   = "LBSNAP"
+	snapshotVersion = 1
+	snapshotFile    = "leaderboard.snap"
+
+	defaultSnapshotInterval = 5 * time.Minute
+)
+
+var (
+	ErrSnapshotCorrupted = errors.New("leaderboard snapshot is corrupted")
+	ErrSnapshotVersion   = errors.New("unsupported leaderboard snapshot version")
+)
+
+// Snapshot - writes all boards to the snapshot directory.
+// The file is replaced atomically, a crash in the middle keeps the previous one.
+func (lbm *LBMemory) Snapshot(ctx context.Context) (leader.Snapshot, error) {
+	if lbm.snapshotDir == "" {
+		return leader.Snapshot{}, leader.ErrSnapshotDisabled
+	}
+	if err := ctx.Err(); err != nil {
+		return leader.Snapshot{}, err
+	}
+
+	// one writer of the file at a time(the worker, the admin endpoint, the shutdown)
+	lbm.snapMu.Lock()
+	defer lbm.snapMu.Unlock()
+
+	// O(N) under the read lock, but only in memory, the disk is written without it
+	lbm.mu.RLock()
+	data, snap := lbm.encodeSnapshot()
+	lbm.mu.RUnlock()
+
+	snap.Path = filepath.Join(lbm.snapshotDir, snapshotFile)
+	snap.Size = int64(len(data))
+	if err := writeFileAtomic(snap.Path, data); err != nil {
+		return leader.Snapshot{}, fmt.Errorf("write leaderboard snapshot: %w", err)
+	}
+	lbm.log.Info("leaderboard snapshot taken",
+		zap.String("path", snap.Path), zap.Int("talents", snap.Talents), zap.Int64("bytes", snap.Size))
+
+	if lbm.compactor != nil {
+		// a failed compaction only keeps more of the log, the snapshot is fine
+		if err := lbm.compactor.Compact(snap.Seq); err != nil {
+			lbm.log.Error("event log compaction failed", zap.Error(err))
+		}
+	}
+
+	return snap, nil
+}
+
+// SnapshotWorker - takes a snapshot every interval until the context is canceled.
+// The last one is taken by RunLBWorker after the last event is applied.
+func (lbm *LBMemory) SnapshotWorker(ctx context.Context) {
+	if lbm.snapshotDir == "" {
+		return
+	}
+	lbm.log.Info("starting leaderboard snapshot worker")
+
+	ticker := time.NewTicker(lbm.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := lbm.Snapshot(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				lbm.log.Error("leaderboard snapshot failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			lbm.log.Info("leaderboard snapshot worker gracefully stopped")
+			return
+		}
+	}
+}
+
+// wakeUp - restores the boards from the last snapshot, if there is one.
+func (lbm *LBMemory) wakeUp() error {
+	if lbm.snapshotDir == "" {
+		return nil
+	}
+
+	path := filepath.Join(lbm.snapshotDir, snapshotFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		lbm.log.Info("no leaderboard snapshot, starting empty", zap.String("path", path))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read leaderboard snapshot: %w", err)
+	}
+
+	lbm.mu.Lock()
+	defer lbm.mu.Unlock()
+	snap, err := lbm.decodeSnapshot(data)
+	if err != nil {
+		return fmt.Errorf("restore leaderboard snapshot %s: %w", path, err)
+	}
+	// the snapshot may be from a previous period of some windows
+	lbm.rollover(lbm.now())
+	lbm.log.Info("leaderboard restored",
+		zap.String("path", path), zap.Time("taken_at", snap.TakenAt), zap.Int("talents", snap.Talents))
+
+	return nil
+}
+
+// encodeSnapshot - must be called under the read lock.
+func (lbm *LBMemory) encodeSnapshot() ([]byte, leader.Snapshot) {
+	snap := leader.Snapshot{TakenAt: lbm.now(), Boards: len(lbm.boards), Seq: lbm.applied.upTo}
+	ws := lbm.windows
+
+	var w snapWriter
+	w.buf.WriteString(snapshotMagic)
+	w.buf.Write(binary.LittleEndian.AppendUint16(nil, snapshotVersion))
+	w.str(lbm.agg.Name())
+	w.time(snap.TakenAt)
+
+	w.uvarint(uint64(len(ws.periods)))
+	for win, p := range ws.periods {
+		w.str(string(win))
+		w.time(p)
+	}
+	w.time(ws.rollingFrom)
+
+	w.uvarint(uint64(len(lbm.boards)))
+	for s, b := range lbm.boards {
+		w.scope(s)
+		w.talents(b.talents)
+		if s == (leader.Scope{}) {
+			snap.Talents = len(b.talents)
+		}
+	}
+
+	w.uvarint(uint64(len(ws.days)))
+	for day, scopes := range ws.days {
+		w.time(day)
+		w.uvarint(uint64(len(scopes)))
+		for s, talents := range scopes {
+			w.scope(s)
+			w.talents(talents)
+		}
+	}
+
+	w.uvarint(lbm.applied.upTo)
+	w.uvarint(uint64(len(lbm.applied.ahead)))
+	for seq := range lbm.applied.ahead {
+		w.uvarint(seq)
+	}
+
+	w.uvarint(uint64(lbm.seasons.current.ID))
+	w.time(lbm.seasons.current.Start)
+	w.time(lbm.seasons.current.End)
+
+	w.buf.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(w.buf.Bytes())))
+
+	return w.buf.Bytes(), snap
+}
+
+// decodeSnapshot - replaces the boards with the snapshot content,
+// must be called under the write lock. Nothing is changed on error.
+func (lbm *LBMemory) decodeSnapshot(data []byte) (leader.Snapshot, error) {
+	var snap leader.Snapshot
+	header := len(snapshotMagic) + 2
+	if len(data) < header+4 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return snap, ErrSnapshotCorrupted
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return snap, ErrSnapshotCorrupted
+	}
+	version := binary.LittleEndian.Uint16(data[len(snapshotMagic):])
+	if version != snapshotVersion {
+		return snap, fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+
+	r := snapReader{data: body[header:], loc: lbm.windows.loc}
+	if name := r.str(); r.err == nil && name != lbm.agg.Name() {
+		return snap, fmt.Errorf("taken with the %q aggregation, configured %q", name, lbm.agg.Name())
+	}
+	snap.TakenAt = r.time()
+
+	ws := lbm.windows
+	periods := make(map[leader.Window]time.Time)
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		win := leader.Window(r.str())
+		periods[win] = r.time()
+	}
+	rollingFrom := r.time()
+
+	boards := make(map[leader.Scope]*board)
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		s := r.scope()
+		b := newBoard(lbm.agg, lbm.ranking)
+		for talentID, st := range r.talents() {
+			b.set(talentID, state{}, false, st)
+		}
+		boards[s] = b
+	}
+	snap.Boards = len(boards)
+	if b, ok := boards[leader.Scope{}]; ok {
+		snap.Talents = len(b.talents)
+	} else {
+		boards[leader.Scope{}] = newBoard(lbm.agg, lbm.ranking)
+	}
+
+	days := make(map[time.Time]map[leader.Scope]map[string]state)
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		day := r.time()
+		scopes := make(map[leader.Scope]map[string]state)
+		for m := r.count(); m > 0 && r.err == nil; m-- {
+			s := r.scope()
+			scopes[s] = r.talents()
+		}
+		days[day] = scopes
+	}
+
+	var progress applied
+	progress.upTo = r.uvarint()
+	for n := r.count(); n > 0 && r.err == nil; n-- {
+		if progress.ahead == nil {
+			progress.ahead = make(map[uint64]struct{})
+		}
+		progress.ahead[r.uvarint()] = struct{}{}
+	}
+	snap.Seq = progress.upTo
+	var current season.Season
+	current.ID = int(r.uvarint())
+	current.Start = r.time()
+	current.End = r.time()
+	if r.err == nil && len(r.data) != 0 {
+		r.err = ErrSnapshotCorrupted
+	}
+	if r.err != nil {
+		return snap, r.err
+	}
+
+	lbm.boards = boards
+	ws.periods = periods
+	ws.rollingFrom = rollingFrom
+	ws.days = days
+	lbm.applied = progress
+	lbm.seasons.current = current
+
+	return snap, nil
+}
+
+// writeFileAtomic - temp file in the same directory + rename.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op after the rename
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+type snapWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *snapWriter) uvarint(v uint64) { w.buf.Write(binary.AppendUvarint(nil, v)) }
+func (w *snapWriter) varint(v int64)   { w.buf.Write(binary.AppendVarint(nil, v)) }
+
+func (w *snapWriter) f64(v float64) {
+	w.buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+}
+
+func (w *snapWriter) str(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// time - nanoseconds since the epoch, the zero time is written as MinInt64.
+func (w *snapWriter) time(t time.Time) {
+	if t.IsZero() {
+		w.varint(math.MinInt64)
+		return
+	}
+	w.varint(t.UnixNano())
+}
+
+func (w *snapWriter) scope(s leader.Scope) {
+	w.str(s.Skill)
+	w.str(string(s.Window))
+}
+
+func (w *snapWriter) talents(talents map[string]state) {
+	w.uvarint(uint64(len(talents)))
+	for talentID, st := range talents {
+		w.str(talentID)
+		w.f64(st.Value)
+		w.uvarint(uint64(st.Count))
+		w.time(st.TS)
+		w.uvarint(uint64(len(st.Last)))
+		for _, v := range st.Last {
+			w.f64(v)
+		}
+	}
+}
+
+// snapReader - the first error sticks, every read after it returns zero values.
+type snapReader struct {
+	data []byte
+	err  error
+	// loc - of the decoded times, the same as the windows use
+	loc *time.Location
+}
+
+func (r *snapReader) fail() {
+	if r.err == nil {
+		r.err = ErrSnapshotCorrupted
+	}
+}
+
+func (r *snapReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *snapReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+// count - length of a collection, never more than the bytes that are left,
+// so a damaged length can't make us allocate gigabytes.
+func (r *snapReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (r *snapReader) f64() float64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 8 {
+		r.fail()
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.data))
+	r.data = r.data[8:]
+	return v
+}
+
+func (r *snapReader) str() string {
+	n := r.count()
+	if r.err != nil {
+		return ""
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *snapReader) time() time.Time {
+	v := r.varint()
+	if r.err != nil || v == math.MinInt64 {
+		return time.Time{}
+	}
+	return time.Unix(0, v).In(r.loc)
+}
+
+func (r *snapReader) scope() leader.Scope {
+	skill := r.str()
+	return leader.Scope{Skill: skill, Window: leader.Window(r.str())}
+}
+
+func (r *snapReader) talents() map[string]state {
+	n := r.count()
+	talents := make(map[string]state, n)
+	for ; n > 0 && r.err == nil; n-- {
+		talentID := r.str()
+		st := state{Value: r.f64(), Count: int(r.uvarint()), TS: r.time()}
+		if k := r.count(); k > 0 {
+			st.Last = make([]float64, k)
+			for i := range st.Last {
+				st.Last[i] = r.f64()
+			}
+		}
+		talents[talentID] = st
+	}
+	return talents
+}
@@ -0,0 +1,189 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+var snapshotNow = time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// newSnapshotLB - the clock is set before the wake up, so restored windows are deterministic.
+func newSnapshotLB(t *testing.T, dir string, now time.Time) *LBMemory {
+	t.Helper()
+	agg, err := NewAggregation(AggregationAvg, 3, 0)
+	require.NoError(t, err)
+	cfg := testConfig
+	cfg.Aggregation = agg
+
+	lb := newTestLBWith(t, cfg)
+	lb.snapshotDir = dir
+	lb.now = func() time.Time { return now }
+	lb.rollover(now)
+	require.NoError(t, lb.wakeUp())
+
+	return lb
+}
+
+func fillSnapshotLB(lb *LBMemory) {
+	for _, e := range []event.Event{
+		{TalentID: "t1", Skill: "pass", Score: 10, TS: snapshotNow.Add(-time.Hour)},
+		{TalentID: "t1", Skill: "pass", Score: 40, TS: snapshotNow},
+		{TalentID: "t2", Skill: "shoot", Score: 30, TS: snapshotNow.AddDate(0, 0, -3)},
+		{TalentID: "t3", Score: 20, TS: snapshotNow.AddDate(0, -2, 0)},
+	} {
+		lb.updateIfBetter(e)
+	}
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	lb := newSnapshotLB(t, dir, snapshotNow)
+	fillSnapshotLB(lb)
+
+	snap, err := lb.Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, snapshotFile), snap.Path)
+	require.Equal(t, 3, snap.Talents)
+	require.Equal(t, len(lb.boards), snap.Boards)
+	require.Positive(t, snap.Size)
+
+	restored := newSnapshotLB(t, dir, snapshotNow)
+
+	scopes := []leader.Scope{{}, {Skill: "pass"}, {Skill: "shoot"}}
+	for _, w := range leader.Windows {
+		for _, s := range scopes {
+			s.Window = w
+			require.Equal(t, lb.Range(s, 0, 10), restored.Range(s, 0, 10), "scope %+v", s)
+		}
+	}
+	require.Equal(t, lb.boards[leader.Scope{}].talents, restored.boards[leader.Scope{}].talents)
+	require.Equal(t, lb.windows.periods, restored.windows.periods)
+	require.Equal(t, lb.windows.days, restored.windows.days)
+
+	// the restored board keeps aggregating, avg of the last 3
+	restored.updateIfBetter(event.Event{TalentID: "t1", Score: 70, TS: snapshotNow})
+	l, ok := restored.RankOf(leader.Scope{}, "t1")
+	require.True(t, ok)
+	require.InDelta(t, 40, l.Score, 1e-9)
+}
+
+func TestSnapshot_RestoreRollsWindowsOver(t *testing.T) {
+	dir := t.TempDir()
+	lb := newSnapshotLB(t, dir, snapshotNow)
+	fillSnapshotLB(lb)
+	_, err := lb.Snapshot(context.Background())
+	require.NoError(t, err)
+
+	// restart on the next day
+	restored := newSnapshotLB(t, dir, snapshotNow.AddDate(0, 0, 1))
+	require.Empty(t, restored.Range(leader.Scope{Window: leader.WindowDaily}, 0, 10).Leaders)
+	require.Equal(t, []string{"t1"}, ids(restored.Range(leader.Scope{Window: leader.WindowWeekly}, 0, 10).Leaders))
+	require.Equal(t, []string{"t2", "t1"}, ids(restored.Range(leader.Scope{Window: leader.WindowRolling}, 0, 10).Leaders))
+	require.Equal(t, []string{"t2", "t1", "t3"}, ids(restored.Range(leader.Scope{}, 0, 10).Leaders))
+}
+
+func TestSnapshot_Invalid(t *testing.T) {
+	lb := newSnapshotLB(t, t.TempDir(), snapshotNow)
+	fillSnapshotLB(lb)
+	lb.mu.RLock()
+	data, _ := lb.encodeSnapshot()
+	lb.mu.RUnlock()
+
+	resum := func(b []byte) []byte {
+		body := b[:len(b)-4]
+		return binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+	}
+
+	tests := []struct {
+		name    string
+		data    func() []byte
+		wantErr error
+	}{
+		{"Empty", func() []byte { return nil }, ErrSnapshotCorrupted},
+		{"Wrong magic", func() []byte {
+			b := append([]byte(nil), data...)
+			b[0] = 'X'
+			return b
+		}, ErrSnapshotCorrupted},
+		{"Flipped byte", func() []byte {
+			b := append([]byte(nil), data...)
+			b[len(b)/2] ^= 0xFF
+			return b
+		}, ErrSnapshotCorrupted},
+		{"Truncated", func() []byte { return resum(append([]byte(nil), data[:len(data)/2]...)) }, ErrSnapshotCorrupted},
+		{"Newer version", func() []byte {
+			b := append([]byte(nil), data...)
+			binary.LittleEndian.PutUint16(b[len(snapshotMagic):], snapshotVersion+1)
+			return resum(b)
+		}, ErrSnapshotVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newSnapshotLB(t, "", snapshotNow)
+			target.updateIfBetter(event.Event{TalentID: "kept", Score: 1, TS: snapshotNow})
+
+			target.mu.Lock()
+			_, err := target.decodeSnapshot(tt.data())
+			target.mu.Unlock()
+			require.ErrorIs(t, err, tt.wantErr)
+			// nothing is changed on error
+			require.Equal(t, []string{"kept"}, ids(target.Range(leader.Scope{}, 0, 10).Leaders))
+		})
+	}
+}
+
+func TestSnapshot_AggregationMismatch(t *testing.T) {
+	dir := t.TempDir()
+	lb := newSnapshotLB(t, dir, snapshotNow)
+	fillSnapshotLB(lb)
+	_, err := lb.Snapshot(context.Background())
+	require.NoError(t, err)
+
+	cfg := testConfig
+	cfg.SnapshotDir = dir
+	_, err = New(context.Background(), lb.log, make(chan event.Event), cfg, newTestMetrics(), newTestSkillMetrics())
+	require.ErrorContains(t, err, `taken with the "avg" aggregation, configured "max"`)
+}
+
+func TestSnapshot_Disabled(t *testing.T) {
+	lb := newTestLB(t)
+	_, err := lb.Snapshot(context.Background())
+	require.ErrorIs(t, err, leader.ErrSnapshotDisabled)
+}
+
+func TestSnapshot_NoFileStartsEmpty(t *testing.T) {
+	lb := newSnapshotLB(t, filepath.Join(t.TempDir(), "missing"), snapshotNow)
+	require.Empty(t, lb.Range(leader.Scope{}, 0, 10).Leaders)
+}
+
+// TestRunLBWorker_FinalSnapshot - the snapshot on stop has every event of the closed channel.
+func TestRunLBWorker_FinalSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	in := make(chan event.Event, 10)
+	cfg := testConfig
+	cfg.SnapshotDir = dir
+	lb, err := New(context.Background(), newTestLB(t).log, in, cfg, newTestMetrics(), newTestSkillMetrics())
+	require.NoError(t, err)
+
+	in <- event.Event{TalentID: "t1", Score: 10, TS: time.Now()}
+	in <- event.Event{TalentID: "t2", Score: 20, TS: time.Now()}
+	close(in)
+	lb.RunLBWorker(context.Background())
+
+	_, err = os.Stat(filepath.Join(dir, snapshotFile))
+	require.NoError(t, err)
+	restored, err := New(context.Background(), lb.log, make(chan event.Event), cfg, newTestMetrics(), newTestSkillMetrics())
+	require.NoError(t, err)
+	require.Equal(t, []string{"t2", "t1"}, ids(restored.Range(leader.Scope{}, 0, 10).Leaders))
+}
@@ -0,0 +1,359 @@
+package leaderboard
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/leader"
+)
+
+const (
+	defaultStreamBuffer  = 256
+	defaultStreamHistory = 10000
+)
+
+// change - a move on a board with its stream ID, kept for the resuming subscribers.
+type change struct {
+	id uint64
+	c  leader.Change
+}
+
+// hub - fan-out of the all-time board changes to the stream subscribers.
+// publish and reset run under the LBMemory write lock and subscribe under the read
+// one, so nothing is applied between the snapshot of a subscriber and its first update.
+type hub struct {
+	mu     sync.Mutex
+	log    *zap.Logger
+	subs   map[*Subscription]struct{}
+	buffer int
+	closed bool
+
+	// history - ring of the last changes, head is the next slot to write.
+	history []change
+	head    int
+	count   int
+	lastID  uint64
+	// floor - the oldest ID a subscriber can resume after.
+	floor uint64
+}
+
+func newHub(log *zap.Logger, buffer, history int) *hub {
+	if buffer <= 0 {
+		buffer = defaultStreamBuffer
+	}
+	if history <= 0 {
+		history = defaultStreamHistory
+	}
+	// IDs start at the start time, so an ID of a previous run is never resumed
+	start := uint64(time.Now().UnixMicro())
+
+	return &hub{
+		log:     log,
+		subs:    make(map[*Subscription]struct{}),
+		buffer:  buffer,
+		history: make([]change, history),
+		lastID:  start,
+		floor:   start,
+	}
+}
+
+// publish - lookup finds the talent at a rank of a board, for the top N subscribers
+// whose last talent has dropped out.
+func (h *hub) publish(changes []leader.Change, lookup func(skill string, rank int) (leader.Leader, bool)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range changes {
+		h.lastID++
+		h.remember(change{id: h.lastID, c: c})
+		for s := range h.subs {
+			if s.watch.Skill != c.Skill {
+				continue
+			}
+			if u, ok := s.apply(h.lastID, c, lookup); ok {
+				h.send(s, u)
+			}
+		}
+	}
+}
+
+func (h *hub) remember(c change) {
+	if h.count == len(h.history) {
+		// the oldest one is overwritten
+		h.floor = h.history[h.head].id
+	} else {
+		h.count++
+	}
+	h.history[h.head] = c
+	h.head = (h.head + 1) % len(h.history)
+}
+
+// since - the changes after the ID, false - some of them are gone.
+func (h *hub) since(id uint64) ([]change, bool) {
+	if id < h.floor || id > h.lastID {
+		return nil, false
+	}
+	var cs []change
+	for i := range h.count {
+		c := h.history[(h.head-h.count+i+len(h.history))%len(h.history)]
+		if c.id > id {
+			cs = append(cs, c)
+		}
+	}
+
+	return cs, true
+}
+
+// subscribe - view is the current view of a watch, see LBMemory.view.
+func (h *hub) subscribe(w leader.Watch, lastID uint64, view func(leader.Watch) leader.Leaders) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, leader.ErrStreamClosed
+	}
+	s := &Subscription{
+		hub:   h,
+		watch: w,
+		ch:    make(chan leader.Update, h.buffer),
+		done:  make(chan struct{}),
+	}
+	if !h.resume(s, lastID) {
+		s.ch <- s.snapshot(h.lastID, view)
+	}
+	h.subs[s] = struct{}{}
+
+	return s, nil
+}
+
+// resume - queues the changes missed since lastID. Only a top N view can be resumed,
+// the watched talents need their current ranks, they get a snapshot.
+func (h *hub) resume(s *Subscription, lastID uint64) bool {
+	n := s.watch.TopN
+	if n == 0 || lastID == 0 {
+		return false
+	}
+	cs, ok := h.since(lastID)
+	if !ok {
+		return false
+	}
+	var missed []leader.Update
+	for _, c := range cs {
+		if c.c.Skill != s.watch.Skill || !touches(n, c.c) {
+			continue
+		}
+		// the talent that took the last place is not known anymore
+		if leaves(n, c.c) {
+			return false
+		}
+		missed = append(missed, leader.Update{ID: c.id, Changes: []leader.Change{c.c}})
+	}
+	if len(missed) > h.buffer {
+		return false
+	}
+	for _, u := range missed {
+		s.ch <- u
+	}
+
+	return true
+}
+
+// reset - the boards were replaced, every subscriber gets a new snapshot
+// and the changes of the old boards can't be resumed.
+func (h *hub) reset(view func(leader.Watch) leader.Leaders) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.head, h.count = 0, 0
+	h.floor = h.lastID
+	for s := range h.subs {
+		h.send(s, s.snapshot(h.lastID, view))
+	}
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subs {
+		h.drop(s, leader.ErrStreamClosed)
+	}
+}
+
+// send - never blocks the boards, a subscriber with a full buffer is dropped.
+func (h *hub) send(s *Subscription, u leader.Update) {
+	select {
+	case s.ch <- u:
+	default:
+		h.log.Warn("stream subscriber dropped", zap.Error(leader.ErrSlowConsumer), zap.Int("buffer", h.buffer))
+		h.drop(s, leader.ErrSlowConsumer)
+	}
+}
+
+func (h *hub) drop(s *Subscription, err error) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	s.err = err
+	close(s.done)
+}
+
+// Subscription - updates of one stream subscriber.
+type Subscription struct {
+	hub   *hub
+	watch leader.Watch
+	// tracked - the watched talents in the order of the watch, rank 0 - not on the board.
+	tracked []leader.Leader
+	ch      chan leader.Update
+	done    chan struct{}
+	err     error
+}
+
+var _ ports.LBSubscription = (*Subscription)(nil)
+
+func (s *Subscription) Updates() <-chan leader.Update { return s.ch }
+
+// Done - closed when the stream ends, Err tells why.
+func (s *Subscription) Done() <-chan struct{} { return s.done }
+
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.drop(s, leader.ErrStreamClosed)
+}
+
+func (s *Subscription) snapshot(id uint64, view func(leader.Watch) leader.Leaders) leader.Update {
+	ls := view(s.watch)
+	if len(s.watch.Talents) > 0 {
+		ranks := make(map[string]*leader.Leader, len(ls))
+		for _, l := range ls {
+			ranks[l.TalentID] = l
+		}
+		s.tracked = make([]leader.Leader, len(s.watch.Talents))
+		for i, id := range s.watch.Talents {
+			s.tracked[i] = leader.Leader{TalentID: id}
+			if l, ok := ranks[id]; ok {
+				s.tracked[i] = *l
+			}
+		}
+	}
+
+	return leader.Update{ID: id, Snapshot: ls}
+}
+
+// apply - the update of the subscriber caused by a change, false - it's not affected.
+func (s *Subscription) apply(id uint64, c leader.Change, lookup func(skill string, rank int) (leader.Leader, bool)) (leader.Update, bool) {
+	u := leader.Update{ID: id}
+	if n := s.watch.TopN; n > 0 {
+		if !touches(n, c) {
+			return u, false
+		}
+		u.Changes = append(u.Changes, c)
+		if leaves(n, c) {
+			// the next talent moves up to the last place
+			if l, ok := lookup(c.Skill, n); ok {
+				u.Changes = append(u.Changes, leader.Change{Skill: c.Skill, TalentID: l.TalentID, Score: l.Score, PrevScore: l.Score, Rank: n, PrevRank: n + 1})
+			}
+		}
+
+		return u, true
+	}
+
+	for i := range s.tracked {
+		t := &s.tracked[i]
+		switch {
+		case t.TalentID == c.TalentID:
+			u.Changes = append(u.Changes, c)
+			t.Rank, t.Score = c.Rank, c.Score
+		case t.Rank != 0:
+			if r := shift(t.Rank, c); r != t.Rank {
+				u.Changes = append(u.Changes, leader.Change{Skill: c.Skill, TalentID: t.TalentID, Score: t.Score, PrevScore: t.Score, Rank: r, PrevRank: t.Rank})
+				t.Rank = r
+			}
+		}
+	}
+	sort.SliceStable(u.Changes, func(i, j int) bool { return u.Changes[i].Rank < u.Changes[j].Rank })
+
+	return u, len(u.Changes) > 0
+}
+
+// touches - the change moves a talent into, out of or within the top n.
+func touches(n int, c leader.Change) bool {
+	return c.Rank <= n || (c.PrevRank != 0 && c.PrevRank <= n)
+}
+
+// leaves - the talent dropped out of the top n, the n+1th one took its place.
+func leaves(n int, c leader.Change) bool {
+	return c.PrevRank != 0 && c.PrevRank <= n && c.Rank > n
+}
+
+// shift - the rank of another talent after the change.
+func shift(rank int, c leader.Change) int {
+	switch {
+	case c.PrevRank == 0 && rank >= c.Rank:
+		return rank + 1
+	case c.PrevRank != 0 && c.Rank < c.PrevRank && rank >= c.Rank && rank < c.PrevRank:
+		return rank + 1
+	case c.PrevRank != 0 && c.Rank > c.PrevRank && rank > c.PrevRank && rank <= c.Rank:
+		return rank - 1
+	}
+
+	return rank
+}
+
+// Subscribe - the stream of the all-time board changes of the watch, resumed after
+// lastID(0 - from a snapshot) when the missed changes are still kept.
+func (lbm *LBMemory) Subscribe(w leader.Watch, lastID uint64) (ports.LBSubscription, error) {
+	lbm.mu.RLock()
+	defer lbm.mu.RUnlock()
+
+	s, err := lbm.hub.subscribe(w, lastID, lbm.view)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// CloseStreams - ends every stream, e.g. on shutdown, no new ones are accepted.
+func (lbm *LBMemory) CloseStreams() {
+	lbm.hub.close()
+}
+
+// view - the current leaders of the watch, under the lock.
+func (lbm *LBMemory) view(w leader.Watch) leader.Leaders {
+	b, ok := lbm.boards[leader.Scope{Skill: w.Skill}]
+	if !ok {
+		return leader.Leaders{}
+	}
+	now := lbm.now()
+	if w.TopN > 0 {
+		return b.descendBy(b.tree.Len()-1, w.TopN, now, leader.RankOrdinal)
+	}
+
+	ls := leader.Leaders{}
+	for _, id := range w.Talents {
+		if rank := b.rank(id); rank != 0 {
+			ls = append(ls, &leader.Leader{Rank: rank, TalentID: id, Score: b.agg.Score(b.talents[id], now)})
+		}
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].Rank < ls[j].Rank })
+
+	return ls
+}
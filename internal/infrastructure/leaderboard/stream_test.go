@@ -0,0 +1,288 @@
+package leaderboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+// next - the next update of the subscription, it must be queued already.
+func next(t *testing.T, s ports.LBSubscription) leader.Update {
+	t.Helper()
+	select {
+	case u := <-s.Updates():
+		return u
+	default:
+		t.Fatal("no update")
+		return leader.Update{}
+	}
+}
+
+func noUpdate(t *testing.T, s ports.LBSubscription) {
+	t.Helper()
+	select {
+	case u := <-s.Updates():
+		t.Fatalf("unexpected update %+v", u)
+	default:
+	}
+}
+
+func scores(lb *LBMemory, events ...event.Event) {
+	for i, e := range events {
+		if e.TS.IsZero() {
+			e.TS = time.Now().Add(time.Duration(i) * time.Millisecond)
+		}
+		lb.updateIfBetter(e)
+	}
+}
+
+func TestStream_TopN(t *testing.T) {
+	lb := newTestLB(t)
+	scores(lb, event.Event{TalentID: "t1", Score: 10}, event.Event{TalentID: "t2", Score: 20}, event.Event{TalentID: "t3", Score: 5})
+
+	s, err := lb.Subscribe(leader.Watch{TopN: 2}, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	snap := next(t, s)
+	require.Equal(t, leader.Leaders{{Rank: 1, TalentID: "t2", Score: 20}, {Rank: 2, TalentID: "t1", Score: 10}}, snap.Snapshot)
+
+	tests := []struct {
+		name string
+		e    event.Event
+		want []leader.Change
+	}{
+		{"Below the top", event.Event{TalentID: "t4", Score: 1}, nil},
+		{"Not better", event.Event{TalentID: "t1", Score: 1}, nil},
+		{"Other skill board", event.Event{TalentID: "t5", Score: 1, Skill: "pass"}, nil},
+		{"New leader", event.Event{TalentID: "t6", Score: 30}, []leader.Change{{TalentID: "t6", Score: 30, Rank: 1}}},
+		{"Into the top", event.Event{TalentID: "t3", Score: 25}, []leader.Change{{TalentID: "t3", Score: 25, PrevScore: 5, Rank: 2, PrevRank: 4, Improved: true}}},
+		{"Within the top", event.Event{TalentID: "t3", Score: 40}, []leader.Change{{TalentID: "t3", Score: 40, PrevScore: 25, Rank: 1, PrevRank: 2, Improved: true}}},
+	}
+
+	lastID := snap.ID
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores(lb, tt.e)
+			if tt.want == nil {
+				noUpdate(t, s)
+				return
+			}
+			u := next(t, s)
+			require.Equal(t, tt.want, u.Changes)
+			require.Greater(t, u.ID, lastID)
+			lastID = u.ID
+		})
+	}
+}
+
+func TestStream_TopNLeaves(t *testing.T) {
+	lb := newTestLBWith(t, Config{Aggregation: latestAgg{}, Location: time.UTC, RollingDays: 7})
+	scores(lb, event.Event{TalentID: "t1", Score: 30}, event.Event{TalentID: "t2", Score: 20}, event.Event{TalentID: "t3", Score: 10})
+
+	s, err := lb.Subscribe(leader.Watch{TopN: 2}, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	next(t, s)
+
+	// t1 drops out, t3 takes the last place
+	scores(lb, event.Event{TalentID: "t1", Score: 1, TS: time.Now().Add(time.Hour)})
+	require.Equal(t, []leader.Change{
+		{TalentID: "t1", Score: 1, PrevScore: 30, Rank: 3, PrevRank: 1},
+		{TalentID: "t3", Score: 10, PrevScore: 10, Rank: 2, PrevRank: 3},
+	}, next(t, s).Changes)
+}
+
+func TestStream_Talents(t *testing.T) {
+	lb := newTestLB(t)
+	scores(lb,
+		event.Event{TalentID: "t1", Score: 30, Skill: "pass"},
+		event.Event{TalentID: "t2", Score: 20, Skill: "pass"},
+		event.Event{TalentID: "t3", Score: 10, Skill: "pass"},
+	)
+
+	s, err := lb.Subscribe(leader.Watch{Skill: "pass", Talents: []string{"t3", "t2", "t9"}}, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	require.Equal(t, leader.Leaders{{Rank: 2, TalentID: "t2", Score: 20}, {Rank: 3, TalentID: "t3", Score: 10}}, next(t, s).Snapshot)
+
+	tests := []struct {
+		name string
+		e    event.Event
+		want []leader.Change
+	}{
+		{"Above the watched", event.Event{TalentID: "t1", Score: 35, Skill: "pass"}, nil},
+		{"Global board", event.Event{TalentID: "t4", Score: 50}, nil},
+		{"Overtakes both", event.Event{TalentID: "t5", Score: 25, Skill: "pass"}, []leader.Change{
+			{Skill: "pass", TalentID: "t2", Score: 20, PrevScore: 20, Rank: 3, PrevRank: 2},
+			{Skill: "pass", TalentID: "t3", Score: 10, PrevScore: 10, Rank: 4, PrevRank: 3},
+		}},
+		{"Watched one moves up", event.Event{TalentID: "t3", Score: 22, Skill: "pass"}, []leader.Change{
+			{Skill: "pass", TalentID: "t3", Score: 22, PrevScore: 10, Rank: 3, PrevRank: 4, Improved: true},
+			{Skill: "pass", TalentID: "t2", Score: 20, PrevScore: 20, Rank: 4, PrevRank: 3},
+		}},
+		{"Not on the board yet", event.Event{TalentID: "t9", Score: 1, Skill: "pass"}, []leader.Change{
+			{Skill: "pass", TalentID: "t9", Score: 1, Rank: 5},
+		}},
+		{"Below the watched", event.Event{TalentID: "t6", Score: 0, Skill: "pass"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores(lb, tt.e)
+			if tt.want == nil {
+				noUpdate(t, s)
+				return
+			}
+			require.Equal(t, tt.want, next(t, s).Changes)
+		})
+	}
+}
+
+func TestStream_Resume(t *testing.T) {
+	lb := newTestLBWith(t, Config{Location: time.UTC, RollingDays: 7, StreamHistory: 3})
+	scores(lb, event.Event{TalentID: "t1", Score: 10})
+
+	s, err := lb.Subscribe(leader.Watch{TopN: 2}, 0)
+	require.NoError(t, err)
+	lastID := next(t, s).ID
+	s.Close()
+
+	// missed meanwhile
+	scores(lb, event.Event{TalentID: "t2", Score: 20}, event.Event{TalentID: "t3", Score: 1})
+
+	s, err = lb.Subscribe(leader.Watch{TopN: 2}, lastID)
+	require.NoError(t, err)
+	u := next(t, s)
+	require.Nil(t, u.Snapshot)
+	require.Equal(t, []leader.Change{{TalentID: "t2", Score: 20, Rank: 1}}, u.Changes)
+	noUpdate(t, s)
+	s.Close()
+
+	tests := []struct {
+		name   string
+		lastID uint64
+		watch  leader.Watch
+	}{
+		{"Gone from the history", lastID, leader.Watch{TopN: 2}},
+		{"Of another run", lastID + 1000, leader.Watch{TopN: 2}},
+		{"Watched talents", u.ID, leader.Watch{Talents: []string{"t1"}}},
+	}
+
+	// pushes the first changes out of the history
+	scores(lb, event.Event{TalentID: "t4", Score: 2}, event.Event{TalentID: "t5", Score: 3})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := lb.Subscribe(tt.watch, tt.lastID)
+			require.NoError(t, err)
+			defer s.Close()
+			require.NotNil(t, next(t, s).Snapshot)
+			noUpdate(t, s)
+		})
+	}
+}
+
+func TestStream_SlowConsumer(t *testing.T) {
+	lb := newTestLBWith(t, Config{Location: time.UTC, RollingDays: 7, StreamBuffer: 2})
+
+	s, err := lb.Subscribe(leader.Watch{TopN: 10}, 0)
+	require.NoError(t, err)
+	// the snapshot and one change fit
+	scores(lb, event.Event{TalentID: "t1", Score: 1})
+	require.NoError(t, s.Err())
+	scores(lb, event.Event{TalentID: "t2", Score: 2})
+
+	<-s.Done()
+	require.ErrorIs(t, s.Err(), leader.ErrSlowConsumer)
+	// the boards are not blocked by it
+	scores(lb, event.Event{TalentID: "t3", Score: 3})
+}
+
+func TestStream_SwapAndClose(t *testing.T) {
+	lb := newTestLB(t)
+	scores(lb, event.Event{Seq: 1, TalentID: "t1", Score: 10})
+
+	s, err := lb.Subscribe(leader.Watch{TopN: 10}, 0)
+	require.NoError(t, err)
+	next(t, s)
+
+	sh := lb.NewShadow()
+	sh.Apply([]event.Event{{Seq: 1, TalentID: "t1", Score: 100, TS: time.Now()}})
+	require.NoError(t, lb.Swap(sh, nil))
+	u := next(t, s)
+	require.Equal(t, leader.Leaders{{Rank: 1, TalentID: "t1", Score: 100}}, u.Snapshot)
+
+	// the changes of the old boards can't be resumed
+	s2, err := lb.Subscribe(leader.Watch{TopN: 10}, u.ID-1)
+	require.NoError(t, err)
+	require.NotNil(t, next(t, s2).Snapshot)
+
+	lb.CloseStreams()
+	<-s.Done()
+	require.ErrorIs(t, s.Err(), leader.ErrStreamClosed)
+	require.ErrorIs(t, s2.Err(), leader.ErrStreamClosed)
+	s.Close()
+
+	_, err = lb.Subscribe(leader.Watch{TopN: 10}, 0)
+	require.ErrorIs(t, err, leader.ErrStreamClosed)
+}
+
+func TestNotify(t *testing.T) {
+	var (
+		got  []leader.Change
+		seqs []uint64
+	)
+	cfg := testConfig
+	cfg.Notify = func(e event.Event, changes []leader.Change) {
+		seqs = append(seqs, e.Seq)
+		got = append(got, changes...)
+	}
+	lb := newTestLBWith(t, cfg)
+
+	scores(lb, event.Event{Seq: 1, TalentID: "t1", Score: 10, Skill: "pass"}, event.Event{Seq: 2, TalentID: "t1", Score: 5, Skill: "pass"},
+		event.Event{Seq: 3, TalentID: "t1", Score: 20, Skill: "pass"})
+
+	// not better - no call
+	require.Equal(t, []uint64{1, 3}, seqs)
+	require.Equal(t, []leader.Change{
+		{TalentID: "t1", Score: 10, Rank: 1},
+		{Skill: "pass", TalentID: "t1", Score: 10, Rank: 1},
+		{TalentID: "t1", Score: 20, PrevScore: 10, Rank: 1, PrevRank: 1, Improved: true},
+		{Skill: "pass", TalentID: "t1", Score: 20, PrevScore: 10, Rank: 1, PrevRank: 1, Improved: true},
+	}, got)
+}
+
+// TestNotify_Improved - improved by the ranking of the aggregation, not by the score.
+func TestNotify_Improved(t *testing.T) {
+	tests := []struct {
+		agg    string
+		scores []float64
+		want   []bool
+	}{
+		{"min", []float64{10, 8, 9}, []bool{false, true}},
+		{"latest", []float64{10, 8}, []bool{false, false}},
+		{"max", []float64{10, 12}, []bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.agg, func(t *testing.T) {
+			var got []bool
+			cfg := testConfig
+			agg, err := NewAggregation(tt.agg, 0, 0)
+			require.NoError(t, err)
+			cfg.Aggregation = agg
+			cfg.Notify = func(_ event.Event, changes []leader.Change) {
+				got = append(got, changes[0].Improved)
+			}
+			lb := newTestLBWith(t, cfg)
+			for i, score := range tt.scores {
+				scores(lb, event.Event{Seq: uint64(i + 1), TalentID: "t1", Score: score, TS: time.Now()})
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package leaderboard
+
+import (
+	"slices"
+	"time"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+// rolloverInterval - how often the worker checks the windows for expiry
+// when there are no events coming.
+const rolloverInterval = time.Minute
+
+// windows - state of the time windowed boards.
+// The current period of every window is taken from the clock, events
+// with TS before it are too old for the window and skipped.
+type windows struct {
+	loc         *time.Location
+	rollingDays int
+	// periods - start of the current period of every calendar window.
+	periods map[leader.Window]time.Time
+	// rollingFrom - start of the oldest day of the rolling window.
+	rollingFrom time.Time
+	// days - aggregated states per day and rolling scope. An aggregate over
+	// several days can't be "un-aggregated", so when the oldest day expires
+	// the rolling boards are rebuilt from the days that are left.
+	days map[time.Time]map[leader.Scope]map[string]state
+}
+
+func newWindows(cfg Config) *windows {
+	loc := cfg.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return &windows{
+		loc:         loc,
+		rollingDays: max(cfg.RollingDays, 1),
+		periods:     make(map[leader.Window]time.Time),
+		days:        make(map[time.Time]map[leader.Scope]map[string]state),
+	}
+}
+
+// contains - whether the event belongs to the current period of the window.
+func (ws *windows) contains(w leader.Window, ts time.Time) bool {
+	switch w {
+	case leader.WindowAll:
+		return true
+	case leader.WindowRolling:
+		return !ws.dayStart(ts).Before(ws.rollingFrom)
+	default:
+		return !ws.periodStart(w, ts).Before(ws.periods[w])
+	}
+}
+
+// remember - folds the event into the day's state for the rolling window rebuild.
+func (ws *windows) remember(agg Aggregation, s leader.Scope, e event.Event) {
+	day := ws.dayStart(e.TS)
+	scopes, ok := ws.days[day]
+	if !ok {
+		scopes = make(map[leader.Scope]map[string]state)
+		ws.days[day] = scopes
+	}
+	talents, ok := scopes[s]
+	if !ok {
+		talents = make(map[string]state)
+		scopes[s] = talents
+	}
+	talents[e.TalentID] = agg.Add(talents[e.TalentID], e.Score, e.TS)
+}
+
+func (ws *windows) periodStart(w leader.Window, t time.Time) time.Time {
+	day := ws.dayStart(t)
+	switch w {
+	case leader.WindowWeekly:
+		// ISO week, starts on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case leader.WindowMonthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, ws.loc)
+	default:
+		return day
+	}
+}
+
+func (ws *windows) dayStart(t time.Time) time.Time {
+	t = t.In(ws.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, ws.loc)
+}
+
+// rollover - starts the new period of every expired window.
+// Must be called under the write lock.
+func (lbm *LBMemory) rollover(now time.Time) {
+	ws := lbm.windows
+	for _, w := range []leader.Window{leader.WindowDaily, leader.WindowWeekly, leader.WindowMonthly} {
+		p := ws.periodStart(w, now)
+		if p.Equal(ws.periods[w]) {
+			continue
+		}
+		ws.periods[w] = p
+		lbm.dropBoards(w)
+	}
+
+	from := ws.dayStart(now).AddDate(0, 0, -(ws.rollingDays - 1))
+	if from.Equal(ws.rollingFrom) {
+		return
+	}
+	ws.rollingFrom = from
+	expired := false
+	for day := range ws.days {
+		if day.Before(from) {
+			delete(ws.days, day)
+			expired = true
+		}
+	}
+	if !expired {
+		return
+	}
+
+	// O(M log N) once a day, M - number of remembered day states.
+	// Days are merged oldest first, as the aggregations expect.
+	lbm.dropBoards(leader.WindowRolling)
+	days := make([]time.Time, 0, len(ws.days))
+	for day := range ws.days {
+		days = append(days, day)
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	for _, day := range days {
+		for s, talents := range ws.days[day] {
+			b := lbm.board(s)
+			for talentID, st := range talents {
+				b.merge(talentID, st)
+			}
+		}
+	}
+}
+
+func (lbm *LBMemory) dropBoards(w leader.Window) {
+	for s := range lbm.boards {
+		if s.Window == w {
+			delete(lbm.boards, s)
+		}
+	}
+}
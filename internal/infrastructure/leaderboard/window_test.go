@@ -0,0 +1,95 @@
+package leaderboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+)
+
+// newClockLB - board with a manual clock, starts on Wednesday 2025-01-15 12:00 UTC.
+func newClockLB(t *testing.T) (*LBMemory, *time.Time) {
+	t.Helper()
+	lb := newTestLB(t)
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	lb.now = func() time.Time { return now }
+	lb.rollover(now)
+
+	return lb, &now
+}
+
+func ids(ls leader.Leaders) []string {
+	res := []string{}
+	for _, l := range ls {
+		res = append(res, l.TalentID)
+	}
+	return res
+}
+
+func TestWindows_Contains_Table(t *testing.T) {
+	lb, now := newClockLB(t)
+
+	tests := []struct {
+		name   string
+		window leader.Window
+		ts     time.Time
+		want   bool
+	}{
+		{"All-time takes everything", leader.WindowAll, time.Time{}, true},
+		{"Daily today", leader.WindowDaily, now.Add(-time.Hour), true},
+		{"Daily yesterday", leader.WindowDaily, now.Add(-13 * time.Hour), false},
+		{"Weekly Monday", leader.WindowWeekly, time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC), true},
+		{"Weekly previous Sunday", leader.WindowWeekly, time.Date(2025, 1, 12, 23, 59, 0, 0, time.UTC), false},
+		{"Monthly first day", leader.WindowMonthly, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"Monthly previous month", leader.WindowMonthly, time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), false},
+		{"Rolling 6 days ago", leader.WindowRolling, time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC), true},
+		{"Rolling 7 days ago", leader.WindowRolling, time.Date(2025, 1, 8, 23, 0, 0, 0, time.UTC), false},
+		{"Future event counts", leader.WindowDaily, now.Add(24 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, lb.windows.contains(tt.window, tt.ts))
+		})
+	}
+}
+
+func TestWindows_Timezone(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	ws := newWindows(Config{Location: loc, RollingDays: 1})
+
+	// 22:00 UTC is already the next day in UTC+3
+	got := ws.periodStart(leader.WindowDaily, time.Date(2025, 1, 15, 22, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2025, 1, 16, 0, 0, 0, 0, loc), got)
+}
+
+func TestWindows_Rollover(t *testing.T) {
+	lb, now := newClockLB(t)
+
+	_ = lb.updateIfBetter(event.Event{TalentID: "old", Skill: "pass", Score: 100, TS: now.AddDate(0, 0, -2)})
+	_ = lb.updateIfBetter(event.Event{TalentID: "today", Skill: "pass", Score: 10, TS: *now})
+
+	require.Equal(t, []string{"old", "today"}, ids(lb.Range(leader.Scope{}, 0, 10).Leaders))
+	require.Equal(t, []string{"today"}, ids(lb.Range(leader.Scope{Window: leader.WindowDaily}, 0, 10).Leaders))
+	require.Equal(t, []string{"old", "today"}, ids(lb.Range(leader.Scope{Window: leader.WindowWeekly}, 0, 10).Leaders))
+	require.Equal(t, []string{"old", "today"}, ids(lb.Range(leader.Scope{Skill: "pass", Window: leader.WindowRolling}, 0, 10).Leaders))
+
+	// next day: the daily board starts empty
+	*now = now.Add(24 * time.Hour)
+	lb.rollover(*now)
+	require.Empty(t, lb.Range(leader.Scope{Window: leader.WindowDaily}, 0, 10).Leaders)
+	require.Len(t, lb.Range(leader.Scope{Window: leader.WindowWeekly}, 0, 10).Leaders, 2)
+
+	// next Monday "old" leaves the rolling window, "today" is still in it
+	*now = now.AddDate(0, 0, 4)
+	lb.rollover(*now)
+	require.Empty(t, lb.Range(leader.Scope{Window: leader.WindowWeekly}, 0, 10).Leaders)
+	require.Equal(t, []string{"today"}, ids(lb.Range(leader.Scope{Window: leader.WindowRolling}, 0, 10).Leaders))
+	require.Equal(t, []string{"today"}, ids(lb.Range(leader.Scope{Skill: "pass", Window: leader.WindowRolling}, 0, 10).Leaders))
+
+	// all-time board is never expired
+	require.Len(t, lb.Range(leader.Scope{}, 0, 10).Leaders, 2)
+}
@@ -15,3 +15,310 @@ func New() *prometheus.CounterVec {
 		},
 		[]string{"result"})
 }
+
+// NewIngestRejected - events refused by the validation, once per reason of an event,
+// reason is "required", "not_finite", "unknown_skill", "out_of_range", "future" or "pattern".
+func NewIngestRejected() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "ingest",
+			Name:      "rejected_events_total",
+			Help:      "Events refused by the validation",
+		},
+		[]string{"reason"})
+}
+
+// NewSkill - per-skill board updates, result is "improved" or "ignored".
+func NewSkill() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "board",
+			Name:      "skill_events_total",
+			Help:      "Total number of events applied to per-skill leaderboards",
+		},
+		[]string{"skill", "result"})
+}
+
+// NewCacheEvictions - evicted event IDs, reason is "expired" or "capacity".
+func NewCacheEvictions() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "cache",
+			Name:      "evictions_total",
+			Help:      "Total number of event IDs evicted from the dedup cache",
+		},
+		[]string{"reason"})
+}
+
+func NewCacheSize() prometheus.Gauge {
+	return promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "cache",
+			Name:      "size",
+			Help:      "Number of event IDs kept in the dedup cache",
+		})
+}
+
+// NewScorerRequests - calls of the model server, result is "ok" or "error".
+func NewScorerRequests() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "remote_requests_total",
+			Help:      "Total number of calls of the remote model server",
+		},
+		[]string{"result"})
+}
+
+// NewScorerFallbacks - events scored locally instead of the model server,
+// reason is "error", "breaker_open" or "canceled".
+func NewScorerFallbacks() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "fallback_events_total",
+			Help:      "Total number of events scored by the fallback model",
+		},
+		[]string{"reason"})
+}
+
+// NewScorerBatchSize - events per backend call of a scorer worker.
+func NewScorerBatchSize() prometheus.Histogram {
+	return promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "batch_size",
+			Help:      "Number of events scored in one batch",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		})
+}
+
+func NewScorerBatchDuration() prometheus.Histogram {
+	return promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "batch_duration_seconds",
+			Help:      "Duration of scoring one batch",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		})
+}
+
+// NewScorerPool - the pool settings, param is "workers", "batch_size" or "linger_seconds".
+func NewScorerPool() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "pool",
+			Help:      "Settings of the scorer worker pool",
+		},
+		[]string{"param"})
+}
+
+// NewScorerModelInfo - 1 with the version of the active scoring models.
+func NewScorerModelInfo() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "model_info",
+			Help:      "Version of the active scoring models",
+		},
+		[]string{"version"})
+}
+
+// NewScorerQueueDepth - events in the scorer queues, queue is "in" or "out".
+func NewScorerQueueDepth() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "queue_depth",
+			Help:      "Events in the scorer queue",
+		},
+		[]string{"queue"})
+}
+
+// NewScorerQueueWait - the longest wait for room in the scorer queues
+// during the last report interval, queue is "in" or "out".
+func NewScorerQueueWait() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "queue_wait_seconds",
+			Help:      "Longest wait for room in the scorer queue during the last interval",
+		},
+		[]string{"queue"})
+}
+
+// NewScorerShed - events refused by the ingest queue,
+// reason is "full", "watermark", "timeout" or "canceled".
+func NewScorerShed() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "scorer",
+			Name:      "shed_events_total",
+			Help:      "Events refused by the scorer ingest queue",
+		},
+		[]string{"reason"})
+}
+
+// NewWebhookDeliveries - webhook delivery attempts,
+// result is "delivered", "failed", "dead" or "dropped".
+func NewWebhookDeliveries() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "webhook",
+			Name:      "deliveries_total",
+			Help:      "Webhook delivery attempts by result",
+		},
+		[]string{"webhook", "result"})
+}
+
+// NewWebhookPending - deliveries waiting to be sent.
+func NewWebhookPending() prometheus.Gauge {
+	return promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "webhook",
+			Name:      "pending",
+			Help:      "Webhook deliveries waiting to be sent",
+		})
+}
+
+// Metrics of the named boards, the same as the ones of the default board
+// under the "boards" subsystem with the "board" label first.
+
+func NewBoardEvents() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "ingest_events_processed_total",
+			Help:      "Total number of processed events of a board",
+		},
+		[]string{"board", "result"})
+}
+
+func NewBoardSkill() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "skill_events_total",
+			Help:      "Total number of events applied to per-skill leaderboards of a board",
+		},
+		[]string{"board", "skill", "result"})
+}
+
+func NewBoardCacheEvictions() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "cache_evictions_total",
+			Help:      "Total number of event IDs evicted from the dedup cache of a board",
+		},
+		[]string{"board", "reason"})
+}
+
+func NewBoardCacheSize() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "cache_size",
+			Help:      "Number of event IDs kept in the dedup cache of a board",
+		},
+		[]string{"board"})
+}
+
+func NewBoardModelInfo() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_model_info",
+			Help:      "Version of the active scoring models of a board",
+		},
+		[]string{"board", "version"})
+}
+
+func NewBoardScorerBatchSize() *prometheus.HistogramVec {
+	return promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_batch_size",
+			Help:      "Number of events of a board scored in one batch",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		},
+		[]string{"board"})
+}
+
+func NewBoardScorerBatchDuration() *prometheus.HistogramVec {
+	return promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_batch_duration_seconds",
+			Help:      "Duration of scoring one batch of a board",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		},
+		[]string{"board"})
+}
+
+func NewBoardScorerPool() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_pool",
+			Help:      "Settings of the scorer worker pool of a board",
+		},
+		[]string{"board", "param"})
+}
+
+func NewBoardScorerQueueDepth() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_queue_depth",
+			Help:      "Events in the scorer queue of a board",
+		},
+		[]string{"board", "queue"})
+}
+
+func NewBoardScorerQueueWait() *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_queue_wait_seconds",
+			Help:      "Longest wait for room in the scorer queue of a board during the last interval",
+		},
+		[]string{"board", "queue"})
+}
+
+func NewBoardScorerShed() *prometheus.CounterVec {
+	return promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "leaderboard",
+			Subsystem: "boards",
+			Name:      "scorer_shed_events_total",
+			Help:      "Events refused by the scorer ingest queue of a board",
+		},
+		[]string{"board", "reason"})
+}
@@ -0,0 +1,93 @@
+package ml
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker - circuit breaker: opens after `failures` failed calls in a row,
+// lets one probe call through after `cooldown`, the probe closes or reopens it.
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	cooldown time.Duration
+	state    breakerState
+	failed   int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func newBreaker(failures int, cooldown time.Duration) *breaker {
+	return &breaker{failures: failures, cooldown: cooldown, now: time.Now}
+}
+
+// allow - false while open, a true must be followed by success or failure.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		// the only probe is in flight
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success - true if it closes the breaker.
+func (b *breaker) success() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	closed := b.state != breakerClosed
+	b.state = breakerClosed
+	b.failed = 0
+	b.probing = false
+
+	return closed
+}
+
+// failure - true if it opens the closed breaker, a failed probe just reopens it.
+func (b *breaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	opened := false
+	b.failed++
+	b.probing = false
+	if b.state == breakerHalfOpen || b.failed >= b.failures {
+		opened = b.state == breakerClosed
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+
+	return opened
+}
+
+func (b *breaker) current() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,125 @@
+package ml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// FeatureRawMetric - the only feature we have for now, the schema has
+// feature names so a model file keeps working when more are added.
+const FeatureRawMetric = "raw_metric"
+
+// GBT - gradient boosted trees:
+// score = BaseScore + LearningRate * sum(leaf of every tree).
+//
+//	{
+//	  "base_score": 10, "learning_rate": 0.5, "features": ["raw_metric"],
+//	  "trees": [{"nodes": [
+//	    {"feature": 0, "threshold": 50, "left": 1, "right": 2},
+//	    {"leaf": -4},
+//	    {"leaf": 12}
+//	  ]}]
+//	}
+//
+// The root is the node 0, "x < threshold" goes left, children always
+// have bigger indexes than their parent, so a tree can't have cycles.
+type GBT struct {
+	BaseScore    float64   `json:"base_score"`
+	LearningRate float64   `json:"learning_rate"`
+	Features     []string  `json:"features"`
+	Trees        []gbtTree `json:"trees"`
+}
+
+type gbtTree struct {
+	Nodes []gbtNode `json:"nodes"`
+}
+
+type gbtNode struct {
+	Feature   int      `json:"feature"`
+	Threshold float64  `json:"threshold"`
+	Left      int      `json:"left"`
+	Right     int      `json:"right"`
+	Leaf      *float64 `json:"leaf,omitempty"`
+}
+
+func LoadGBT(path string) (*GBT, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gbt model: %w", err)
+	}
+	// absent learning_rate - the leaves as they are
+	m := GBT{LearningRate: 1}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse gbt model %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("gbt model %s: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+func (m *GBT) validate() error {
+	for _, f := range m.Features {
+		if f != FeatureRawMetric {
+			return fmt.Errorf("unknown feature %q", f)
+		}
+	}
+	// Score has a vector of one feature
+	if len(m.Features) != 1 {
+		return fmt.Errorf("features %v: exactly one %q is supported", m.Features, FeatureRawMetric)
+	}
+	// 0 scores every talent as base_score
+	if m.LearningRate <= 0 {
+		return fmt.Errorf("learning_rate %v: must be positive", m.LearningRate)
+	}
+	if len(m.Trees) == 0 {
+		return errors.New("no trees")
+	}
+	for t, tree := range m.Trees {
+		if len(tree.Nodes) == 0 {
+			return fmt.Errorf("tree %d: no nodes", t)
+		}
+		for i, n := range tree.Nodes {
+			if n.Leaf != nil {
+				continue
+			}
+			if n.Feature < 0 || n.Feature >= len(m.Features) {
+				return fmt.Errorf("tree %d node %d: feature %d out of range", t, i, n.Feature)
+			}
+			for _, child := range []int{n.Left, n.Right} {
+				if child <= i || child >= len(tree.Nodes) {
+					return fmt.Errorf("tree %d node %d: invalid child %d", t, i, child)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (*GBT) Name() string { return ModelGBT }
+
+// Score - O(trees * depth)
+func (m *GBT) Score(raw float64) float64 {
+	// features by index, only raw_metric is supported
+	x := [1]float64{raw}
+
+	sum := 0.0
+	for _, tree := range m.Trees {
+		i := 0
+		for tree.Nodes[i].Leaf == nil {
+			n := tree.Nodes[i]
+			if x[n.Feature] < n.Threshold {
+				i = n.Left
+			} else {
+				i = n.Right
+			}
+		}
+		sum += *tree.Nodes[i].Leaf
+	}
+
+	return m.BaseScore + m.LearningRate*sum
+}
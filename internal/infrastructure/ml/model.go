@@ -0,0 +1,234 @@
+package ml
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"leaderboard-api/internal/domain/model"
+)
+
+const (
+	ModelLinear    = "linear"
+	ModelPiecewise = "piecewise"
+	ModelGBT       = "gbt"
+)
+
+// Model - turns the raw metric of an event into its score.
+// Must be deterministic and safe for concurrent use.
+type Model interface {
+	Name() string
+	Score(rawMetric float64) float64
+}
+
+const (
+	versionIdentity    = "identity"
+	versionUnversioned = "unversioned"
+)
+
+// Models - a model per skill and the default one for the rest.
+type Models struct {
+	version  string
+	loadedAt time.Time
+	def      Model
+	skills   map[string]Model
+}
+
+// IdentityModels - the score is the raw metric, when no models are configured.
+func IdentityModels() *Models {
+	return &Models{version: versionIdentity, loadedAt: time.Now(), def: Linear{Weight: 1}}
+}
+
+func (ms *Models) Version() string { return ms.version }
+
+func (ms *Models) Info() model.Version {
+	skills := make(map[string]string, len(ms.skills))
+	for skill, m := range ms.skills {
+		skills[skill] = m.Name()
+	}
+
+	return model.Version{
+		Name:     ms.version,
+		LoadedAt: ms.loadedAt,
+		Default:  ms.def.Name(),
+		Skills:   skills,
+	}
+}
+
+func (ms *Models) For(skill string) Model {
+	if m, ok := ms.skills[skill]; ok {
+		return m
+	}
+	return ms.def
+}
+
+func (ms *Models) Score(skill string, rawMetric float64) float64 {
+	return ms.For(skill).Score(rawMetric)
+}
+
+// ScoreBatch - the local Backend, never fails.
+func (ms *Models) ScoreBatch(_ context.Context, in []Input) (Scores, error) {
+	scores := make([]float64, len(in))
+	for i, x := range in {
+		scores[i] = ms.Score(x.Skill, x.RawMetric)
+	}
+
+	return Scores{Values: scores, Version: ms.version}, nil
+}
+
+// ModelSpec - one model in the models file, the fields depend on the type.
+type ModelSpec struct {
+	Type string `json:"type"`
+	// linear
+	Weight float64 `json:"weight"`
+	Bias   float64 `json:"bias"`
+	// piecewise
+	Points []Point `json:"points"`
+	// gbt - model file, relative to the models file
+	Path string `json:"path"`
+}
+
+// ModelsFile - e.g.
+//
+//	{
+//	  "version": "2025-01-15",
+//	  "default": {"type": "linear", "weight": 1},
+//	  "skills": {
+//	    "shoot": {"type": "piecewise", "points": [{"x": 0, "y": 0}, {"x": 100, "y": 200}]},
+//	    "pass":  {"type": "gbt", "path": "pass.gbt.json"}
+//	  }
+//	}
+type ModelsFile struct {
+	// Version - optional, a version directory overrides it by its name.
+	Version string               `json:"version"`
+	Default ModelSpec            `json:"default"`
+	Skills  map[string]ModelSpec `json:"skills"`
+}
+
+// LoadModels - reads the models file, every model is validated on load.
+func LoadModels(path string) (*Models, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read models: %w", err)
+	}
+	ms, err := ParseModels(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("models %s: %w", path, err)
+	}
+
+	return ms, nil
+}
+
+// ParseModels - the models file content, gbt paths are relative to dir.
+func ParseModels(data []byte, dir string) (*Models, error) {
+	var f ModelsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse models: %w", err)
+	}
+
+	var err error
+	ms := &Models{
+		version:  cmp.Or(f.Version, versionUnversioned),
+		loadedAt: time.Now(),
+		skills:   make(map[string]Model, len(f.Skills)),
+	}
+	if ms.def, err = newModel(f.Default, dir); err != nil {
+		return nil, fmt.Errorf("default model: %w", err)
+	}
+	for skill, spec := range f.Skills {
+		if ms.skills[skill], err = newModel(spec, dir); err != nil {
+			return nil, fmt.Errorf("model of %q: %w", skill, err)
+		}
+	}
+
+	return ms, nil
+}
+
+func newModel(spec ModelSpec, dir string) (Model, error) {
+	switch spec.Type {
+	case ModelLinear:
+		return Linear{Weight: spec.Weight, Bias: spec.Bias}, nil
+	case ModelPiecewise:
+		return NewPiecewise(spec.Points)
+	case ModelGBT:
+		if spec.Path == "" {
+			return nil, errors.New("gbt model without a path")
+		}
+		path := spec.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return LoadGBT(path)
+	default:
+		return nil, fmt.Errorf("unknown model type %q", spec.Type)
+	}
+}
+
+// Linear - Weight*raw + Bias.
+type Linear struct {
+	Weight float64
+	Bias   float64
+}
+
+func (Linear) Name() string { return ModelLinear }
+
+func (l Linear) Score(raw float64) float64 { return l.Weight*raw + l.Bias }
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Piecewise - lookup table with linear interpolation between the points,
+// flat outside of them.
+type Piecewise struct {
+	points []Point
+}
+
+func NewPiecewise(points []Point) (*Piecewise, error) {
+	if len(points) == 0 {
+		return nil, errors.New("piecewise model without points")
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].X <= points[i-1].X {
+			return nil, errors.New("piecewise model points must be sorted by x without repeats")
+		}
+	}
+
+	return &Piecewise{points: slices.Clone(points)}, nil
+}
+
+func (*Piecewise) Name() string { return ModelPiecewise }
+
+// Score - O(log P)
+func (p *Piecewise) Score(raw float64) float64 {
+	pts := p.points
+	i, _ := slices.BinarySearchFunc(pts, raw, func(pt Point, x float64) int {
+		switch {
+		case pt.X < x:
+			return -1
+		case pt.X > x:
+			return 1
+		default:
+			return 0
+		}
+	})
+	switch {
+	case i == 0:
+		return pts[0].Y
+	case i == len(pts):
+		return pts[len(pts)-1].Y
+	}
+	a, b := pts[i-1], pts[i]
+	if raw == b.X {
+		return b.Y
+	}
+
+	return a.Y + (b.Y-a.Y)*(raw-a.X)/(b.X-a.X)
+}
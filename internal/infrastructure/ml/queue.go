@@ -0,0 +1,168 @@
+package ml
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+const (
+	// PolicyWait - a new event waits for room up to MaxWait.
+	PolicyWait = "wait"
+	// PolicyReject - a new event is shed at once when the queue is full.
+	PolicyReject = "reject"
+
+	defaultQueueSize = 1000
+
+	queueReportInterval = time.Second
+)
+
+// Shed reasons.
+const (
+	ShedFull      = "full"
+	ShedWatermark = "watermark"
+	ShedTimeout   = "timeout"
+	ShedCanceled  = "canceled"
+)
+
+// QueueConfig - the ingest queue of the pool, Size is the capacity of both queues.
+// Above ShedAt(a share of Size, 0 - off) new events are shed by any policy,
+// so a burst doesn't take the room of the events that must be queued(replay).
+// MaxWait 0 - the "wait" policy waits as long as the request lives.
+type QueueConfig struct {
+	Size    int
+	Policy  string
+	MaxWait time.Duration
+	ShedAt  float64
+}
+
+func (q QueueConfig) validate() error {
+	switch q.Policy {
+	case PolicyWait, PolicyReject:
+	default:
+		return fmt.Errorf("unknown queue policy %q", q.Policy)
+	}
+	if q.ShedAt < 0 || q.ShedAt > 1 {
+		return fmt.Errorf("queue shed watermark %g must be in [0, 1]", q.ShedAt)
+	}
+
+	return nil
+}
+
+// maxWait - the longest wait since the last report.
+type maxWait struct{ ns atomic.Int64 }
+
+func (w *maxWait) observe(d time.Duration) {
+	for {
+		cur := w.ns.Load()
+		if int64(d) <= cur || w.ns.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+func (w *maxWait) reset() time.Duration { return time.Duration(w.ns.Swap(0)) }
+
+// Submit - queues a new event by the shedding policy. accept is called once
+// the event has room in the queue(e.g. to log it), the event is not queued
+// if it fails. ErrQueueFull and ErrQueueTimeout tell the producer to retry later.
+func (s *Scorer) Submit(ctx context.Context, e event.Event, accept func(*event.Event) error) error {
+	if err := s.admit(ctx); err != nil {
+		return err
+	}
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		<-s.slots
+		return event.ErrQueueClosed
+	}
+	if accept != nil {
+		if err := accept(&e); err != nil {
+			<-s.slots
+			return err
+		}
+	}
+	// never blocks, the room is reserved
+	s.in <- e
+
+	return nil
+}
+
+// Push - queues an event that can't be shed(replayed, seeded), waits for room
+// as long as ctx lives.
+func (s *Scorer) Push(ctx context.Context, e event.Event) error {
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		<-s.slots
+		return event.ErrQueueClosed
+	}
+	s.in <- e
+
+	return nil
+}
+
+// admit - reserves room in the ingest queue for one event.
+func (s *Scorer) admit(ctx context.Context) error {
+	q := s.cfg.Queue
+	if s.shedAt > 0 && len(s.slots) >= s.shedAt {
+		s.metrics.Shed.WithLabelValues(ShedWatermark).Inc()
+		return event.ErrQueueFull
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if q.Policy == PolicyReject {
+		s.metrics.Shed.WithLabelValues(ShedFull).Inc()
+		return event.ErrQueueFull
+	}
+
+	var timeout <-chan time.Time
+	if q.MaxWait > 0 {
+		timer := time.NewTimer(q.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	start := time.Now()
+	select {
+	case s.slots <- struct{}{}:
+		s.inWait.observe(time.Since(start))
+		return nil
+	case <-timeout:
+		s.inWait.observe(q.MaxWait)
+		s.metrics.Shed.WithLabelValues(ShedTimeout).Inc()
+		return event.ErrQueueTimeout
+	case <-ctx.Done():
+		s.metrics.Shed.WithLabelValues(ShedCanceled).Inc()
+		return ctx.Err()
+	}
+}
+
+// reportQueues - depth and the longest wait of both queues every interval.
+func (s *Scorer) reportQueues(ctx context.Context) {
+	ticker := time.NewTicker(queueReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.metrics.QueueDepth.WithLabelValues("in").Set(float64(len(s.in)))
+			s.metrics.QueueDepth.WithLabelValues("out").Set(float64(len(s.out)))
+			s.metrics.QueueWait.WithLabelValues("in").Set(s.inWait.reset().Seconds())
+			s.metrics.QueueWait.WithLabelValues("out").Set(s.outWait.reset().Seconds())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ml
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+func TestScorer_Shedding(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue QueueConfig
+		// fits - events accepted before the queue sheds
+		fits       int
+		wantErr    error
+		wantReason string
+	}{
+		{"Reject when full", QueueConfig{Size: 3, Policy: PolicyReject}, 3, event.ErrQueueFull, ShedFull},
+		{"Wait times out", QueueConfig{Size: 3, Policy: PolicyWait, MaxWait: 10 * time.Millisecond}, 3, event.ErrQueueTimeout, ShedTimeout},
+		{"Watermark", QueueConfig{Size: 10, Policy: PolicyWait, MaxWait: time.Hour, ShedAt: 0.5}, 5, event.ErrQueueFull, ShedWatermark},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mtr := newTestMetrics()
+			// no workers, nothing leaves the queue
+			sc := newTestScorer(t, nil, Config{Queue: tt.queue}, mtr)
+
+			for i := range tt.fits {
+				require.NoError(t, sc.Submit(ctx, event.Event{RawMetric: float64(i)}, nil))
+			}
+			accepted := false
+			err := sc.Submit(ctx, event.Event{}, func(*event.Event) error {
+				accepted = true
+				return nil
+			})
+			require.ErrorIs(t, err, tt.wantErr)
+			require.False(t, accepted, "a shed event is not logged")
+			require.Equal(t, 1.0, testutil.ToFloat64(mtr.Shed.WithLabelValues(tt.wantReason)))
+
+			// replayed events are never shed, they take the room above the watermark
+			if tt.queue.ShedAt > 0 {
+				require.NoError(t, sc.Push(ctx, event.Event{}))
+			}
+		})
+	}
+}
+
+func TestScorer_SubmitWaitsForRoom(t *testing.T) {
+	ctx := context.Background()
+	sc := newTestScorer(t, nil, Config{Queue: QueueConfig{Size: 1, Policy: PolicyWait, MaxWait: time.Second}}, newTestMetrics())
+	require.NoError(t, sc.Submit(ctx, event.Event{RawMetric: 1}, nil))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		sc.RunScorerPool(ctx, 1)
+	}()
+	require.NoError(t, sc.Submit(ctx, event.Event{RawMetric: 2}, nil))
+	require.GreaterOrEqual(t, sc.inWait.reset(), 10*time.Millisecond)
+
+	out := receive(t, sc, 2)
+	require.ElementsMatch(t, []float64{1, 2}, []float64{out[0].Score, out[1].Score})
+}
+
+func TestScorer_SubmitRequestDeadline(t *testing.T) {
+	mtr := newTestMetrics()
+	// no max wait, the request deadline bounds it
+	sc := newTestScorer(t, nil, Config{Queue: QueueConfig{Size: 1}}, mtr)
+	require.NoError(t, sc.Submit(context.Background(), event.Event{}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, sc.Submit(ctx, event.Event{}, nil), context.DeadlineExceeded)
+	require.Equal(t, 1.0, testutil.ToFloat64(mtr.Shed.WithLabelValues(ShedCanceled)))
+}
+
+func TestScorer_SubmitAccept(t *testing.T) {
+	ctx := context.Background()
+	sc := newTestScorer(t, nil, Config{Queue: QueueConfig{Size: 1, Policy: PolicyReject}}, newTestMetrics())
+
+	// a failed accept gives the room back
+	failure := errors.New("disk is full")
+	require.ErrorIs(t, sc.Submit(ctx, event.Event{}, func(*event.Event) error { return failure }), failure)
+
+	// the accepted event is queued as accept left it
+	require.NoError(t, sc.Submit(ctx, event.Event{RawMetric: 5}, func(e *event.Event) error {
+		e.Seq = 42
+		return nil
+	}))
+	sc.RunScorerPool(ctx, 1)
+	out := receive(t, sc, 1)[0]
+	require.Equal(t, uint64(42), out.Seq)
+	require.Equal(t, 5.0, out.Score)
+}
+
+func TestScorer_InvalidQueue(t *testing.T) {
+	for _, q := range []QueueConfig{{Policy: "magic"}, {ShedAt: 1.5}, {ShedAt: -1}} {
+		_, err := New(context.Background(), zap.NewNop(), nil, Config{Queue: q}, newTestMetrics())
+		require.Error(t, err, "%+v", q)
+	}
+}
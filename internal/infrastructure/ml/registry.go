@@ -0,0 +1,227 @@
+package ml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/model"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+
+	// versionModelsFile - the models file of a version directory.
+	versionModelsFile = "models.json"
+)
+
+var ErrNoModelVersions = errors.New("no valid model versions")
+
+// Registry - Backend of the active version of the local models.
+// With a directory every subdirectory is a version("<dir>/<version>/models.json"),
+// the greatest valid one is active, so removing it rolls back to the previous one.
+// A new version must appear at once(e.g. written aside and renamed into the dir).
+type Registry struct {
+	log      *zap.Logger
+	dir      string
+	interval time.Duration
+	active   atomic.Pointer[Models]
+	info     *prometheus.GaugeVec
+	// invalid - versions that failed to load by the mod time of their models file,
+	// they aren't loaded(and logged) again until changed.
+	invalid map[string]time.Time
+}
+
+// NewRegistry - static registry of the given models, never reloaded.
+func NewRegistry(log *zap.Logger, models *Models, info *prometheus.GaugeVec) *Registry {
+	r := &Registry{log: log, info: info}
+	r.swap(models)
+
+	return r
+}
+
+// OpenRegistry - loads the greatest valid version in dir, Watch reloads it.
+func OpenRegistry(log *zap.Logger, dir string, interval time.Duration, info *prometheus.GaugeVec) (*Registry, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	r := &Registry{log: log, dir: dir, interval: interval, info: info, invalid: make(map[string]time.Time)}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ScoreBatch - a batch is scored by one version, a swap doesn't affect the batches in flight.
+func (r *Registry) ScoreBatch(ctx context.Context, in []Input) (Scores, error) {
+	return r.active.Load().ScoreBatch(ctx, in)
+}
+
+func (r *Registry) Active() model.Version {
+	return r.active.Load().Info()
+}
+
+// Watch - polls the directory every interval and swaps to the greatest valid version.
+func (r *Registry) Watch(ctx context.Context) {
+	if r.dir == "" {
+		return
+	}
+	r.log.Info("starting model watcher", zap.String("dir", r.dir))
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := r.Reload(); err != nil {
+				r.log.Error("model reload failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			r.log.Info("model watcher gracefully stopped")
+			return
+		}
+	}
+}
+
+// Reload - true if another version became active. Not safe for concurrent use,
+// it's called by OpenRegistry and then by Watch only.
+func (r *Registry) Reload() (bool, error) {
+	if r.dir == "" {
+		return false, nil
+	}
+	versions, err := r.versions()
+	if err != nil {
+		return false, err
+	}
+
+	current := ""
+	if m := r.active.Load(); m != nil {
+		current = m.version
+	}
+	// from the greatest, the first valid one wins
+	for i := len(versions) - 1; i >= 0; i-- {
+		name := versions[i]
+		if name == current {
+			return false, nil
+		}
+		path := filepath.Join(r.dir, name, versionModelsFile)
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if at, ok := r.invalid[name]; ok && at.Equal(fi.ModTime()) {
+			continue
+		}
+
+		models, err := LoadModels(path)
+		if err != nil {
+			r.invalid[name] = fi.ModTime()
+			r.log.Error("invalid model version skipped", zap.String("version", name), zap.Error(err))
+			continue
+		}
+		delete(r.invalid, name)
+		models.version = name
+		r.swap(models)
+		r.log.Info("model version activated", zap.String("version", name), zap.String("previous", current))
+
+		return true, nil
+	}
+	if current == "" {
+		return false, fmt.Errorf("%w in %s", ErrNoModelVersions, r.dir)
+	}
+
+	return false, nil
+}
+
+// Set - activates the models, e.g. the new scoring config of a board.
+func (r *Registry) Set(models *Models) {
+	r.swap(models)
+	r.log.Info("models activated", zap.String("version", models.version))
+}
+
+func (r *Registry) swap(models *Models) {
+	prev := r.active.Swap(models)
+	if prev != nil {
+		r.info.DeleteLabelValues(prev.version)
+	}
+	r.info.WithLabelValues(models.version).Set(1)
+}
+
+// versions - names of the version directories in the ascending order.
+func (r *Registry) versions() ([]string, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, fmt.Errorf("model versions: %w", err)
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name()[0] != '.' {
+			versions = append(versions, e.Name())
+		}
+	}
+	slices.SortFunc(versions, compareVersions)
+
+	return versions, nil
+}
+
+// compareVersions - natural order, numbers are compared by value: "v2" < "v10", "1.9" < "1.10".
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		ad, bd := isDigit(a[0]), isDigit(b[0])
+		if ad != bd {
+			// digits go first
+			if ad {
+				return -1
+			}
+			return 1
+		}
+
+		na, nb := chunk(a, ad), chunk(b, bd)
+		ca, cb := a[:na], b[:nb]
+		if ad {
+			// by length after the leading zeros, then lexically
+			ta, tb := trimZeros(ca), trimZeros(cb)
+			if len(ta) != len(tb) {
+				return len(ta) - len(tb)
+			}
+			ca, cb = ta, tb
+		}
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+
+	return len(a) - len(b)
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// chunk - length of the leading run of digits or non-digits.
+func chunk(s string, digits bool) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) == digits {
+		n++
+	}
+	return n
+}
+
+func trimZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
@@ -0,0 +1,191 @@
+package ml
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+// writeVersion - every version scores raw*weight by the default model.
+func writeVersion(t *testing.T, dir, version string, weight float64) {
+	t.Helper()
+	writeVersionFile(t, dir, version, fmt.Sprintf(`{"default": {"type": "linear", "weight": %g}}`, weight))
+}
+
+func writeVersionFile(t *testing.T, dir, version, data string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, version), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, version, versionModelsFile), []byte(data), 0o644))
+}
+
+func newTestModelInfo() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_model_info"}, []string{"version"})
+}
+
+func registryScore(t *testing.T, r *Registry, raw float64) Scores {
+	t.Helper()
+	scores, err := r.ScoreBatch(context.Background(), []Input{{RawMetric: raw}})
+	require.NoError(t, err)
+
+	return scores
+}
+
+func TestRegistry_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion(t, dir, "v2", 2)
+	writeVersion(t, dir, "v10", 10)
+	info := newTestModelInfo()
+
+	r, err := OpenRegistry(zap.NewNop(), dir, time.Minute, info)
+	require.NoError(t, err)
+	// natural order, v10 > v2
+	require.Equal(t, Scores{Values: []float64{10}, Version: "v10"}, registryScore(t, r, 1))
+	require.Equal(t, "v10", r.Active().Name)
+	require.Equal(t, 1.0, testutil.ToFloat64(info.WithLabelValues("v10")))
+
+	// nothing new
+	swapped, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	// a new version
+	writeVersion(t, dir, "v11", 11)
+	swapped, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, Scores{Values: []float64{11}, Version: "v11"}, registryScore(t, r, 1))
+	require.Equal(t, 1, testutil.CollectAndCount(info))
+	require.Equal(t, 1.0, testutil.ToFloat64(info.WithLabelValues("v11")))
+
+	// an invalid one is skipped, the active one is kept
+	writeVersionFile(t, dir, "v12", `{"default": {"type": "magic"}}`)
+	swapped, err = r.Reload()
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.Equal(t, "v11", r.Active().Name)
+
+	// until it's fixed
+	writeVersion(t, dir, "v12", 12)
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "v12", versionModelsFile), future, future))
+	swapped, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, "v12", r.Active().Name)
+
+	// removing the active version rolls back
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "v12")))
+	swapped, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, Scores{Values: []float64{11}, Version: "v11"}, registryScore(t, r, 1))
+}
+
+func TestRegistry_SkipsInvalidOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion(t, dir, "1.9", 9)
+	writeVersionFile(t, dir, "1.10", `{`)
+	// not a version
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tmp-1.11"), 0o755))
+
+	r, err := OpenRegistry(zap.NewNop(), dir, time.Minute, newTestModelInfo())
+	require.NoError(t, err)
+	require.Equal(t, "1.9", r.Active().Name)
+}
+
+func TestRegistry_NoVersions(t *testing.T) {
+	dir := t.TempDir()
+	writeVersionFile(t, dir, "v1", `{`)
+
+	_, err := OpenRegistry(zap.NewNop(), dir, time.Minute, newTestModelInfo())
+	require.ErrorIs(t, err, ErrNoModelVersions)
+
+	_, err = OpenRegistry(zap.NewNop(), filepath.Join(dir, "missing"), time.Minute, newTestModelInfo())
+	require.Error(t, err)
+}
+
+func TestRegistry_Static(t *testing.T) {
+	r := NewRegistry(zap.NewNop(), newTestModels(t), newTestModelInfo())
+	require.Equal(t, "test-1", r.Active().Name)
+	require.Equal(t, map[string]string{"dribble": "linear", "pass": "piecewise", "shoot": "gbt"}, r.Active().Skills)
+	require.Equal(t, "linear", r.Active().Default)
+
+	// without a directory there is nothing to watch
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Watch(ctx)
+	swapped, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, swapped)
+}
+
+// TestRegistry_SwapKeepsEvents - a swap in the middle of the traffic
+// doesn't lose events, every event is scored by one of the versions.
+func TestRegistry_SwapKeepsEvents(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion(t, dir, "v1", 1)
+	r, err := OpenRegistry(zap.NewNop(), dir, time.Minute, newTestModelInfo())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	sc := newTestScorer(t, r, Config{BatchSize: 8, Linger: time.Millisecond}, newTestMetrics())
+	sc.RunScorerPool(ctx, 4)
+	defer sc.ClosePool(ctx)
+
+	// picked up by the reload only
+	writeVersion(t, dir, "v2", 2)
+	const events = 500
+	go func() {
+		for i := range events {
+			if i == events/2 {
+				_, _ = r.Reload()
+			}
+			_ = sc.Push(ctx, event.Event{RawMetric: 1})
+		}
+	}()
+
+	versions := map[string]int{}
+	for _, e := range receive(t, sc, events) {
+		switch e.ModelVersion {
+		case "v1":
+			require.Equal(t, 1.0, e.Score)
+		case "v2":
+			require.Equal(t, 2.0, e.Score)
+		default:
+			t.Fatalf("unexpected version %q", e.ModelVersion)
+		}
+		versions[e.ModelVersion]++
+	}
+	require.Positive(t, versions["v2"])
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1", "v1", 0},
+		{"v2", "v10", -1},
+		{"1.10", "1.9", 1},
+		{"v01", "v1", 0},
+		{"2025-01-15", "2025-02-01", -1},
+		{"v1", "v1-hotfix", -1},
+		{"a", "b", -1},
+		{"1", "a", -1},
+	}
+	for _, tt := range tests {
+		got := compareVersions(tt.a, tt.b)
+		require.Equal(t, tt.want, max(-1, min(1, got)), "%s vs %s", tt.a, tt.b)
+		require.Equal(t, -tt.want, max(-1, min(1, compareVersions(tt.b, tt.a))), "%s vs %s", tt.b, tt.a)
+	}
+}
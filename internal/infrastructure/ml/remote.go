@@ -0,0 +1,305 @@
+package ml
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRemoteTimeout    = time.Second
+	defaultRemoteBatchSize  = 64
+	defaultRetryBackoff     = 50 * time.Millisecond
+	defaultMaxRetryBackoff  = time.Second
+	defaultBreakerFailures  = 5
+	defaultBreakerCooldown  = 10 * time.Second
+	maxRemoteResponseLength = 4 << 20
+
+	FallbackError    = "error"
+	FallbackOpen     = "breaker_open"
+	FallbackCanceled = "canceled"
+)
+
+// errRemoteRejected - the same request fails again, no retries.
+var errRemoteRejected = errors.New("remote scorer: model server rejected the request")
+
+type RemoteConfig struct {
+	// URL - POST {"inputs": [Input...]} -> {"scores": [float64...]}, a score per input.
+	URL string
+	// Timeout - of one HTTP call.
+	Timeout time.Duration
+	// Retries - after the first call, with exponential backoff and full jitter.
+	Retries int
+	// RetryBackoff - the first backoff, doubled by every retry up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// BatchSize - concurrent calls are sent in one request of up to BatchSize inputs,
+	// a request waits up to Linger for more, 0 - no waiting.
+	BatchSize int
+	Linger    time.Duration
+	// BreakerFailures - failed requests in a row(after retries) that open the breaker,
+	// while open everything is scored by the fallback, a probe goes after BreakerCooldown.
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+// RemoteMetrics - requests by result("ok", "error") and events scored
+// by the fallback by reason("error", "breaker_open", "canceled").
+type RemoteMetrics struct {
+	Requests  *prometheus.CounterVec
+	Fallbacks *prometheus.CounterVec
+}
+
+// Remote - Backend of an external model server. Never fails, when the server
+// is unhealthy the events are scored by the fallback(local) backend.
+type Remote struct {
+	log      *zap.Logger
+	cfg      RemoteConfig
+	client   *http.Client
+	fallback Backend
+	breaker  *breaker
+	metrics  RemoteMetrics
+
+	mu      sync.Mutex
+	pending []*remoteCall
+	size    int
+	timer   *time.Timer
+}
+
+type remoteCall struct {
+	in  []Input
+	out chan Scores
+}
+
+type remoteRequest struct {
+	Inputs []Input `json:"inputs"`
+}
+
+// remoteResponse - Version of the served model is optional.
+type remoteResponse struct {
+	Scores  []float64 `json:"scores"`
+	Version string    `json:"version"`
+}
+
+func NewRemote(log *zap.Logger, cfg RemoteConfig, fallback Backend, metrics RemoteMetrics) (*Remote, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("remote scorer without a URL")
+	}
+	if fallback == nil {
+		return nil, errors.New("remote scorer without a fallback")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultRemoteTimeout
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	if cfg.MaxRetryBackoff < cfg.RetryBackoff {
+		cfg.MaxRetryBackoff = max(defaultMaxRetryBackoff, cfg.RetryBackoff)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultRemoteBatchSize
+	}
+	if cfg.Linger < 0 {
+		cfg.Linger = 0
+	}
+	if cfg.BreakerFailures <= 0 {
+		cfg.BreakerFailures = defaultBreakerFailures
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaultBreakerCooldown
+	}
+
+	return &Remote{
+		log:      log,
+		cfg:      cfg,
+		client:   &http.Client{},
+		fallback: fallback,
+		breaker:  newBreaker(cfg.BreakerFailures, cfg.BreakerCooldown),
+		metrics:  metrics,
+	}, nil
+}
+
+// ScoreBatch - joins the batch of the next request. If ctx is done first,
+// the inputs are scored by the fallback, the request goes on without them.
+func (r *Remote) ScoreBatch(ctx context.Context, in []Input) (Scores, error) {
+	if len(in) == 0 {
+		return Scores{}, nil
+	}
+	call := &remoteCall{in: in, out: make(chan Scores, 1)}
+	r.enqueue(call)
+
+	select {
+	case scores := <-call.out:
+		return scores, nil
+	case <-ctx.Done():
+		r.metrics.Fallbacks.WithLabelValues(FallbackCanceled).Add(float64(len(in)))
+		return r.fallback.ScoreBatch(context.WithoutCancel(ctx), in)
+	}
+}
+
+func (r *Remote) enqueue(call *remoteCall) {
+	r.mu.Lock()
+	r.pending = append(r.pending, call)
+	r.size += len(call.in)
+	if r.size >= r.cfg.BatchSize || r.cfg.Linger == 0 {
+		batch := r.take()
+		r.mu.Unlock()
+		go r.send(batch)
+		return
+	}
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.cfg.Linger, r.flush)
+	}
+	r.mu.Unlock()
+}
+
+func (r *Remote) flush() {
+	r.mu.Lock()
+	batch := r.take()
+	r.mu.Unlock()
+
+	if len(batch) > 0 {
+		r.send(batch)
+	}
+}
+
+// take - must be called under the lock.
+func (r *Remote) take() []*remoteCall {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	batch := r.pending
+	r.pending = nil
+	r.size = 0
+
+	return batch
+}
+
+// send - one request per BatchSize inputs, a call is never split between them.
+func (r *Remote) send(batch []*remoteCall) {
+	for len(batch) > 0 {
+		n, size := 0, 0
+		for n < len(batch) && (n == 0 || size+len(batch[n].in) <= r.cfg.BatchSize) {
+			size += len(batch[n].in)
+			n++
+		}
+		r.sendChunk(batch[:n], size)
+		batch = batch[n:]
+	}
+}
+
+func (r *Remote) sendChunk(calls []*remoteCall, size int) {
+	in := make([]Input, 0, size)
+	for _, c := range calls {
+		in = append(in, c.in...)
+	}
+
+	scores, reason := r.score(in)
+	if scores.Values == nil {
+		r.metrics.Fallbacks.WithLabelValues(reason).Add(float64(len(in)))
+		// the local fallback never fails
+		scores, _ = r.fallback.ScoreBatch(context.Background(), in)
+	}
+	values := scores.Values
+	for _, c := range calls {
+		c.out <- Scores{Values: values[:len(c.in):len(c.in)], Version: scores.Version}
+		values = values[len(c.in):]
+	}
+}
+
+// score - no values and the fallback reason if the remote can't score now.
+func (r *Remote) score(in []Input) (Scores, string) {
+	if !r.breaker.allow() {
+		return Scores{}, FallbackOpen
+	}
+
+	scores, err := r.callWithRetries(in)
+	if err != nil {
+		if r.breaker.failure() {
+			r.log.Warn("model server is unhealthy, scoring by the fallback", zap.Duration("cooldown", r.cfg.BreakerCooldown), zap.Error(err))
+		}
+		return Scores{}, FallbackError
+	}
+	if r.breaker.success() {
+		r.log.Info("model server is healthy again")
+	}
+
+	return scores, ""
+}
+
+func (r *Remote) callWithRetries(in []Input) (Scores, error) {
+	body, err := json.Marshal(remoteRequest{Inputs: in})
+	if err != nil {
+		return Scores{}, fmt.Errorf("remote scorer: %w", err)
+	}
+
+	backoff := r.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		scores, err := r.call(body, len(in))
+		if err == nil {
+			r.metrics.Requests.WithLabelValues("ok").Inc()
+			return scores, nil
+		}
+		r.metrics.Requests.WithLabelValues("error").Inc()
+		if attempt == r.cfg.Retries || errors.Is(err, errRemoteRejected) {
+			return Scores{}, err
+		}
+		r.log.Debug("model server call failed, retrying", zap.Int("attempt", attempt+1), zap.Error(err))
+
+		// full jitter, retries of many instances don't come in waves
+		time.Sleep(rand.N(backoff) + 1)
+		backoff = min(2*backoff, r.cfg.MaxRetryBackoff)
+	}
+}
+
+func (r *Remote) call(body []byte, n int) (Scores, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return Scores{}, fmt.Errorf("remote scorer: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return Scores{}, fmt.Errorf("remote scorer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Scores{}, fmt.Errorf("remote scorer: status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Scores{}, fmt.Errorf("%w: status %d", errRemoteRejected, resp.StatusCode)
+	}
+
+	var out remoteResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteResponseLength)).Decode(&out); err != nil {
+		return Scores{}, fmt.Errorf("remote scorer: decode response: %w", err)
+	}
+	if len(out.Scores) != n {
+		return Scores{}, fmt.Errorf("%w: %d scores for %d inputs", errRemoteRejected, len(out.Scores), n)
+	}
+
+	return Scores{Values: out.Scores, Version: cmp.Or(out.Version, BackendRemote)}, nil
+}
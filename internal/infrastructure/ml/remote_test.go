@@ -0,0 +1,262 @@
+package ml
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// modelServer - httptest stand-in of the model server, scores 2*raw
+// unless fail says otherwise for the n-th request(from 1).
+type modelServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	mu       sync.Mutex
+	batches  [][]Input
+	fail     func(n int32) int
+	delay    time.Duration
+}
+
+func newModelServer(t *testing.T) *modelServer {
+	t.Helper()
+	ms := &modelServer{fail: func(int32) int { return 0 }}
+	ms.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := ms.requests.Add(1)
+		var req remoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ms.mu.Lock()
+		ms.batches = append(ms.batches, req.Inputs)
+		ms.mu.Unlock()
+
+		time.Sleep(ms.delay)
+		if code := ms.fail(n); code != 0 {
+			w.WriteHeader(code)
+			return
+		}
+		resp := remoteResponse{Scores: make([]float64, len(req.Inputs)), Version: "srv-2"}
+		for i, in := range req.Inputs {
+			resp.Scores[i] = 2 * in.RawMetric
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ms.Close)
+
+	return ms
+}
+
+func newTestRemoteMetrics() RemoteMetrics {
+	return RemoteMetrics{
+		Requests:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total"}, []string{"result"}),
+		Fallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_fallbacks_total"}, []string{"reason"}),
+	}
+}
+
+func newTestRemote(t *testing.T, url string, cfg RemoteConfig) *Remote {
+	t.Helper()
+	cfg.URL = url
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 200 * time.Millisecond
+	}
+	cfg.RetryBackoff = time.Millisecond
+	// the fallback scores raw+1000, easy to tell apart
+	r, err := NewRemote(zap.NewNop(), cfg, &Models{version: "local-1", def: Linear{Weight: 1, Bias: 1000}}, newTestRemoteMetrics())
+	require.NoError(t, err)
+
+	return r
+}
+
+func scoreOne(t *testing.T, r *Remote, raw float64) float64 {
+	t.Helper()
+	scores, err := r.ScoreBatch(context.Background(), []Input{{TalentID: "t1", Skill: "pass", RawMetric: raw}})
+	require.NoError(t, err)
+	require.Len(t, scores.Values, 1)
+	if scores.Values[0] >= 1000 {
+		require.Equal(t, "local-1", scores.Version)
+	} else {
+		require.Equal(t, "srv-2", scores.Version)
+	}
+
+	return scores.Values[0]
+}
+
+func TestRemote_Batching(t *testing.T) {
+	srv := newModelServer(t)
+	r := newTestRemote(t, srv.URL, RemoteConfig{BatchSize: 10, Linger: 50 * time.Millisecond})
+
+	// 10 concurrent callers fill one batch
+	var wg sync.WaitGroup
+	got := make([]float64, 10)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i] = scoreOne(t, r, float64(i))
+		}()
+	}
+	wg.Wait()
+
+	for i, score := range got {
+		require.Equal(t, 2*float64(i), score)
+	}
+	require.EqualValues(t, 1, srv.requests.Load())
+	require.Len(t, srv.batches[0], 10)
+
+	// a lonely call goes after the linger
+	require.Equal(t, 14.0, scoreOne(t, r, 7))
+	require.EqualValues(t, 2, srv.requests.Load())
+}
+
+func TestRemote_SplitsBigBatches(t *testing.T) {
+	srv := newModelServer(t)
+	r := newTestRemote(t, srv.URL, RemoteConfig{BatchSize: 4})
+
+	in := make([]Input, 10)
+	for i := range in {
+		in[i] = Input{RawMetric: float64(i)}
+	}
+	scores, err := r.ScoreBatch(context.Background(), in)
+	require.NoError(t, err)
+	require.Len(t, scores.Values, 10)
+	require.Equal(t, "srv-2", scores.Version)
+	for i, score := range scores.Values {
+		require.Equal(t, 2*float64(i), score)
+	}
+	// a call is never split
+	require.EqualValues(t, 1, srv.requests.Load())
+}
+
+func TestRemote_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		fail         func(n int32) int
+		retries      int
+		want         float64
+		wantRequests int32
+	}{
+		{"Recovers after 5xx", func(n int32) int {
+			if n < 3 {
+				return http.StatusServiceUnavailable
+			}
+			return 0
+		}, 2, 10, 3},
+		{"Gives up after retries", func(int32) int { return http.StatusInternalServerError }, 2, 1005, 3},
+		{"Retries 429", func(n int32) int {
+			if n == 1 {
+				return http.StatusTooManyRequests
+			}
+			return 0
+		}, 1, 10, 2},
+		{"No retries of 4xx", func(int32) int { return http.StatusBadRequest }, 3, 1005, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newModelServer(t)
+			srv.fail = tt.fail
+			r := newTestRemote(t, srv.URL, RemoteConfig{Retries: tt.retries})
+
+			require.Equal(t, tt.want, scoreOne(t, r, 5))
+			require.Equal(t, tt.wantRequests, srv.requests.Load())
+		})
+	}
+}
+
+func TestRemote_TimeoutFallsBack(t *testing.T) {
+	srv := newModelServer(t)
+	srv.delay = 100 * time.Millisecond
+	r := newTestRemote(t, srv.URL, RemoteConfig{Timeout: 10 * time.Millisecond})
+
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.metrics.Fallbacks.WithLabelValues(FallbackError)))
+}
+
+func TestRemote_UnreachableFallsBack(t *testing.T) {
+	srv := newModelServer(t)
+	srv.Close()
+	r := newTestRemote(t, srv.URL, RemoteConfig{Retries: 1})
+
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+}
+
+func TestRemote_WrongNumberOfScores(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"scores": [1, 2]}`))
+	}))
+	defer srv.Close()
+	r := newTestRemote(t, srv.URL, RemoteConfig{Retries: 3})
+
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.metrics.Requests.WithLabelValues("error")))
+}
+
+func TestRemote_CircuitBreaker(t *testing.T) {
+	srv := newModelServer(t)
+	var down atomic.Bool
+	down.Store(true)
+	srv.fail = func(int32) int {
+		if down.Load() {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	r := newTestRemote(t, srv.URL, RemoteConfig{BreakerFailures: 2, BreakerCooldown: time.Minute})
+	now := time.Now()
+	r.breaker.now = func() time.Time { return now }
+
+	// two failures open the breaker
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+	require.Equal(t, breakerOpen, r.breaker.current())
+	require.EqualValues(t, 2, srv.requests.Load())
+
+	// open - the server is not called at all
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+	require.EqualValues(t, 2, srv.requests.Load())
+	require.Equal(t, 1.0, testutil.ToFloat64(r.metrics.Fallbacks.WithLabelValues(FallbackOpen)))
+
+	// after the cooldown a failed probe reopens it
+	now = now.Add(time.Minute)
+	require.Equal(t, 1005.0, scoreOne(t, r, 5))
+	require.EqualValues(t, 3, srv.requests.Load())
+	require.Equal(t, breakerOpen, r.breaker.current())
+
+	// a successful probe closes it
+	down.Store(false)
+	now = now.Add(time.Minute)
+	require.Equal(t, 10.0, scoreOne(t, r, 5))
+	require.Equal(t, breakerClosed, r.breaker.current())
+	require.Equal(t, 12.0, scoreOne(t, r, 6))
+}
+
+func TestRemote_CanceledCallerFallsBack(t *testing.T) {
+	srv := newModelServer(t)
+	srv.delay = 100 * time.Millisecond
+	r := newTestRemote(t, srv.URL, RemoteConfig{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	scores, err := r.ScoreBatch(ctx, []Input{{RawMetric: 5}})
+	require.NoError(t, err)
+	require.Equal(t, Scores{Values: []float64{1005}, Version: "local-1"}, scores)
+	require.Equal(t, 1.0, testutil.ToFloat64(r.metrics.Fallbacks.WithLabelValues(FallbackCanceled)))
+}
+
+func TestRemote_InvalidConfig(t *testing.T) {
+	_, err := NewRemote(zap.NewNop(), RemoteConfig{}, IdentityModels(), newTestRemoteMetrics())
+	require.Error(t, err)
+	_, err = NewRemote(zap.NewNop(), RemoteConfig{URL: "http://localhost"}, nil, newTestRemoteMetrics())
+	require.Error(t, err)
+}
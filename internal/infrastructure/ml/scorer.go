@@ -2,66 +2,302 @@ package ml
 
 import (
 	"context"
+	"fmt"
 	"leaderboard-api/internal/domain/event"
-	"math/rand/v2"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
-// bufferSize
-// Good to have +/- same size as writers.
-// - Reducing the blocking of writers
-// - Aligning producer/consumer speeds(like in streaming)
-// "Rely on metrics, not guesses."
-var bufferSize = 1000
+const (
+	BackendLocal  = "local"
+	BackendRemote = "remote"
+
+	defaultBatchSize = 32
+
+	// scoreRetryBackoff - the first wait before a failed batch is scored again,
+	// doubled by every failure up to maxScoreRetryBackoff.
+	scoreRetryBackoff    = 50 * time.Millisecond
+	maxScoreRetryBackoff = 5 * time.Second
+)
 
 type OutputChan = chan event.Event
 
+// Input - what a model knows about an event.
+type Input struct {
+	TalentID  string  `json:"talent_id"`
+	Skill     string  `json:"skill"`
+	RawMetric float64 `json:"raw_metric"`
+}
+
+// Scores - a score per input in the same order and the version of the models.
+type Scores struct {
+	Values  []float64
+	Version string
+}
+
+// Backend - scores a batch of events. *Models, *Registry or *Remote.
+type Backend interface {
+	ScoreBatch(ctx context.Context, in []Input) (Scores, error)
+}
+
+// Config - a worker scores up to BatchSize events in one backend call,
+// it waits up to Linger for a batch to fill, 0 - takes only what is already queued.
+type Config struct {
+	BatchSize int
+	Linger    time.Duration
+	Queue     QueueConfig
+}
+
+// Metrics - sizes and durations of the scored batches, the pool
+// settings(param "workers", "batch_size", "linger_seconds", "queue_size")
+// and the state of the queues.
+type Metrics struct {
+	BatchSize     prometheus.Histogram
+	BatchDuration prometheus.Histogram
+	Pool          *prometheus.GaugeVec
+	QueueDepth    *prometheus.GaugeVec
+	QueueWait     *prometheus.GaugeVec
+	Shed          *prometheus.CounterVec
+}
+
 type Scorer struct {
 	in  chan event.Event
 	out OutputChan
-	log *zap.Logger
+	// slots - room reserved in `in`, an event is sent to `in` only with a slot,
+	// so the send never blocks and the shedding is decided before the event is logged.
+	slots  chan struct{}
+	shedAt int
+	// closeMu - senders hold it for reading, so `in` is never closed under a send.
+	closeMu sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+	// abort - stops the workers when the drain is out of time.
+	abort   context.CancelFunc
+	inWait  maxWait
+	outWait maxWait
+	log     *zap.Logger
+	backend Backend
+	cfg     Config
+	metrics Metrics
 }
 
-func New(ctx context.Context, log *zap.Logger) *Scorer {
+// New - nil backend scores every event by its raw metric.
+func New(ctx context.Context, log *zap.Logger, backend Backend, cfg Config, metrics Metrics) (*Scorer, error) {
+	if backend == nil {
+		backend = IdentityModels()
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Linger < 0 {
+		cfg.Linger = 0
+	}
+	if cfg.Queue.Size <= 0 {
+		cfg.Queue.Size = defaultQueueSize
+	}
+	if cfg.Queue.Policy == "" {
+		cfg.Queue.Policy = PolicyWait
+	}
+	if err := cfg.Queue.validate(); err != nil {
+		return nil, fmt.Errorf("scorer: %w", err)
+	}
 	sc := &Scorer{
-		in:  make(chan event.Event, bufferSize),
-		out: make(OutputChan, bufferSize),
-		log: log,
+		in:      make(chan event.Event, cfg.Queue.Size),
+		out:     make(OutputChan, cfg.Queue.Size),
+		slots:   make(chan struct{}, cfg.Queue.Size),
+		log:     log,
+		backend: backend,
+		cfg:     cfg,
+		metrics: metrics,
+	}
+	if cfg.Queue.ShedAt > 0 && cfg.Queue.ShedAt < 1 {
+		sc.shedAt = max(int(cfg.Queue.ShedAt*float64(cfg.Queue.Size)), 1)
 	}
+	metrics.Pool.WithLabelValues("batch_size").Set(float64(cfg.BatchSize))
+	metrics.Pool.WithLabelValues("linger_seconds").Set(cfg.Linger.Seconds())
+	metrics.Pool.WithLabelValues("queue_size").Set(float64(cfg.Queue.Size))
 
-	return sc
+	return sc, nil
 }
 
 // RunScorerPool - We are using "WorkerPool" concurrency pattern
 // to create a pool of parallel processes then "Fan-In" pattern
 // to send results into one channel.
+// The workers outlive ctx, they drain the queue until ClosePool.
 func (s *Scorer) RunScorerPool(ctx context.Context, size int) {
-	s.log.Info("starting ml pool")
+	size = max(size, 1)
+	s.log.Info("starting ml pool", zap.Int("workers", size), zap.Int("batch_size", s.cfg.BatchSize), zap.Duration("linger", s.cfg.Linger))
+	s.metrics.Pool.WithLabelValues("workers").Set(float64(size))
+
+	workerCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	s.abort = abort
+	s.workers.Add(size)
 	for i := 0; i < size; i++ {
-		go s.worker(ctx)
+		go func() {
+			defer s.workers.Done()
+			s.worker(workerCtx)
+		}()
 	}
+	go s.reportQueues(ctx)
 }
 
-func (s *Scorer) ClosePool(ctx context.Context) {
+// ClosePool - refuses new events, waits for the workers to score the queued ones
+// and closes the output, so its reader gets every scored event and stops.
+// When ctx is done first the rest of the queue is dropped(the logged events
+// are replayed on the next start).
+func (s *Scorer) ClosePool(ctx context.Context) error {
+	s.closeMu.Lock()
+	if s.closed {
+		s.closeMu.Unlock()
+		return nil
+	}
+	s.closed = true
 	close(s.in)
+	s.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		if s.abort != nil {
+			s.abort()
+		}
+		<-drained
+		err = fmt.Errorf("scorer drain: %w, %d events left in the queue", ctx.Err(), len(s.in))
+	}
+	if s.abort != nil {
+		s.abort()
+	}
+	// no worker sends anymore
 	close(s.out)
+
+	if err != nil {
+		return err
+	}
 	s.log.Info("ml scorer pool gracefully stopped")
+	return nil
 }
 
+// worker - "Micro-batching": waits for the first event, then collects
+// more until the batch is full or the linger is over.
 func (s *Scorer) worker(ctx context.Context) {
+	batch := make([]event.Event, 0, s.cfg.BatchSize)
+	linger := time.NewTimer(s.cfg.Linger)
+	linger.Stop()
+
 	for evnt := range s.in {
-		evnt.Score = s.score(ctx, evnt.RawMetric, evnt.Skill)
-		s.out <- evnt
+		<-s.slots
+		if ctx.Err() != nil {
+			return
+		}
+		batch = append(batch[:0], evnt)
+		if s.cfg.Linger > 0 {
+			linger.Reset(s.cfg.Linger)
+		}
+		batch = s.collect(batch, linger.C)
+		linger.Stop()
+
+		if !s.scoreRetrying(ctx, batch) {
+			return
+		}
+
+		start := time.Now()
+		for _, e := range batch {
+			select {
+			case s.out <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+		s.outWait.observe(time.Since(start))
+	}
+}
+
+// scoreRetrying - the events are accepted(logged, deduped) already, a failed
+// batch is never dropped, it's scored again with backoff until the pool is aborted.
+// The aborted events are replayed from the event log on the next start.
+func (s *Scorer) scoreRetrying(ctx context.Context, batch []event.Event) bool {
+	backoff := scoreRetryBackoff
+	for {
+		start := time.Now()
+		err := s.Score(ctx, batch)
+		s.metrics.BatchDuration.Observe(time.Since(start).Seconds())
+		s.metrics.BatchSize.Observe(float64(len(batch)))
+		if err == nil {
+			return true
+		}
+		s.log.Error("scoring failed, batch retried", zap.Int("events", len(batch)), zap.Duration("backoff", backoff), zap.Error(err))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return false
+		}
+		backoff = min(2*backoff, maxScoreRetryBackoff)
 	}
 }
 
-// todo: What is really happening in ML process ??
-func (s *Scorer) score(ctx context.Context, metric float64, skill string) float64 {
-	time.Sleep(time.Duration(rand.IntN(150-80+1)+80) * time.Millisecond)
-	return rand.Float64()*(200.0-0.0) + 0.0
+// Score - sets Score and ModelVersion of the events by one backend call.
+func (s *Scorer) Score(ctx context.Context, events []event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	in := make([]Input, len(events))
+	for i, e := range events {
+		in[i] = Input{TalentID: e.TalentID, Skill: e.Skill, RawMetric: e.RawMetric}
+	}
+	scores, err := s.backend.ScoreBatch(ctx, in)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		events[i].Score = scores.Values[i]
+		events[i].ModelVersion = scores.Version
+	}
+
+	return nil
+}
+
+// collect - adds queued events to the batch, waits for them
+// until the linger fires only if the linger is set.
+func (s *Scorer) collect(batch []event.Event, linger <-chan time.Time) []event.Event {
+	for len(batch) < s.cfg.BatchSize {
+		if s.cfg.Linger == 0 {
+			select {
+			case e, ok := <-s.in:
+				if !ok {
+					return batch
+				}
+				<-s.slots
+				batch = append(batch, e)
+			default:
+				return batch
+			}
+			continue
+		}
+
+		select {
+		case e, ok := <-s.in:
+			if !ok {
+				return batch
+			}
+			<-s.slots
+			batch = append(batch, e)
+		case <-linger:
+			return batch
+		}
+	}
+
+	return batch
 }
 
-func (s *Scorer) GetInputChan() chan event.Event { return s.in }
-func (s *Scorer) GetOutChan() OutputChan         { return s.out }
+func (s *Scorer) GetOutChan() OutputChan { return s.out }
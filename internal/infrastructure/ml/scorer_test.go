@@ -2,15 +2,94 @@ package ml
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"leaderboard-api/internal/domain/event"
 )
 
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func newTestModels(t *testing.T) *Models {
+	t.Helper()
+	ms, err := LoadModels(filepath.Join("testdata", "models.json"))
+	require.NoError(t, err)
+
+	return ms
+}
+
+func newTestMetrics() Metrics {
+	return Metrics{
+		BatchSize:     prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_batch_size"}),
+		BatchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_batch_duration_seconds"}),
+		Pool:          prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_pool"}, []string{"param"}),
+		QueueDepth:    prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_queue_depth"}, []string{"queue"}),
+		QueueWait:     prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_queue_wait_seconds"}, []string{"queue"}),
+		Shed:          prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_shed_events_total"}, []string{"reason"}),
+	}
+}
+
+func newTestScorer(t *testing.T, backend Backend, cfg Config, metrics Metrics) *Scorer {
+	t.Helper()
+	sc, err := New(context.Background(), zap.NewNop(), backend, cfg, metrics)
+	require.NoError(t, err)
+
+	return sc
+}
+
+// batchBackend - remembers the size of every batch, scores raw+1.
+type batchBackend struct {
+	mu      sync.Mutex
+	batches []int
+}
+
+func (b *batchBackend) ScoreBatch(_ context.Context, in []Input) (Scores, error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, len(in))
+	b.mu.Unlock()
+
+	scores := make([]float64, len(in))
+	for i, x := range in {
+		scores[i] = x.RawMetric + 1
+	}
+	return Scores{Values: scores, Version: "batch"}, nil
+}
+
+func (b *batchBackend) sizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return slices.Clone(b.batches)
+}
+
+func receive(t *testing.T, sc *Scorer, n int) []event.Event {
+	t.Helper()
+	out := make([]event.Event, 0, n)
+	for range n {
+		select {
+		case e := <-sc.GetOutChan():
+			out = append(out, e)
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for scored event %d", len(out)+1)
+		}
+	}
+
+	return out
+}
+
 func TestScorer(t *testing.T) {
 	t.Parallel()
 
@@ -19,21 +98,22 @@ func TestScorer(t *testing.T) {
 		metric     float64
 		skill      string
 		workerSize int
+		want       float64
 	}{
-		{"Basic test A", 42.0, "dribble", 1},
-		{"Basic test B", 95.3, "jump", 3},
-		{"Basic test C", 10.0, "shoot", 2},
+		{"Linear", 42.0, "dribble", 1, 68},
+		{"Default", 95.3, "jump", 3, 95.3},
+		{"Piecewise", 60.0, "pass", 2, 105},
+		{"GBT", 10.0, "shoot", 2, 15},
 	}
 
+	models := newTestModels(t)
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
 			ctx := context.Background()
-			logger := zap.NewNop()
-
-			sc := New(ctx, logger)
+			sc := newTestScorer(t, models, Config{}, newTestMetrics())
 
 			sc.RunScorerPool(ctx, tt.workerSize)
 
@@ -42,14 +122,13 @@ func TestScorer(t *testing.T) {
 				Skill:     tt.skill,
 			}
 
-			sc.GetInputChan() <- ev
+			require.NoError(t, sc.Push(ctx, ev))
 
 			select {
 			case out := <-sc.GetOutChan():
 				require.InDelta(t, tt.metric, out.RawMetric, 0.001)
 				require.Equal(t, tt.skill, out.Skill)
-				require.GreaterOrEqual(t, out.Score, 0.0)
-				require.LessOrEqual(t, out.Score, 200.0)
+				require.InDelta(t, tt.want, out.Score, 1e-9)
 			case <-time.After(1 * time.Second):
 				t.Fatal("Timeout waiting for scored event")
 			}
@@ -58,3 +137,175 @@ func TestScorer(t *testing.T) {
 		})
 	}
 }
+
+// TestModels_Golden - go test ./internal/infrastructure/ml -run Golden -update
+// rewrites testdata/scores.golden after an intended change of a model.
+func TestModels_Golden(t *testing.T) {
+	models := newTestModels(t)
+
+	var b strings.Builder
+	for _, skill := range []string{"dribble", "jump", "pass", "shoot"} {
+		for _, raw := range []float64{-10, 0, 10, 19.99, 20, 35, 40, 50, 64.5, 65, 79, 80, 95, 99.5, 100, 250} {
+			fmt.Fprintf(&b, "%s\t%g\t%s\t%.6f\n", skill, raw, models.For(skill).Name(), models.Score(skill, raw))
+		}
+	}
+
+	golden := filepath.Join("testdata", "scores.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, []byte(b.String()), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(want), b.String())
+}
+
+func TestScorer_IdentityByDefault(t *testing.T) {
+	ctx := context.Background()
+	sc := newTestScorer(t, nil, Config{}, newTestMetrics())
+	sc.RunScorerPool(ctx, 1)
+	defer sc.ClosePool(ctx)
+
+	require.NoError(t, sc.Push(ctx, event.Event{RawMetric: 42.5, Skill: "any"}))
+	out := receive(t, sc, 1)[0]
+	require.Equal(t, 42.5, out.Score)
+	require.Equal(t, versionIdentity, out.ModelVersion)
+}
+
+func TestScorer_Batching(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		events int
+		// gap - between the events
+		gap  time.Duration
+		want []int
+	}{
+		{"Queued events, no linger", Config{BatchSize: 4}, 10, 0, []int{4, 4, 2}},
+		{"Full batch doesn't wait for the linger", Config{BatchSize: 5, Linger: time.Hour}, 5, 0, []int{5}},
+		{"Linger collects late events", Config{BatchSize: 100, Linger: 200 * time.Millisecond}, 3, 10 * time.Millisecond, []int{3}},
+		{"Events after the linger go in the next batch", Config{BatchSize: 100, Linger: time.Millisecond}, 2, 100 * time.Millisecond, []int{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			backend := &batchBackend{}
+			mtr := newTestMetrics()
+			sc := newTestScorer(t, backend, tt.cfg, mtr)
+			if tt.gap == 0 {
+				// all of them are queued before the worker starts
+				for i := range tt.events {
+					require.NoError(t, sc.Push(ctx, event.Event{TalentID: strconv.Itoa(i), RawMetric: float64(i)}))
+				}
+				sc.RunScorerPool(ctx, 1)
+			} else {
+				sc.RunScorerPool(ctx, 1)
+				for i := range tt.events {
+					require.NoError(t, sc.Push(ctx, event.Event{TalentID: strconv.Itoa(i), RawMetric: float64(i)}))
+					time.Sleep(tt.gap)
+				}
+			}
+
+			out := receive(t, sc, tt.events)
+			for i, e := range out {
+				// one worker keeps the order
+				require.Equal(t, strconv.Itoa(i), e.TalentID)
+				require.Equal(t, float64(i)+1, e.Score)
+				require.Equal(t, "batch", e.ModelVersion)
+			}
+			require.Equal(t, tt.want, backend.sizes())
+			require.Equal(t, float64(tt.cfg.BatchSize), testutil.ToFloat64(mtr.Pool.WithLabelValues("batch_size")))
+			require.Equal(t, 1.0, testutil.ToFloat64(mtr.Pool.WithLabelValues("workers")))
+
+			sc.ClosePool(ctx)
+		})
+	}
+}
+
+func TestPiecewise(t *testing.T) {
+	p, err := NewPiecewise([]Point{{0, 0}, {10, 100}, {20, 50}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		raw  float64
+		want float64
+	}{
+		{-5, 0},
+		{0, 0},
+		{5, 50},
+		{10, 100},
+		{15, 75},
+		{20, 50},
+		{30, 50},
+	}
+	for _, tt := range tests {
+		require.InDelta(t, tt.want, p.Score(tt.raw), 1e-9, "raw %v", tt.raw)
+	}
+}
+
+func TestLoadModels_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"Not json", map[string]string{"models.json": `{`}, "parse models"},
+		{"Unknown type", map[string]string{"models.json": `{"default": {"type": "magic"}}`}, `unknown model type "magic"`},
+		{"No default", map[string]string{"models.json": `{"skills": {}}`}, "default model"},
+		{"Unsorted points", map[string]string{
+			"models.json": `{"default": {"type": "linear"}, "skills": {"pass": {"type": "piecewise", "points": [{"x": 2}, {"x": 1}]}}}`,
+		}, "sorted by x"},
+		{"Gbt without path", map[string]string{
+			"models.json": `{"default": {"type": "gbt"}}`,
+		}, "without a path"},
+		{"Gbt missing file", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "missing.json"}}`,
+		}, "read gbt model"},
+		{"Gbt unknown feature", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "m.json"}}`,
+			"m.json":      `{"features": ["age"], "trees": [{"nodes": [{"leaf": 1}]}]}`,
+		}, `unknown feature "age"`},
+		{"Gbt feature twice", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "m.json"}}`,
+			"m.json":      `{"features": ["raw_metric", "raw_metric"], "trees": [{"nodes": [{"feature": 1, "left": 1, "right": 2}, {"leaf": 1}, {"leaf": 2}]}]}`,
+		}, "exactly one"},
+		{"Gbt no features", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "m.json"}}`,
+			"m.json":      `{"trees": [{"nodes": [{"leaf": 1}]}]}`,
+		}, "exactly one"},
+		{"Gbt cycle", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "m.json"}}`,
+			"m.json":      `{"features": ["raw_metric"], "trees": [{"nodes": [{"feature": 0, "left": 0, "right": 1}, {"leaf": 1}]}]}`,
+		}, "invalid child 0"},
+		{"Gbt zero learning rate", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "m.json"}}`,
+			"m.json":      `{"learning_rate": 0, "features": ["raw_metric"], "trees": [{"nodes": [{"leaf": 1}]}]}`,
+		}, "learning_rate 0"},
+		{"Gbt no trees", map[string]string{
+			"models.json": `{"default": {"type": "gbt", "path": "m.json"}}`,
+			"m.json":      `{"features": ["raw_metric"]}`,
+		}, "no trees"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644))
+			}
+			_, err := LoadModels(filepath.Join(dir, "models.json"))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadGBT_DefaultLearningRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "m.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base_score": 1, "features": ["raw_metric"], "trees": [{"nodes": [
+		{"feature": 0, "threshold": 10, "left": 1, "right": 2}, {"leaf": 2}, {"leaf": 5}]}]}`), 0o644))
+
+	m, err := LoadGBT(path)
+	require.NoError(t, err)
+	require.Equal(t, 3.0, m.Score(5))
+	require.Equal(t, 6.0, m.Score(50))
+}
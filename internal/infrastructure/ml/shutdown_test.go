@@ -0,0 +1,73 @@
+package ml
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+// stuckBackend - never answers until the call is canceled.
+type stuckBackend struct{}
+
+func (stuckBackend) ScoreBatch(ctx context.Context, _ []Input) (Scores, error) {
+	<-ctx.Done()
+	return Scores{}, ctx.Err()
+}
+
+// flakyBackend - fails the first calls, then scores raw+1.
+type flakyBackend struct {
+	failures atomic.Int32
+}
+
+func (b *flakyBackend) ScoreBatch(_ context.Context, in []Input) (Scores, error) {
+	if b.failures.Add(-1) >= 0 {
+		return Scores{}, errors.New("model is down")
+	}
+	scores := make([]float64, len(in))
+	for i, x := range in {
+		scores[i] = x.RawMetric + 1
+	}
+	return Scores{Values: scores}, nil
+}
+
+func TestScorer_RetriesFailedBatch(t *testing.T) {
+	ctx := context.Background()
+	backend := &flakyBackend{}
+	backend.failures.Store(2)
+	sc := newTestScorer(t, backend, Config{BatchSize: 1}, newTestMetrics())
+	require.NoError(t, sc.Push(ctx, event.Event{TalentID: "t1", RawMetric: 1}))
+	sc.RunScorerPool(ctx, 1)
+
+	// accepted events are never dropped
+	e := receive(t, sc, 1)[0]
+	require.Equal(t, "t1", e.TalentID)
+	require.Equal(t, 2.0, e.Score)
+	require.NoError(t, sc.ClosePool(ctx))
+}
+
+func TestScorer_DrainTimeout(t *testing.T) {
+	ctx := context.Background()
+	sc := newTestScorer(t, stuckBackend{}, Config{BatchSize: 1}, newTestMetrics())
+	sc.RunScorerPool(ctx, 1)
+	for range 3 {
+		require.NoError(t, sc.Push(ctx, event.Event{RawMetric: 1}))
+	}
+
+	drainCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, sc.ClosePool(drainCtx), context.DeadlineExceeded)
+
+	// the output is closed anyway, its reader stops
+	_, ok := <-sc.GetOutChan()
+	require.False(t, ok)
+
+	require.ErrorIs(t, sc.Submit(ctx, event.Event{}, nil), event.ErrQueueClosed)
+	require.ErrorIs(t, sc.Push(ctx, event.Event{}), event.ErrQueueClosed)
+	require.NoError(t, sc.ClosePool(ctx))
+}
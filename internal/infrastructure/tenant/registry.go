@@ -0,0 +1,423 @@
+package tenant
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/board"
+	"leaderboard-api/internal/infrastructure/cache"
+	"leaderboard-api/internal/infrastructure/leaderboard"
+	"leaderboard-api/internal/infrastructure/ml"
+	"leaderboard-api/internal/infrastructure/wal"
+)
+
+// boardsFile - the definitions of the boards in Config.Dir.
+const boardsFile = "boards.json"
+
+// Config - every board runs the pipeline of the default one with these settings,
+// its data is in "<Dir>/<board>/": the snapshots and the event log(if WAL.Dir is set).
+// Empty Dir keeps the boards in memory only, they are gone after a restart.
+type Config struct {
+	Dir string
+	// Aggregation, AvgLast, DecayHalfLife, TieBreak, RankType - of a board that doesn't set them.
+	Aggregation   string
+	AvgLast       int
+	DecayHalfLife time.Duration
+	TieBreak      string
+	RankType      string
+	// Leaderboard - the window and snapshot settings, the rest is per board.
+	Leaderboard leaderboard.Config
+	Cache       cache.Config
+	// NewStore - the dedup store of a board, nil - memory only.
+	NewStore func(board string) cache.Store
+	// WAL - the event log settings, an empty WAL.Dir turns it off.
+	WAL     wal.Config
+	Compact bool
+	Scorer  ml.Config
+	Workers int
+}
+
+// Metrics - the metrics of the default board with the "board" label first.
+type Metrics struct {
+	Events              *prometheus.CounterVec
+	Skill               *prometheus.CounterVec
+	CacheEvictions      *prometheus.CounterVec
+	CacheSize           *prometheus.GaugeVec
+	ModelInfo           *prometheus.GaugeVec
+	ScorerBatchSize     *prometheus.HistogramVec
+	ScorerBatchDuration *prometheus.HistogramVec
+	ScorerPool          *prometheus.GaugeVec
+	ScorerQueueDepth    *prometheus.GaugeVec
+	ScorerQueueWait     *prometheus.GaugeVec
+	ScorerShed          *prometheus.CounterVec
+}
+
+// forget - removes the series of a deleted board.
+func (m Metrics) forget(name string) {
+	labels := prometheus.Labels{"board": name}
+	for _, vec := range []interface {
+		DeletePartialMatch(prometheus.Labels) int
+	}{
+		m.Events, m.Skill, m.CacheEvictions, m.CacheSize, m.ModelInfo, m.ScorerBatchSize,
+		m.ScorerBatchDuration, m.ScorerPool, m.ScorerQueueDepth, m.ScorerQueueWait, m.ScorerShed,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// Registry - the named boards. The definitions are loaded by Open,
+// the pipelines run from Start till Stop.
+type Registry struct {
+	log     *zap.Logger
+	cfg     Config
+	metrics Metrics
+	now     func() time.Time
+
+	mu      sync.RWMutex
+	defs    map[string]board.Board
+	tenants map[string]*tenant
+	// deleting - the boards being drained, their names are not free yet.
+	deleting map[string]bool
+	// creating - the boards being started, their names are taken already.
+	creating map[string]bool
+	// ctx - of Start, the workers of every board live with it.
+	ctx    context.Context
+	closed bool
+}
+
+func Open(log *zap.Logger, cfg Config, metrics Metrics) (*Registry, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	r := &Registry{
+		log:      log,
+		cfg:      cfg,
+		metrics:  metrics,
+		now:      time.Now,
+		defs:     make(map[string]board.Board),
+		tenants:  make(map[string]*tenant),
+		deleting: make(map[string]bool),
+		creating: make(map[string]bool),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Start - runs the pipelines of the loaded boards, each restored from its snapshot.
+// A board that fails to start is not served, the others are.
+func (r *Registry) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ctx = ctx
+	var errs []error
+	for _, b := range r.defs {
+		t, err := r.start(ctx, b)
+		if err != nil {
+			r.metrics.forget(b.Name)
+			errs = append(errs, fmt.Errorf("board %s: %w", b.Name, err))
+			continue
+		}
+		r.tenants[b.Name] = t
+	}
+	r.log.Info("boards started", zap.Int("boards", len(r.tenants)))
+
+	return errors.Join(errs...)
+}
+
+// Stop - drains every board at once, until ctx is done.
+func (r *Registry) Stop(ctx context.Context) {
+	r.mu.Lock()
+	r.closed = true
+	tenants := r.tenants
+	r.tenants = make(map[string]*tenant)
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, t := range tenants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.stop(ctx)
+		}()
+	}
+	wg.Wait()
+	r.log.Info("boards gracefully stopped", zap.Int("boards", len(tenants)))
+}
+
+func (r *Registry) Create(b board.Board) (board.Board, error) {
+	if err := b.Validate(); err != nil {
+		return board.Board{}, err
+	}
+	b.Aggregation = cmp.Or(b.Aggregation, r.cfg.Aggregation)
+	b.AvgLast = cmp.Or(b.AvgLast, r.cfg.AvgLast)
+	b.DecayHalfLife = cmp.Or(b.DecayHalfLife, r.cfg.DecayHalfLife)
+	b.TieBreak = cmp.Or(b.TieBreak, r.cfg.TieBreak)
+	b.RankType = cmp.Or(b.RankType, r.cfg.RankType)
+	b.CreatedAt = r.now().UTC()
+	b.UpdatedAt = b.CreatedAt
+
+	// the board is started without the lock: the other boards are served meanwhile
+	r.mu.Lock()
+	if err := r.usable(); err != nil {
+		r.mu.Unlock()
+		return board.Board{}, err
+	}
+	if _, ok := r.defs[b.Name]; ok || r.deleting[b.Name] || r.creating[b.Name] {
+		r.mu.Unlock()
+		return board.Board{}, board.ErrBoardExists
+	}
+	r.creating[b.Name] = true
+	ctx := r.ctx
+	r.mu.Unlock()
+
+	t, err := r.create(ctx, b)
+	if err != nil {
+		r.mu.Lock()
+		delete(r.creating, b.Name)
+		r.mu.Unlock()
+		r.metrics.forget(b.Name)
+		return board.Board{}, err
+	}
+
+	r.mu.Lock()
+	delete(r.creating, b.Name)
+	// usable - Stop may have come meanwhile, the board would outlive the registry
+	if err = r.usable(); err == nil {
+		r.defs[b.Name] = b
+		if err = r.save(); err != nil {
+			delete(r.defs, b.Name)
+		} else {
+			r.tenants[b.Name] = t
+		}
+	}
+	r.mu.Unlock()
+	if err != nil {
+		t.stop(context.Background())
+		r.metrics.forget(b.Name)
+		return board.Board{}, err
+	}
+	r.log.Info("board created", zap.String("board", b.Name), zap.String("aggregation", b.Aggregation))
+
+	return b, nil
+}
+
+// create - starts the pipeline of a new board, the name is reserved by the caller.
+func (r *Registry) create(ctx context.Context, b board.Board) (*tenant, error) {
+	// the leftovers of a board that failed to be deleted
+	if err := r.removeDir(b.Name); err != nil {
+		return nil, err
+	}
+
+	return r.start(ctx, b)
+}
+
+func (r *Registry) SetModels(name string, models json.RawMessage) (board.Board, error) {
+	ms, err := parseModels(models)
+	if err != nil {
+		return board.Board{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.usable(); err != nil {
+		return board.Board{}, err
+	}
+	t, ok := r.tenants[name]
+	if !ok {
+		return board.Board{}, board.ErrBoardNotFound
+	}
+	prev := r.defs[name]
+	b := prev
+	b.Models = models
+	b.UpdatedAt = r.now().UTC()
+	r.defs[name] = b
+	if err := r.save(); err != nil {
+		r.defs[name] = prev
+		return board.Board{}, err
+	}
+	t.models.Set(ms)
+
+	return b, nil
+}
+
+// Delete - the other boards are served while it's drained.
+func (r *Registry) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	if err := r.usable(); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	t, ok := r.tenants[name]
+	if !ok {
+		r.mu.Unlock()
+		return board.ErrBoardNotFound
+	}
+	delete(r.defs, name)
+	if err := r.save(); err != nil {
+		r.defs[name] = t.board
+		r.mu.Unlock()
+		return err
+	}
+	delete(r.tenants, name)
+	r.deleting[name] = true
+	r.mu.Unlock()
+
+	t.stop(ctx)
+	r.metrics.forget(name)
+	err := errors.Join(t.drop(ctx), r.removeDir(name))
+
+	r.mu.Lock()
+	delete(r.deleting, name)
+	r.mu.Unlock()
+	r.log.Info("board deleted", zap.String("board", name))
+
+	return err
+}
+
+func (r *Registry) List() []board.Board {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted()
+}
+
+func (r *Registry) Get(name string) (board.Board, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := r.defs[name]
+	if !ok {
+		return board.Board{}, board.ErrBoardNotFound
+	}
+
+	return b, nil
+}
+
+func (r *Registry) Pipeline(name string) (ports.BoardPipeline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tenants[name]
+	if !ok {
+		return ports.BoardPipeline{}, board.ErrBoardNotFound
+	}
+	p := ports.BoardPipeline{
+		Cache:   t.cache,
+		Scorer:  t.scorer,
+		LB:      t.lb,
+		Seasons: t.lb,
+		Metrics: t.metrics,
+	}
+	if t.wal != nil {
+		p.Log = t.wal
+	}
+
+	return p, nil
+}
+
+// usable - under the lock.
+func (r *Registry) usable() error {
+	if r.ctx == nil || r.closed {
+		return board.ErrBoardsClosed
+	}
+
+	return nil
+}
+
+func (r *Registry) dir(name string) string {
+	if r.cfg.Dir == "" {
+		return ""
+	}
+
+	return filepath.Join(r.cfg.Dir, name)
+}
+
+func (r *Registry) removeDir(name string) error {
+	dir := r.dir(name)
+	if dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("board %s data: %w", name, err)
+	}
+
+	return nil
+}
+
+// boardsJSON - the content of the boards file.
+type boardsJSON struct {
+	Boards []board.Board `json:"boards"`
+}
+
+func (r *Registry) load() error {
+	if r.cfg.Dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(r.cfg.Dir, boardsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read boards: %w", err)
+	}
+	var f boardsJSON
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse boards: %w", err)
+	}
+	for _, b := range f.Boards {
+		r.defs[b.Name] = b
+	}
+
+	return nil
+}
+
+// save - under the lock, written aside and renamed.
+func (r *Registry) save() error {
+	if r.cfg.Dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(boardsJSON{Boards: r.sorted()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("save boards: %w", err)
+	}
+	path := filepath.Join(r.cfg.Dir, boardsFile)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("save boards: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("save boards: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Registry) sorted() []board.Board {
+	bs := make([]board.Board, 0, len(r.defs))
+	for _, b := range r.defs {
+		bs = append(bs, b)
+	}
+	slices.SortFunc(bs, func(a, b board.Board) int { return cmp.Compare(a.Name, b.Name) })
+
+	return bs
+}
@@ -0,0 +1,238 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/board"
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+	"leaderboard-api/internal/infrastructure/cache"
+	"leaderboard-api/internal/infrastructure/leaderboard"
+	"leaderboard-api/internal/infrastructure/wal"
+)
+
+func newTestMetrics() Metrics {
+	return Metrics{
+		Events:              prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"board", "result"}),
+		Skill:               prometheus.NewCounterVec(prometheus.CounterOpts{Name: "skill"}, []string{"board", "skill", "result"}),
+		CacheEvictions:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "evictions"}, []string{"board", "reason"}),
+		CacheSize:           prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "size"}, []string{"board"}),
+		ModelInfo:           prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "model"}, []string{"board", "version"}),
+		ScorerBatchSize:     prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "batch_size"}, []string{"board"}),
+		ScorerBatchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "batch_duration"}, []string{"board"}),
+		ScorerPool:          prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pool"}, []string{"board", "param"}),
+		ScorerQueueDepth:    prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "depth"}, []string{"board", "queue"}),
+		ScorerQueueWait:     prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "wait"}, []string{"board", "queue"}),
+		ScorerShed:          prometheus.NewCounterVec(prometheus.CounterOpts{Name: "shed"}, []string{"board", "reason"}),
+	}
+}
+
+func newTestRegistry(t *testing.T, dir string, metrics Metrics) *Registry {
+	t.Helper()
+	cfg := Config{
+		Dir:         dir,
+		Aggregation: "max",
+		RankType:    "ordinal",
+		Leaderboard: leaderboard.Config{Location: time.UTC, RollingDays: 7},
+		WAL:         wal.Config{Dir: "on"},
+		Compact:     true,
+		Workers:     2,
+	}
+	r, err := Open(zaptest.NewLogger(t), cfg, metrics)
+	require.NoError(t, err)
+	require.NoError(t, r.Start(context.Background()))
+	return r
+}
+
+// submit - through the pipeline of the board, like the event service does.
+func submit(t *testing.T, p ports.BoardPipeline, talent string, raw float64) {
+	t.Helper()
+	e := event.Event{EventID: uuid.New(), TalentID: talent, RawMetric: raw, TS: time.Now()}
+	require.True(t, p.Cache.SetIfAbsent(e.EventID, e.TS))
+	require.NoError(t, p.Scorer.Submit(context.Background(), e, func(e *event.Event) error {
+		seq, err := p.Log.Append(*e)
+		e.Seq = seq
+		return err
+	}))
+}
+
+func top(t *testing.T, r *Registry, name string, want leader.Leaders) {
+	t.Helper()
+	p, err := r.Pipeline(name)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(p.LB.Range(leader.Scope{}, 0, 10).Leaders) == len(want)
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, want, p.LB.Range(leader.Scope{}, 0, 10).Leaders)
+}
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	metrics := newTestMetrics()
+	r := newTestRegistry(t, dir, metrics)
+
+	_, err := r.Create(board.Board{Name: "Bad name"})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+	_, err = r.Create(board.Board{Name: "bad-agg", Aggregation: "median"})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+	_, err = r.Create(board.Board{Name: "bad-rank", RankType: "fractional"})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+	_, err = r.Create(board.Board{Name: "gbt", Models: json.RawMessage(`{"default": {"type": "linear"}, "skills": {"pass": {"type": "gbt", "path": "/etc/passwd"}}}`)})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+	_, err = r.Create(board.Board{Name: "bad-models", Models: json.RawMessage(`{"default": {"type": "cubic"}}`)})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+
+	// the boards are isolated: scoring, aggregation and the dedup namespace
+	doubled, err := r.Create(board.Board{Name: "season-1", Models: json.RawMessage(`{"version": "v1", "default": {"type": "linear", "weight": 2}}`)})
+	require.NoError(t, err)
+	require.Equal(t, "max", doubled.Aggregation)
+	require.Equal(t, "ordinal", doubled.RankType)
+	summed, err := r.Create(board.Board{Name: "season-2", Aggregation: "sum"})
+	require.NoError(t, err)
+	require.Equal(t, "sum", summed.Aggregation)
+	_, err = r.Create(board.Board{Name: "season-1"})
+	require.ErrorIs(t, err, board.ErrBoardExists)
+	require.Equal(t, []board.Board{doubled, summed}, r.List())
+
+	s1, err := r.Pipeline("season-1")
+	require.NoError(t, err)
+	s2, err := r.Pipeline("season-2")
+	require.NoError(t, err)
+	submit(t, s1, "t1", 10)
+	submit(t, s1, "t1", 5)
+	submit(t, s2, "t1", 10)
+	submit(t, s2, "t1", 5)
+	top(t, r, "season-1", leader.Leaders{{Rank: 1, TalentID: "t1", Score: 20}})
+	top(t, r, "season-2", leader.Leaders{{Rank: 1, TalentID: "t1", Score: 15}})
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.ModelInfo.WithLabelValues("season-1", "v1")))
+
+	// the new events only
+	_, err = r.SetModels("season-1", json.RawMessage(`{"version": "v2", "default": {"type": "linear", "weight": 10}}`))
+	require.NoError(t, err)
+	submit(t, s1, "t2", 3)
+	top(t, r, "season-1", leader.Leaders{{Rank: 1, TalentID: "t2", Score: 30}, {Rank: 2, TalentID: "t1", Score: 20}})
+	_, err = r.SetModels("unknown", nil)
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+
+	// "restart": the definitions and the boards are restored
+	r.Stop(context.Background())
+	_, err = r.Create(board.Board{Name: "late"})
+	require.ErrorIs(t, err, board.ErrBoardsClosed)
+	r = newTestRegistry(t, dir, metrics)
+	defer r.Stop(context.Background())
+	b, err := r.Get("season-1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version": "v2", "default": {"type": "linear", "weight": 10}}`, string(b.Models))
+	top(t, r, "season-1", leader.Leaders{{Rank: 1, TalentID: "t2", Score: 30}, {Rank: 2, TalentID: "t1", Score: 20}})
+	top(t, r, "season-2", leader.Leaders{{Rank: 1, TalentID: "t1", Score: 15}})
+
+	require.NoError(t, r.Delete(context.Background(), "season-1"))
+	require.ErrorIs(t, r.Delete(context.Background(), "season-1"), board.ErrBoardNotFound)
+	_, err = r.Pipeline("season-1")
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+	_, err = os.Stat(filepath.Join(dir, "season-1"))
+	require.True(t, os.IsNotExist(err))
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.ModelInfo))
+
+	// a new board of the same name starts empty
+	_, err = r.Create(board.Board{Name: "season-1"})
+	require.NoError(t, err)
+	top(t, r, "season-1", leader.Leaders{})
+}
+
+// TestRegistry_CreateUnlocked - the other boards are served while a new one starts.
+func TestRegistry_CreateUnlocked(t *testing.T) {
+	r := newTestRegistry(t, t.TempDir(), newTestMetrics())
+	t.Cleanup(func() { r.Stop(context.Background()) })
+	_, err := r.Create(board.Board{Name: "served"})
+	require.NoError(t, err)
+
+	starting, release := make(chan struct{}), make(chan struct{})
+	r.cfg.NewStore = func(string) cache.Store {
+		close(starting)
+		<-release
+		return nil
+	}
+	created := make(chan error)
+	go func() {
+		_, err := r.Create(board.Board{Name: "slow"})
+		created <- err
+	}()
+	<-starting
+
+	_, err = r.Pipeline("served")
+	require.NoError(t, err)
+	require.Len(t, r.List(), 1)
+	_, err = r.Pipeline("slow")
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+	_, err = r.Create(board.Board{Name: "slow"})
+	require.ErrorIs(t, err, board.ErrBoardExists, "the name is taken while it starts")
+
+	close(release)
+	require.NoError(t, <-created)
+	_, err = r.Pipeline("slow")
+	require.NoError(t, err)
+}
+
+func TestRegistry_Seasons(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRegistry(t, dir, newTestMetrics())
+
+	_, err := r.Create(board.Board{Name: "cup", Season: season.Schedule{Length: time.Hour}})
+	require.ErrorIs(t, err, board.ErrInvalidBoard)
+
+	sched := season.Schedule{Start: time.Now().Add(-time.Hour), Length: 24 * time.Hour}
+	_, err = r.Create(board.Board{Name: "cup", Season: sched})
+	require.NoError(t, err)
+	p, err := r.Pipeline("cup")
+	require.NoError(t, err)
+	require.NoError(t, p.Seasons.Admit(time.Now()))
+	require.ErrorIs(t, p.Seasons.Admit(sched.Start.Add(-time.Second)), season.ErrOutsideSeason)
+	infos, err := p.Seasons.Seasons()
+	require.NoError(t, err)
+	require.Equal(t, season.StateRunning, infos[0].State)
+
+	// the schedule is a part of the definition
+	r.Stop(context.Background())
+	r = newTestRegistry(t, dir, newTestMetrics())
+	defer r.Stop(context.Background())
+	b, err := r.Get("cup")
+	require.NoError(t, err)
+	require.True(t, sched.Start.Equal(b.Season.Start))
+	require.Equal(t, sched.Length, b.Season.Length)
+}
+
+// TestRegistry_StartFailure - a board that can't be restored is not served, the others are.
+func TestRegistry_StartFailure(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRegistry(t, dir, newTestMetrics())
+	for _, name := range []string{"broken", "fine"} {
+		_, err := r.Create(board.Board{Name: name})
+		require.NoError(t, err)
+	}
+	r.Stop(context.Background())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken", snapshotsDir, "leaderboard.snap"), []byte("garbage"), 0o644))
+
+	r, err := Open(zaptest.NewLogger(t), r.cfg, newTestMetrics())
+	require.NoError(t, err)
+	require.ErrorContains(t, r.Start(context.Background()), "board broken")
+	defer r.Stop(context.Background())
+
+	_, err = r.Pipeline("broken")
+	require.ErrorIs(t, err, board.ErrBoardNotFound)
+	_, err = r.Pipeline("fine")
+	require.NoError(t, err)
+}
@@ -0,0 +1,210 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/board"
+	"leaderboard-api/internal/infrastructure/cache"
+	"leaderboard-api/internal/infrastructure/leaderboard"
+	"leaderboard-api/internal/infrastructure/ml"
+	"leaderboard-api/internal/infrastructure/wal"
+)
+
+const (
+	snapshotsDir = "snapshots"
+	walDir       = "wal"
+)
+
+// tenant - the pipeline of a board: dedup cache -> event log -> scorer pool -> boards.
+type tenant struct {
+	board   board.Board
+	log     *zap.Logger
+	store   cache.Store
+	cache   *cache.Cache
+	wal     *wal.Log
+	models  *ml.Registry
+	scorer  *ml.Scorer
+	lb      *leaderboard.LBMemory
+	metrics *prometheus.CounterVec
+
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+	// lbDone - closed when the leaderboard worker has applied the last scored event.
+	lbDone chan struct{}
+}
+
+// start - builds the pipeline of the board and runs its workers until stop.
+func (r *Registry) start(ctx context.Context, b board.Board) (t *tenant, err error) {
+	t = &tenant{
+		board:   b,
+		log:     r.log.With(zap.String("board", b.Name)),
+		metrics: r.metrics.Events.MustCurryWith(prometheus.Labels{"board": b.Name}),
+		lbDone:  make(chan struct{}),
+	}
+	labels := prometheus.Labels{"board": b.Name}
+	dir := r.dir(b.Name)
+
+	models, err := parseModels(b.Models)
+	if err != nil {
+		return nil, err
+	}
+	agg, err := leaderboard.NewAggregation(b.Aggregation, b.AvgLast, b.DecayHalfLife)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", board.ErrInvalidBoard, err)
+	}
+	ranking, err := leaderboard.NewRanking(b.TieBreak, b.RankType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", board.ErrInvalidBoard, err)
+	}
+
+	if r.cfg.NewStore != nil {
+		t.store = r.cfg.NewStore(b.Name)
+	}
+	cacheMtr := cache.Metrics{
+		Evictions: r.metrics.CacheEvictions.MustCurryWith(labels),
+		Size:      r.metrics.CacheSize.WithLabelValues(b.Name),
+	}
+	if t.cache, err = cache.New(ctx, t.log, t.store, r.cfg.Cache, cacheMtr); err != nil {
+		return nil, fmt.Errorf("board %s cache: %w", b.Name, err)
+	}
+	// the workers are not running yet, whatever is built is released on a failure,
+	// t itself is nil by then
+	c := t.cache
+	defer func() {
+		if err != nil {
+			c.Close()
+		}
+	}()
+
+	if dir != "" && r.cfg.WAL.Dir != "" {
+		walCfg := r.cfg.WAL
+		walCfg.Dir = filepath.Join(dir, walDir)
+		if t.wal, err = wal.Open(t.log, walCfg); err != nil {
+			return nil, fmt.Errorf("board %s event log: %w", b.Name, err)
+		}
+		log := t.wal
+		defer func() {
+			if err != nil {
+				_ = log.Close()
+			}
+		}()
+	}
+
+	t.models = ml.NewRegistry(t.log, models, r.metrics.ModelInfo.MustCurryWith(labels))
+	scorerMtr := ml.Metrics{
+		BatchSize:     r.metrics.ScorerBatchSize.WithLabelValues(b.Name).(prometheus.Histogram),
+		BatchDuration: r.metrics.ScorerBatchDuration.WithLabelValues(b.Name).(prometheus.Histogram),
+		Pool:          r.metrics.ScorerPool.MustCurryWith(labels),
+		QueueDepth:    r.metrics.ScorerQueueDepth.MustCurryWith(labels),
+		QueueWait:     r.metrics.ScorerQueueWait.MustCurryWith(labels),
+		Shed:          r.metrics.ScorerShed.MustCurryWith(labels),
+	}
+	if t.scorer, err = ml.New(ctx, t.log, t.models, r.cfg.Scorer, scorerMtr); err != nil {
+		return nil, fmt.Errorf("board %s scorer: %w", b.Name, err)
+	}
+	scorer := t.scorer
+	defer func() {
+		if err != nil {
+			_ = scorer.ClosePool(context.Background())
+		}
+	}()
+
+	lbCfg := r.cfg.Leaderboard
+	lbCfg.Aggregation = agg
+	lbCfg.Ranking = ranking
+	lbCfg.Season = b.Season
+	if dir != "" {
+		lbCfg.SnapshotDir = filepath.Join(dir, snapshotsDir)
+	}
+	if t.wal != nil && r.cfg.Compact {
+		lbCfg.Log = t.wal
+	}
+	t.lb, err = leaderboard.New(ctx, t.log, t.scorer.GetOutChan(), lbCfg,
+		t.metrics, r.metrics.Skill.MustCurryWith(labels))
+	if err != nil {
+		return nil, fmt.Errorf("board %s: %w", b.Name, err)
+	}
+
+	ctx, t.cancel = context.WithCancel(ctx)
+	t.scorer.RunScorerPool(ctx, r.cfg.Workers)
+	go func() {
+		defer close(t.lbDone)
+		t.lb.RunLBWorker(ctx)
+	}()
+	t.run(ctx, t.cache.BackupWorker)
+	t.run(ctx, t.lb.SnapshotWorker)
+	if t.wal != nil {
+		t.run(ctx, t.wal.SyncWorker)
+	}
+
+	return t, nil
+}
+
+// parseModels - the raw metric is the score without the models.
+func parseModels(raw json.RawMessage) (*ml.Models, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ml.IdentityModels(), nil
+	}
+	// a gbt model is a file on the server, an admin request must not choose one
+	var f ml.ModelsFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("%w: parse models: %w", board.ErrInvalidBoard, err)
+	}
+	for _, spec := range append([]ml.ModelSpec{f.Default}, slices.Collect(maps.Values(f.Skills))...) {
+		if spec.Type == ml.ModelGBT {
+			return nil, fmt.Errorf("%w: gbt models are not supported by the named boards", board.ErrInvalidBoard)
+		}
+	}
+	ms, err := ml.ParseModels(raw, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", board.ErrInvalidBoard, err)
+	}
+
+	return ms, nil
+}
+
+func (t *tenant) run(ctx context.Context, worker func(ctx context.Context)) {
+	t.workers.Add(1)
+	go func() {
+		defer t.workers.Done()
+		worker(ctx)
+	}()
+}
+
+// stop - the same order as the shutdown of the default board: the scorer pool
+// drains into the boards, they take the final snapshot, then the rest stops.
+func (t *tenant) stop(ctx context.Context) {
+	if err := t.scorer.ClosePool(ctx); err != nil {
+		t.log.Error("scorer pool drain error", zap.Error(err))
+	}
+	<-t.lbDone
+	t.lb.CloseStreams()
+	t.cancel()
+	t.workers.Wait()
+	if t.wal != nil {
+		if err := t.wal.Close(); err != nil {
+			t.log.Error("event log close failed", zap.Error(err))
+		}
+	}
+}
+
+// drop - removes what the board keeps outside of its directory.
+func (t *tenant) drop(ctx context.Context) error {
+	dropper, ok := t.store.(interface {
+		Drop(ctx context.Context) error
+	})
+	if !ok {
+		return nil
+	}
+
+	return dropper.Drop(ctx)
+}
@@ -0,0 +1,134 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+// Record layout: payload len u32 | crc32(payload) u32 | payload, little endian.
+// Payload: seq | event ID(16 bytes) | talent ID | raw metric f64 | skill | ts,
+// ints as varints, strings with the uvarint length.
+// The score is not logged, replayed events go through the scorer again.
+const (
+	recordHeader = 8
+	// maxRecord - anything longer is a damaged length, not an event.
+	maxRecord = 1 << 20
+)
+
+var errTorn = errors.New("wal: torn or corrupted record")
+
+func encodeRecord(buf []byte, seq uint64, e event.Event) []byte {
+	buf = append(buf[:0], make([]byte, recordHeader)...)
+	buf = binary.AppendUvarint(buf, seq)
+	buf = append(buf, e.EventID[:]...)
+	buf = appendString(buf, e.TalentID)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(e.RawMetric))
+	buf = appendString(buf, e.Skill)
+	buf = binary.AppendVarint(buf, e.TS.UnixNano())
+
+	payload := buf[recordHeader:]
+	binary.LittleEndian.PutUint32(buf[0:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(payload))
+
+	return buf
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// decodeRecord - the record at the start of data and its full length.
+func decodeRecord(data []byte) (event.Event, int, error) {
+	var e event.Event
+	if len(data) < recordHeader {
+		return e, 0, errTorn
+	}
+	n := binary.LittleEndian.Uint32(data[0:])
+	if n > maxRecord || int(n) > len(data)-recordHeader {
+		return e, 0, errTorn
+	}
+	payload := data[recordHeader : recordHeader+int(n)]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[4:]) {
+		return e, 0, errTorn
+	}
+
+	d := decoder{data: payload}
+	e.Seq = d.uvarint()
+	e.EventID = d.uuid()
+	e.TalentID = d.str()
+	e.RawMetric = math.Float64frombits(d.u64())
+	e.Skill = d.str()
+	e.TS = time.Unix(0, d.varint()).UTC()
+	if d.bad || len(d.data) != 0 {
+		return e, 0, errTorn
+	}
+
+	return e, recordHeader + int(n), nil
+}
+
+// decoder - a checksum matched, so a short payload means a bug or another format.
+type decoder struct {
+	data []byte
+	bad  bool
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.bad || n < 0 || n > len(d.data) {
+		d.bad = true
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.bad = true
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.bad = true
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) u64() uint64 {
+	b := d.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (d *decoder) str() string {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.bad = true
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *decoder) uuid() uuid.UUID {
+	var id uuid.UUID
+	copy(id[:], d.take(len(id)))
+	return id
+}
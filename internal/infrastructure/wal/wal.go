@@ -0,0 +1,367 @@
+// Package wal - append-only log of accepted events.
+// An event is written(and synced, depending on the mode) before the producer
+// gets 202, so an event in the scorer pipeline survives a crash of the app.
+package wal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+const (
+	SyncAlways   = "always"
+	SyncInterval = "interval"
+	SyncNever    = "never"
+
+	defaultSegmentSize  = 64 << 20
+	defaultSyncInterval = 100 * time.Millisecond
+
+	segmentExt = ".wal"
+)
+
+var ErrClosed = errors.New("wal: log is closed")
+
+// syncFile - fsync of an appended record, the tests make it fail.
+var syncFile = (*os.File).Sync
+
+type Config struct {
+	Dir string
+	// SegmentSize - a new segment is started once the current one is bigger.
+	SegmentSize int64
+	// Sync - "always" syncs every append before it returns, "interval" every
+	// SyncInterval(a crash may lose the last interval), "never" leaves it to the OS.
+	Sync         string
+	SyncInterval time.Duration
+}
+
+// Log - events in segments "<first seq>.wal", the sequence numbers start from 1.
+// Safe for concurrent use.
+type Log struct {
+	mu       sync.Mutex
+	log      *zap.Logger
+	cfg      Config
+	segments []uint64 // first seq of every segment, ascending
+	file     *os.File // the last segment, the only one written
+	size     int64
+	nextSeq  uint64
+	dirty    bool
+	buf      []byte
+	closed   bool
+}
+
+// Open - a torn record at the end of the last segment(a crash in the
+// middle of an append) is cut off, such an event was never acknowledged.
+func Open(log *zap.Logger, cfg Config) (*Log, error) {
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = defaultSegmentSize
+	}
+	if cfg.Sync == "" {
+		cfg.Sync = SyncAlways
+	}
+	if cfg.Sync != SyncAlways && cfg.Sync != SyncInterval && cfg.Sync != SyncNever {
+		return nil, fmt.Errorf("unknown wal sync mode %q", cfg.Sync)
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = defaultSyncInterval
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+
+	l := &Log{log: log, cfg: cfg, nextSeq: 1}
+	var err error
+	if l.segments, err = listSegments(cfg.Dir); err != nil {
+		return nil, err
+	}
+	if len(l.segments) == 0 {
+		return l, l.startSegment()
+	}
+
+	last := l.segments[len(l.segments)-1]
+	path := l.segmentPath(last)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	l.nextSeq = last
+	valid := 0
+	for valid < len(data) {
+		e, n, err := decodeRecord(data[valid:])
+		if err != nil {
+			log.Warn("wal: cutting off a torn record", zap.String("segment", path), zap.Int("offset", valid))
+			break
+		}
+		l.nextSeq = e.Seq + 1
+		valid += n
+	}
+	if l.file, err = os.OpenFile(path, os.O_RDWR, 0o644); err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	if valid < len(data) {
+		if err := l.file.Truncate(int64(valid)); err != nil {
+			_ = l.file.Close()
+			return nil, fmt.Errorf("wal: %w", err)
+		}
+	}
+	if _, err := l.file.Seek(int64(valid), io.SeekStart); err != nil {
+		_ = l.file.Close()
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	l.size = int64(valid)
+
+	return l, nil
+}
+
+// Append - writes the event and returns its sequence number.
+func (l *Log) Append(e event.Event) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.size >= l.cfg.SegmentSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	seq := l.nextSeq
+	l.buf = encodeRecord(l.buf, seq, e)
+	n, err := l.file.Write(l.buf)
+	if err != nil {
+		// never leave half a record in the middle of the segment
+		l.cutTail()
+		return 0, fmt.Errorf("wal append: %w", err)
+	}
+	if l.cfg.Sync == SyncAlways {
+		if err := syncFile(l.file); err != nil {
+			// the event is refused, it must not be replayed, and its seq goes to the next one
+			l.cutTail()
+			return 0, fmt.Errorf("wal sync: %w", err)
+		}
+	} else {
+		l.dirty = true
+	}
+	l.size += int64(n)
+	l.nextSeq++
+
+	return seq, nil
+}
+
+// cutTail - drops whatever was written after the last appended record.
+func (l *Log) cutTail() {
+	_ = l.file.Truncate(l.size)
+	_, _ = l.file.Seek(l.size, io.SeekStart)
+}
+
+// Replay - every logged event in the sequence order.
+func (l *Log) Replay(ctx context.Context, fn func(e event.Event) error) error {
+	_, err := l.ReplayFrom(ctx, 1, fn)
+	return err
+}
+
+// ReplayFrom - the logged events from the seq `from` in the sequence order,
+// returns the seq after the last replayed one, a later call may go on from it.
+func (l *Log) ReplayFrom(ctx context.Context, from uint64, fn func(e event.Event) error) (uint64, error) {
+	l.mu.Lock()
+	segments := slices.Clone(l.segments)
+	end := l.nextSeq
+	l.mu.Unlock()
+
+	next := max(from, 1)
+	for i, first := range segments {
+		if i+1 < len(segments) && segments[i+1] <= from {
+			// all of it is before from
+			continue
+		}
+		data, err := os.ReadFile(l.segmentPath(first))
+		if errors.Is(err, os.ErrNotExist) {
+			// compacted meanwhile
+			continue
+		}
+		if err != nil {
+			return next, fmt.Errorf("wal replay: %w", err)
+		}
+		for off := 0; off < len(data); {
+			e, n, err := decodeRecord(data[off:])
+			if err != nil && i == len(segments)-1 {
+				// the tail of an append that is still being written
+				return next, nil
+			}
+			if err != nil {
+				return next, fmt.Errorf("wal replay: segment %d offset %d: %w", first, off, err)
+			}
+			if e.Seq >= end {
+				// appended after the replay has started
+				return next, nil
+			}
+			off += n
+			if e.Seq < from {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return next, err
+			}
+			if err := fn(e); err != nil {
+				return next, err
+			}
+			next = e.Seq + 1
+		}
+	}
+
+	return next, nil
+}
+
+// Bounds - the first seq still in the log(the older ones are compacted)
+// and the seq of the next append.
+func (l *Log) Bounds() (first, next uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.segments[0], l.nextSeq
+}
+
+// Compact - removes the segments with events up to seq only,
+// the segment being written is never removed.
+func (l *Log) Compact(upTo uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for len(l.segments) > 1 && l.segments[1] <= upTo+1 {
+		if err := os.Remove(l.segmentPath(l.segments[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("wal compact: %w", err)
+		}
+		l.segments = l.segments[1:]
+		removed++
+	}
+	if removed > 0 {
+		l.log.Info("wal compacted", zap.Int("segments", removed), zap.Uint64("up_to", upTo))
+	}
+
+	return nil
+}
+
+// SyncWorker - syncs the log every SyncInterval in the "interval" mode.
+func (l *Log) SyncWorker(ctx context.Context) {
+	if l.cfg.Sync != SyncInterval {
+		return
+	}
+	l.log.Info("starting wal sync worker")
+
+	ticker := time.NewTicker(l.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.Sync(); err != nil {
+				l.log.Error("wal sync failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			l.log.Info("wal sync worker gracefully stopped")
+			return
+		}
+	}
+}
+
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.sync()
+}
+
+// Close - syncs and closes the log, appends fail after it.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	err := l.sync()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func (l *Log) sync() error {
+	if !l.dirty {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("wal sync: %w", err)
+	}
+	l.dirty = false
+
+	return nil
+}
+
+// rotate - must be called under the lock.
+func (l *Log) rotate() error {
+	l.dirty = true
+	if err := l.sync(); err != nil {
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+
+	return l.startSegment()
+}
+
+func (l *Log) startSegment() error {
+	f, err := os.OpenFile(l.segmentPath(l.nextSeq), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+	l.file = f
+	l.size = 0
+	l.segments = append(l.segments, l.nextSeq)
+
+	return nil
+}
+
+func (l *Log) segmentPath(first uint64) string {
+	return filepath.Join(l.cfg.Dir, fmt.Sprintf("%020d%s", first, segmentExt))
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	var segments []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, first)
+	}
+	slices.Sort(segments)
+
+	return segments, nil
+}
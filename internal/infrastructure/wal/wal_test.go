@@ -0,0 +1,257 @@
+package wal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"leaderboard-api/internal/domain/event"
+)
+
+func openTestLog(t *testing.T, dir string, segmentSize int64) *Log {
+	t.Helper()
+	l, err := Open(zaptest.NewLogger(t), Config{Dir: dir, SegmentSize: segmentSize, Sync: SyncAlways})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func testEvent(i int) event.Event {
+	return event.Event{
+		EventID:   uuid.New(),
+		TalentID:  "t-" + strconv.Itoa(i),
+		RawMetric: float64(i) + 0.5,
+		Skill:     "pass",
+		TS:        time.Date(2025, 1, 1, 0, 0, i, 0, time.UTC),
+	}
+}
+
+func appendEvents(t *testing.T, l *Log, n int) []event.Event {
+	t.Helper()
+	events := make([]event.Event, n)
+	for i := range events {
+		events[i] = testEvent(i)
+		seq, err := l.Append(events[i])
+		require.NoError(t, err)
+		events[i].Seq = seq
+	}
+	return events
+}
+
+func replayAll(t *testing.T, l *Log) []event.Event {
+	t.Helper()
+	var got []event.Event
+	require.NoError(t, l.Replay(context.Background(), func(e event.Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	return got
+}
+
+func TestLog_AppendReplay(t *testing.T) {
+	tests := []struct {
+		name        string
+		segmentSize int64
+		events      int
+		wantSegs    int
+	}{
+		{"One segment", 1 << 20, 10, 1},
+		{"Rotated", 200, 10, 3},
+		{"Empty", 1 << 20, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l := openTestLog(t, dir, tt.segmentSize)
+			want := appendEvents(t, l, tt.events)
+			for i, e := range want {
+				require.Equal(t, uint64(i+1), e.Seq)
+			}
+			require.Len(t, l.segments, tt.wantSegs)
+			if len(want) == 0 {
+				want = nil
+			}
+			require.Equal(t, want, replayAll(t, l))
+
+			// after a restart the sequence goes on
+			require.NoError(t, l.Close())
+			reopened := openTestLog(t, dir, tt.segmentSize)
+			require.Equal(t, want, replayAll(t, reopened))
+			seq, err := reopened.Append(testEvent(99))
+			require.NoError(t, err)
+			require.Equal(t, uint64(tt.events+1), seq)
+		})
+	}
+}
+
+func TestLog_TornTail(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, 1<<20)
+	want := appendEvents(t, l, 3)
+	require.NoError(t, l.Close())
+
+	// a crash in the middle of the 4th append
+	path := l.segmentPath(1)
+	rec := encodeRecord(nil, 4, testEvent(4))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write(rec[:len(rec)/2])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened := openTestLog(t, dir, 1<<20)
+	require.Equal(t, want, replayAll(t, reopened))
+	seq, err := reopened.Append(testEvent(4))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), seq)
+	require.Len(t, replayAll(t, reopened), 4)
+}
+
+func TestLog_CorruptedSegment(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, 200)
+	appendEvents(t, l, 10)
+
+	// damage the first, already closed segment
+	path := l.segmentPath(l.segments[0])
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[recordHeader+2] ^= 0xFF
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	err = l.Replay(context.Background(), func(event.Event) error { return nil })
+	require.ErrorIs(t, err, errTorn)
+}
+
+func TestLog_Compact(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, 200)
+	appendEvents(t, l, 10)
+	segments := append([]uint64(nil), l.segments...)
+	require.Greater(t, len(segments), 2)
+
+	// the second segment is not fully covered
+	require.NoError(t, l.Compact(segments[1]))
+	require.Equal(t, segments[1:], l.segments)
+	_, err := os.Stat(filepath.Join(dir, filepath.Base(l.segmentPath(segments[0]))))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	got := replayAll(t, l)
+	require.Equal(t, segments[1], got[0].Seq)
+
+	// everything is covered, the active segment stays
+	require.NoError(t, l.Compact(10))
+	require.Equal(t, segments[len(segments)-1:], l.segments)
+}
+
+func TestLog_ReplayFrom(t *testing.T) {
+	l := openTestLog(t, t.TempDir(), 200)
+	events := appendEvents(t, l, 10)
+
+	tests := []struct {
+		name     string
+		from     uint64
+		want     []event.Event
+		wantNext uint64
+	}{
+		{"From the start", 0, events, 11},
+		{"From a later segment", 7, events[6:], 11},
+		{"Up to date", 11, nil, 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []event.Event
+			next, err := l.ReplayFrom(context.Background(), tt.from, func(e event.Event) error {
+				got = append(got, e)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantNext, next)
+		})
+	}
+
+	// goes on from the returned seq
+	more := appendEvents(t, l, 2)
+	var got []event.Event
+	next, err := l.ReplayFrom(context.Background(), 11, func(e event.Event) error {
+		got = append(got, e)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(13), next)
+	require.Len(t, got, 2)
+	require.Equal(t, more[0].EventID, got[0].EventID)
+
+	first, next := l.Bounds()
+	require.Equal(t, uint64(1), first)
+	require.Equal(t, uint64(13), next)
+	require.NoError(t, l.Compact(10))
+	first, _ = l.Bounds()
+	require.Greater(t, first, uint64(1))
+}
+
+func TestLog_Errors(t *testing.T) {
+	_, err := Open(zaptest.NewLogger(t), Config{Dir: t.TempDir(), Sync: "sometimes"})
+	require.Error(t, err)
+
+	l := openTestLog(t, t.TempDir(), 1<<20)
+	require.NoError(t, l.Close())
+	_, err = l.Append(testEvent(1))
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestLog_SyncFailed(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, 1<<20)
+	appendEvents(t, l, 2)
+
+	syncFile = func(*os.File) error { return os.ErrInvalid }
+	_, err := l.Append(testEvent(2))
+	syncFile = (*os.File).Sync
+	require.ErrorIs(t, err, os.ErrInvalid)
+
+	// the refused record is gone, its seq is taken by the next append
+	e := testEvent(3)
+	seq, err := l.Append(e)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), seq)
+	e.Seq = seq
+
+	require.NoError(t, l.Close())
+	got := replayAll(t, openTestLog(t, dir, 1<<20))
+	require.Len(t, got, 3)
+	require.Equal(t, e, got[2])
+}
+
+func TestLog_SyncInterval(t *testing.T) {
+	l, err := Open(zaptest.NewLogger(t), Config{Dir: t.TempDir(), Sync: SyncInterval, SyncInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.SyncWorker(ctx)
+		close(done)
+	}()
+
+	appendEvents(t, l, 3)
+	require.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return !l.dirty
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
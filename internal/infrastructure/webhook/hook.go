@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/milestone"
+)
+
+const (
+	defaultTopN = 10
+	// anySkill - a rule of every per-skill board.
+	anySkill = "*"
+)
+
+var hookID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// HooksFile - registered webhooks, e.g.
+//
+//	{"webhooks": [{"id": "partner-a", "url": "https://partner.example/hooks", "secret": "...",
+//	  "rules": [{"type": "top_n", "n": 10}, {"type": "first_place", "skill": "pass"}]}]}
+type HooksFile struct {
+	Webhooks []Hook `json:"webhooks"`
+}
+
+// Hook - a partner endpoint, every payload is signed with its Secret.
+type Hook struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+	Rules  []Rule `json:"rules"`
+}
+
+// Rule - a milestone on the all-time board of Skill: empty - the global board,
+// "*" - every per-skill board.
+type Rule struct {
+	Type  milestone.Type `json:"type"`
+	N     int            `json:"n,omitempty"`
+	Skill string         `json:"skill,omitempty"`
+}
+
+func LoadHooks(path string) ([]Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read webhooks: %w", err)
+	}
+	var f HooksFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse webhooks %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(f.Webhooks))
+	for i := range f.Webhooks {
+		h := &f.Webhooks[i]
+		if err := h.validate(); err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", h.ID, err)
+		}
+		if seen[h.ID] {
+			return nil, fmt.Errorf("webhook %q: duplicate id", h.ID)
+		}
+		seen[h.ID] = true
+	}
+
+	return f.Webhooks, nil
+}
+
+func (h *Hook) validate() error {
+	if !hookID.MatchString(h.ID) {
+		return fmt.Errorf("id must match %s", hookID)
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q", h.URL)
+	}
+	if h.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	if len(h.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	for i := range h.Rules {
+		r := &h.Rules[i]
+		switch r.Type {
+		case milestone.TopN:
+			if r.N == 0 {
+				r.N = defaultTopN
+			}
+			if r.N < 0 {
+				return fmt.Errorf("top_n: n must be > 0")
+			}
+		case milestone.FirstPlace, milestone.PersonalBest:
+		default:
+			return fmt.Errorf("unknown rule type %q", r.Type)
+		}
+	}
+
+	return nil
+}
+
+// match - the milestone the change has reached by the rule.
+func (r Rule) match(c leader.Change) (milestone.Milestone, bool) {
+	switch {
+	case r.Skill == anySkill && c.Skill == "":
+		return milestone.Milestone{}, false
+	case r.Skill != anySkill && r.Skill != c.Skill:
+		return milestone.Milestone{}, false
+	}
+
+	var ok bool
+	switch r.Type {
+	case milestone.TopN:
+		ok = c.Rank <= r.N && (c.PrevRank == 0 || c.PrevRank > r.N)
+	case milestone.FirstPlace:
+		ok = c.Rank == 1 && c.PrevRank != 1
+	case milestone.PersonalBest:
+		ok = c.PrevRank != 0 && c.Improved
+	}
+	if !ok {
+		return milestone.Milestone{}, false
+	}
+
+	m := milestone.Milestone{
+		Type:      r.Type,
+		Skill:     c.Skill,
+		TalentID:  c.TalentID,
+		Score:     c.Score,
+		PrevScore: c.PrevScore,
+		Rank:      c.Rank,
+		PrevRank:  c.PrevRank,
+	}
+	if r.Type == milestone.TopN {
+		m.N = r.N
+	}
+
+	return m, true
+}
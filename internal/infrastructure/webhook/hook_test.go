@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/milestone"
+)
+
+func TestRule_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		change leader.Change
+		want   bool
+	}{
+		{"Enters the top", Rule{Type: milestone.TopN, N: 10}, leader.Change{Rank: 10, PrevRank: 11}, true},
+		{"New on the board in the top", Rule{Type: milestone.TopN, N: 10}, leader.Change{Rank: 3}, true},
+		{"Within the top", Rule{Type: milestone.TopN, N: 10}, leader.Change{Rank: 2, PrevRank: 5}, false},
+		{"Below the top", Rule{Type: milestone.TopN, N: 10}, leader.Change{Rank: 11, PrevRank: 40}, false},
+		{"Takes first place", Rule{Type: milestone.FirstPlace}, leader.Change{Rank: 1, PrevRank: 2}, true},
+		{"New and first", Rule{Type: milestone.FirstPlace}, leader.Change{Rank: 1}, true},
+		{"Keeps first place", Rule{Type: milestone.FirstPlace}, leader.Change{Rank: 1, PrevRank: 1}, false},
+		{"Personal best", Rule{Type: milestone.PersonalBest}, leader.Change{Score: 5, PrevScore: 4, Rank: 9, PrevRank: 9, Improved: true}, true},
+		{"First score is no best", Rule{Type: milestone.PersonalBest}, leader.Change{Score: 5, Rank: 9}, false},
+		{"Lower time by min", Rule{Type: milestone.PersonalBest}, leader.Change{Score: 3, PrevScore: 4, Rank: 9, PrevRank: 12, Improved: true}, true},
+		{"Worse score", Rule{Type: milestone.PersonalBest}, leader.Change{Score: 3, PrevScore: 4, Rank: 9, PrevRank: 8}, false},
+		{"Skill board", Rule{Type: milestone.FirstPlace, Skill: "pass"}, leader.Change{Skill: "pass", Rank: 1}, true},
+		{"Other skill board", Rule{Type: milestone.FirstPlace, Skill: "pass"}, leader.Change{Skill: "shoot", Rank: 1}, false},
+		{"Global rule, skill board", Rule{Type: milestone.FirstPlace}, leader.Change{Skill: "pass", Rank: 1}, false},
+		{"Any skill", Rule{Type: milestone.FirstPlace, Skill: anySkill}, leader.Change{Skill: "shoot", Rank: 1}, true},
+		{"Any skill, global board", Rule{Type: milestone.FirstPlace, Skill: anySkill}, leader.Change{Rank: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := tt.rule.match(tt.change)
+			require.Equal(t, tt.want, ok)
+			if ok {
+				require.Equal(t, tt.rule.Type, m.Type)
+				require.Equal(t, tt.change.Skill, m.Skill)
+				require.Equal(t, tt.change.Rank, m.Rank)
+			}
+		})
+	}
+}
+
+func TestLoadHooks(t *testing.T) {
+	write := func(t *testing.T, body string) string {
+		path := filepath.Join(t.TempDir(), "webhooks.json")
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+		return path
+	}
+
+	hooks, err := LoadHooks(write(t, `{"webhooks": [{"id": "partner-a", "url": "https://partner.example/hooks", "secret": "x",
+		"rules": [{"type": "top_n"}, {"type": "personal_best", "skill": "pass"}]}]}`))
+	require.NoError(t, err)
+	require.Equal(t, []Rule{{Type: milestone.TopN, N: defaultTopN}, {Type: milestone.PersonalBest, Skill: "pass"}}, hooks[0].Rules)
+
+	for name, body := range map[string]string{
+		"Bad id":       `{"webhooks": [{"id": "a b", "url": "http://x", "secret": "x", "rules": [{"type": "first_place"}]}]}`,
+		"Bad url":      `{"webhooks": [{"id": "a", "url": "ftp://x", "secret": "x", "rules": [{"type": "first_place"}]}]}`,
+		"No secret":    `{"webhooks": [{"id": "a", "url": "http://x", "rules": [{"type": "first_place"}]}]}`,
+		"No rules":     `{"webhooks": [{"id": "a", "url": "http://x", "secret": "x"}]}`,
+		"Unknown rule": `{"webhooks": [{"id": "a", "url": "http://x", "secret": "x", "rules": [{"type": "top_5"}]}]}`,
+		"Negative n":   `{"webhooks": [{"id": "a", "url": "http://x", "secret": "x", "rules": [{"type": "top_n", "n": -1}]}]}`,
+		"Duplicate id": `{"webhooks": [{"id": "a", "url": "http://x", "secret": "x", "rules": [{"type": "first_place"}]},
+			{"id": "a", "url": "http://y", "secret": "x", "rules": [{"type": "first_place"}]}]}`,
+		"Not json": `webhooks`,
+	} {
+		_, err := LoadHooks(write(t, body))
+		require.Error(t, err, name)
+	}
+}
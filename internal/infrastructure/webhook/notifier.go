@@ -0,0 +1,499 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/milestone"
+)
+
+// Headers of every delivery.
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature - "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>" by the secret of the webhook.
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Delivery results, a label of the deliveries metric.
+const (
+	ResultDelivered = "delivered"
+	ResultFailed    = "failed"
+	ResultDead      = "dead"
+	ResultDropped   = "dropped"
+)
+
+const (
+	defaultTimeout     = 5 * time.Second
+	defaultMaxAttempts = 8
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = 10 * time.Minute
+	defaultConcurrency = 4
+	defaultBuffer      = 1000
+
+	dispatchInterval = 100 * time.Millisecond
+	// recentDelivered - the delivered ones kept for the status lookups.
+	recentDelivered = 1000
+)
+
+// deliveryNamespace - delivery IDs of a logged event are the same on every replay of it.
+var deliveryNamespace = uuid.MustParse("8f0e7c52-1d7e-4c3b-9a51-2f4f6a0b9d13")
+
+// Config - Dir is the durable queue, empty - the undelivered ones are lost on restart.
+// The n-th failed attempt is retried after Backoff*2^(n-1) up to MaxBackoff,
+// after MaxAttempts the delivery is dead-lettered.
+type Config struct {
+	Dir         string
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Concurrency int
+	// Buffer - milestones between the leaderboard worker and the queue,
+	// the worker never waits, the overflow is dropped.
+	Buffer int
+}
+
+type Metrics struct {
+	// Deliveries - attempts by webhook and result(delivered, failed, dead, dropped).
+	Deliveries *prometheus.CounterVec
+	Pending    prometheus.Gauge
+}
+
+// Notifier - posts the milestones of the leaderboards to the webhooks,
+// at least once: a receiver dedupes them by HeaderDelivery.
+type Notifier struct {
+	log     *zap.Logger
+	cfg     Config
+	hooks   []Hook
+	byID    map[string]Hook
+	client  *http.Client
+	store   *store
+	metrics Metrics
+	now     func() time.Time
+	in      chan milestone.Delivery
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mu        sync.Mutex
+	pending   map[string]*milestone.Delivery
+	inFlight  map[string]bool
+	dead      map[string]*milestone.Delivery
+	delivered []milestone.Delivery
+}
+
+func New(log *zap.Logger, hooks []Hook, cfg Config, metrics Metrics) (*Notifier, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = max(defaultMaxBackoff, cfg.Backoff)
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = defaultBuffer
+	}
+	n := &Notifier{
+		log:      log,
+		cfg:      cfg,
+		hooks:    hooks,
+		byID:     make(map[string]Hook, len(hooks)),
+		client:   &http.Client{Timeout: cfg.Timeout},
+		metrics:  metrics,
+		now:      time.Now,
+		in:       make(chan milestone.Delivery, cfg.Buffer),
+		sem:      make(chan struct{}, cfg.Concurrency),
+		pending:  make(map[string]*milestone.Delivery),
+		inFlight: make(map[string]bool),
+		dead:     make(map[string]*milestone.Delivery),
+	}
+	for _, h := range hooks {
+		n.byID[h.ID] = h
+	}
+
+	if cfg.Dir != "" {
+		var err error
+		if n.store, err = openStore(cfg.Dir); err != nil {
+			return nil, err
+		}
+		ds, err := n.store.load()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range ds {
+			if d.Status == milestone.StatusDead {
+				n.dead[d.ID] = &d
+			} else {
+				n.pending[d.ID] = &d
+			}
+		}
+		n.metrics.Pending.Set(float64(len(n.pending)))
+		log.Info("webhook queue restored", zap.Int("pending", len(n.pending)), zap.Int("dead", len(n.dead)))
+	}
+
+	return n, nil
+}
+
+// Notify - matches the changes of an event to the rules, see leaderboard.Config.Notify.
+func (n *Notifier) Notify(e event.Event, changes []leader.Change) {
+	for _, c := range changes {
+		for _, h := range n.hooks {
+			for _, r := range h.Rules {
+				m, ok := r.match(c)
+				if !ok {
+					continue
+				}
+				m.Seq, m.At = e.Seq, n.now()
+				d := milestone.Delivery{ID: deliveryID(h.ID, m), Webhook: h.ID, Milestone: m}
+				select {
+				case n.in <- d:
+				default:
+					n.metrics.Deliveries.WithLabelValues(h.ID, ResultDropped).Inc()
+					n.log.Warn("webhook milestone dropped, the queue is behind", zap.String("webhook", h.ID), zap.String("milestone", string(m.Type)))
+				}
+			}
+		}
+	}
+}
+
+func deliveryID(hook string, m milestone.Milestone) string {
+	if m.Seq == 0 {
+		return uuid.NewString()
+	}
+	key := fmt.Sprintf("%d/%s/%s/%d/%s", m.Seq, hook, m.Type, m.N, m.Skill)
+
+	return uuid.NewSHA1(deliveryNamespace, []byte(key)).String()
+}
+
+// Run - sends the due deliveries until ctx is done, the ones in flight are
+// left pending then.
+func (n *Notifier) Run(ctx context.Context) {
+	n.log.Info("starting webhook notifier", zap.Int("webhooks", len(n.hooks)))
+	defer n.log.Info("webhook notifier gracefully stopped")
+
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case d := <-n.in:
+			n.accept(d)
+			n.dispatch(ctx)
+		case <-ticker.C:
+			n.dispatch(ctx)
+		case <-ctx.Done():
+			n.wg.Wait()
+			return
+		}
+	}
+}
+
+// Close - queues the milestones still in the buffer, after the leaderboard worker
+// has stopped, they are sent on the next start.
+func (n *Notifier) Close() {
+	for {
+		select {
+		case d := <-n.in:
+			n.accept(d)
+		default:
+			return
+		}
+	}
+}
+
+// accept - queues a new delivery, a replayed one is skipped.
+func (n *Notifier) accept(d milestone.Delivery) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.known(d.ID) {
+		return
+	}
+	now := n.now()
+	d.Status = milestone.StatusPending
+	d.CreatedAt, d.UpdatedAt, d.NextAttempt = now, now, now
+	n.persist(d)
+	n.pending[d.ID] = &d
+	n.metrics.Pending.Set(float64(len(n.pending)))
+}
+
+func (n *Notifier) known(id string) bool {
+	if n.pending[id] != nil || n.dead[id] != nil {
+		return true
+	}
+
+	return slices.ContainsFunc(n.delivered, func(d milestone.Delivery) bool { return d.ID == id })
+}
+
+// dispatch - sends the due deliveries, up to Concurrency at once.
+func (n *Notifier) dispatch(ctx context.Context) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	for id, d := range n.pending {
+		if n.inFlight[id] || d.NextAttempt.After(now) {
+			continue
+		}
+		select {
+		case n.sem <- struct{}{}:
+		default:
+			return
+		}
+		n.inFlight[id] = true
+		n.wg.Add(1)
+		go func(d milestone.Delivery) {
+			defer n.wg.Done()
+			defer func() { <-n.sem }()
+			err := n.send(ctx, d)
+			n.done(ctx, d.ID, err)
+		}(*d)
+	}
+}
+
+// done - the result of an attempt.
+func (n *Notifier) done(ctx context.Context, id string, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.inFlight, id)
+	d := n.pending[id]
+	if d == nil {
+		return
+	}
+	// the shutdown is not the receiver's failure
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	now := n.now()
+	d.Attempts++
+	d.UpdatedAt = now
+	switch {
+	case err == nil:
+		d.Status, d.LastError = milestone.StatusDelivered, ""
+		delete(n.pending, id)
+		n.remove(milestone.StatusPending, id)
+		n.delivered = append(n.delivered, *d)
+		if len(n.delivered) > recentDelivered {
+			n.delivered = slices.Delete(n.delivered, 0, len(n.delivered)-recentDelivered)
+		}
+		n.metrics.Deliveries.WithLabelValues(d.Webhook, ResultDelivered).Inc()
+	case d.Attempts >= n.cfg.MaxAttempts || errors.Is(err, errUnknownHook):
+		d.Status, d.LastError = milestone.StatusDead, err.Error()
+		delete(n.pending, id)
+		n.dead[id] = d
+		if n.store != nil {
+			if err := n.store.move(milestone.StatusPending, *d); err != nil {
+				n.log.Error("webhook dead letter write failed", zap.String("delivery", id), zap.Error(err))
+			}
+		}
+		n.metrics.Deliveries.WithLabelValues(d.Webhook, ResultDead).Inc()
+		n.log.Warn("webhook delivery dead-lettered", zap.String("delivery", id), zap.String("webhook", d.Webhook), zap.Error(err))
+	default:
+		d.LastError = err.Error()
+		d.NextAttempt = now.Add(n.backoff(d.Attempts))
+		n.persist(*d)
+		n.metrics.Deliveries.WithLabelValues(d.Webhook, ResultFailed).Inc()
+	}
+	n.metrics.Pending.Set(float64(len(n.pending)))
+}
+
+// backoff - exponential with up to 20% of jitter, so the retries of many
+// deliveries don't come at once.
+func (n *Notifier) backoff(attempts int) time.Duration {
+	d := n.cfg.Backoff
+	for i := 1; i < attempts && d < n.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, n.cfg.MaxBackoff)
+
+	return d + time.Duration(rand.Int64N(int64(d)/5+1))
+}
+
+var errUnknownHook = errors.New("the webhook is not registered anymore")
+
+// payload - the body of a delivery.
+type payload struct {
+	ID        string    `json:"id"`
+	Webhook   string    `json:"webhook"`
+	Milestone string    `json:"milestone"`
+	N         int       `json:"n,omitempty"`
+	Skill     string    `json:"skill,omitempty"`
+	TalentID  string    `json:"talent_id"`
+	Score     float64   `json:"score"`
+	PrevScore float64   `json:"prev_score"`
+	Rank      int       `json:"rank"`
+	PrevRank  int       `json:"prev_rank"`
+	At        time.Time `json:"at"`
+}
+
+func (n *Notifier) send(ctx context.Context, d milestone.Delivery) error {
+	h, ok := n.byID[d.Webhook]
+	if !ok {
+		return errUnknownHook
+	}
+	m := d.Milestone
+	body, err := json.Marshal(payload{
+		ID:        d.ID,
+		Webhook:   d.Webhook,
+		Milestone: string(m.Type),
+		N:         m.N,
+		Skill:     m.Skill,
+		TalentID:  m.TalentID,
+		Score:     m.Score,
+		PrevScore: m.PrevScore,
+		Rank:      m.Rank,
+		PrevRank:  m.PrevRank,
+		At:        m.At,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ts := n.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(h.Secret, ts, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Sign - the HeaderSignature value, a receiver recomputes it with the shared secret
+// and rejects old timestamps.
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (n *Notifier) persist(d milestone.Delivery) {
+	if n.store == nil {
+		return
+	}
+	if err := n.store.save(d); err != nil {
+		n.log.Error("webhook queue write failed", zap.String("delivery", d.ID), zap.Error(err))
+	}
+}
+
+func (n *Notifier) remove(st milestone.Status, id string) {
+	if n.store == nil {
+		return
+	}
+	if err := n.store.remove(st, id); err != nil {
+		n.log.Error("webhook queue remove failed", zap.String("delivery", id), zap.Error(err))
+	}
+}
+
+// Deliveries - by status, the oldest first, delivered - the recent ones only.
+func (n *Notifier) Deliveries(st milestone.Status) []milestone.Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var ds []milestone.Delivery
+	switch st {
+	case milestone.StatusPending:
+		for _, d := range n.pending {
+			ds = append(ds, *d)
+		}
+	case milestone.StatusDead:
+		for _, d := range n.dead {
+			ds = append(ds, *d)
+		}
+	case milestone.StatusDelivered:
+		ds = slices.Clone(n.delivered)
+	}
+	slices.SortStableFunc(ds, func(a, b milestone.Delivery) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return ds
+}
+
+func (n *Notifier) Delivery(id string) (milestone.Delivery, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if d := n.pending[id]; d != nil {
+		return *d, nil
+	}
+	if d := n.dead[id]; d != nil {
+		return *d, nil
+	}
+	for _, d := range n.delivered {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+
+	return milestone.Delivery{}, milestone.ErrDeliveryNotFound
+}
+
+// Redrive - a dead-lettered delivery is sent again with a fresh count of attempts.
+func (n *Notifier) Redrive(id string) (milestone.Delivery, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	d := n.dead[id]
+	if d == nil {
+		if n.known(id) {
+			return milestone.Delivery{}, milestone.ErrNotDead
+		}
+		return milestone.Delivery{}, milestone.ErrDeliveryNotFound
+	}
+	delete(n.dead, id)
+	d.Status, d.Attempts = milestone.StatusPending, 0
+	d.UpdatedAt, d.NextAttempt = n.now(), n.now()
+	if n.store != nil {
+		if err := n.store.move(milestone.StatusDead, *d); err != nil {
+			n.log.Error("webhook queue write failed", zap.String("delivery", id), zap.Error(err))
+		}
+	}
+	n.pending[id] = d
+	n.metrics.Pending.Set(float64(len(n.pending)))
+
+	return *d, nil
+}
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/milestone"
+)
+
+const testSecret = "s3cr3t"
+
+// receiver - a partner endpoint that fails the first failures requests.
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	failures int
+	payloads []payload
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(rc.t, err)
+	ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(rc.t, err)
+	require.Equal(rc.t, Sign(testSecret, ts, body), r.Header.Get(HeaderSignature))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	var p payload
+	require.NoError(rc.t, json.Unmarshal(body, &p))
+	require.Equal(rc.t, p.ID, r.Header.Get(HeaderDelivery))
+	rc.payloads = append(rc.payloads, p)
+}
+
+func (rc *receiver) received() []payload {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]payload(nil), rc.payloads...)
+}
+
+func newTestMetrics() Metrics {
+	return Metrics{
+		Deliveries: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "deliveries"}, []string{"webhook", "result"}),
+		Pending:    prometheus.NewGauge(prometheus.GaugeOpts{Name: "pending"}),
+	}
+}
+
+func newTestNotifier(t *testing.T, url string, cfg Config) *Notifier {
+	t.Helper()
+	hooks := []Hook{{ID: "partner", URL: url, Secret: testSecret, Rules: []Rule{{Type: milestone.FirstPlace}}}}
+	if cfg.Backoff == 0 {
+		cfg.Backoff, cfg.MaxBackoff = time.Millisecond, 5*time.Millisecond
+	}
+	n, err := New(zaptest.NewLogger(t), hooks, cfg, newTestMetrics())
+	require.NoError(t, err)
+	return n
+}
+
+// run - until the test ends.
+func run(t *testing.T, n *Notifier) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+var firstPlace = leader.Change{TalentID: "t1", Score: 90, PrevScore: 80, Rank: 1, PrevRank: 2}
+
+func TestNotifier_Delivers(t *testing.T) {
+	rc := &receiver{t: t}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL, Config{})
+	run(t, n)
+
+	n.Notify(event.Event{Seq: 7}, []leader.Change{firstPlace, {Skill: "pass", TalentID: "t1", Rank: 1}})
+
+	require.Eventually(t, func() bool { return len(rc.received()) == 1 }, time.Second, 5*time.Millisecond)
+	p := rc.received()[0]
+	require.Equal(t, "partner", p.Webhook)
+	require.Equal(t, string(milestone.FirstPlace), p.Milestone)
+	require.Equal(t, "t1", p.TalentID)
+	require.Equal(t, 90.0, p.Score)
+	require.Equal(t, 2, p.PrevRank)
+
+	require.Eventually(t, func() bool { return len(n.Deliveries(milestone.StatusDelivered)) == 1 }, time.Second, 5*time.Millisecond)
+	d, err := n.Delivery(p.ID)
+	require.NoError(t, err)
+	require.Equal(t, milestone.StatusDelivered, d.Status)
+	require.Equal(t, 1, d.Attempts)
+	require.Equal(t, 1.0, testutil.ToFloat64(n.metrics.Deliveries.WithLabelValues("partner", ResultDelivered)))
+
+	// the same event replayed after a restart is not sent twice
+	n.Notify(event.Event{Seq: 7}, []leader.Change{firstPlace})
+	time.Sleep(20 * time.Millisecond)
+	require.Len(t, rc.received(), 1)
+}
+
+func TestNotifier_RetriesWithBackoff(t *testing.T) {
+	rc := &receiver{t: t, failures: 2}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL, Config{MaxAttempts: 5})
+	run(t, n)
+
+	n.Notify(event.Event{Seq: 1}, []leader.Change{firstPlace})
+
+	require.Eventually(t, func() bool { return len(n.Deliveries(milestone.StatusDelivered)) == 1 }, time.Second, 5*time.Millisecond)
+	d := n.Deliveries(milestone.StatusDelivered)[0]
+	require.Equal(t, 3, d.Attempts)
+	require.Empty(t, d.LastError)
+	require.Equal(t, 2.0, testutil.ToFloat64(n.metrics.Deliveries.WithLabelValues("partner", ResultFailed)))
+}
+
+func TestNotifier_DeadLetterAndRedrive(t *testing.T) {
+	rc := &receiver{t: t, failures: 3}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL, Config{MaxAttempts: 3, Dir: t.TempDir()})
+	run(t, n)
+
+	n.Notify(event.Event{Seq: 1}, []leader.Change{firstPlace})
+
+	require.Eventually(t, func() bool { return len(n.Deliveries(milestone.StatusDead)) == 1 }, time.Second, 5*time.Millisecond)
+	d := n.Deliveries(milestone.StatusDead)[0]
+	require.Equal(t, 3, d.Attempts)
+	require.Contains(t, d.LastError, "502")
+	require.Empty(t, rc.received())
+
+	_, err := n.Redrive("unknown")
+	require.ErrorIs(t, err, milestone.ErrDeliveryNotFound)
+
+	d, err = n.Redrive(d.ID)
+	require.NoError(t, err)
+	require.Equal(t, milestone.StatusPending, d.Status)
+	require.Eventually(t, func() bool { return len(rc.received()) == 1 }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		d, err := n.Delivery(d.ID)
+		return err == nil && d.Status == milestone.StatusDelivered
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = n.Redrive(d.ID)
+	require.ErrorIs(t, err, milestone.ErrNotDead)
+}
+
+func TestNotifier_Durable(t *testing.T) {
+	rc := &receiver{t: t}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	dir := t.TempDir()
+
+	// not running, the deliveries only reach the queue
+	n := newTestNotifier(t, srv.URL, Config{Dir: dir})
+	n.Notify(event.Event{Seq: 1}, []leader.Change{firstPlace})
+	n.Notify(event.Event{Seq: 2}, []leader.Change{firstPlace})
+	n.Close()
+	require.Len(t, n.Deliveries(milestone.StatusPending), 2)
+
+	// the next start sends them
+	n = newTestNotifier(t, srv.URL, Config{Dir: dir})
+	require.Len(t, n.Deliveries(milestone.StatusPending), 2)
+	run(t, n)
+	require.Eventually(t, func() bool { return len(rc.received()) == 2 }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return len(n.Deliveries(milestone.StatusPending)) == 0 }, time.Second, 5*time.Millisecond)
+
+	n = newTestNotifier(t, srv.URL, Config{Dir: dir})
+	require.Empty(t, n.Deliveries(milestone.StatusPending))
+	require.Empty(t, n.Deliveries(milestone.StatusDead))
+}
+
+func TestNotifier_Dropped(t *testing.T) {
+	n := newTestNotifier(t, "http://localhost:1", Config{Buffer: 1})
+	n.Notify(event.Event{}, []leader.Change{firstPlace, firstPlace, firstPlace})
+	require.Equal(t, 2.0, testutil.ToFloat64(n.metrics.Deliveries.WithLabelValues("partner", ResultDropped)))
+}
+
+func TestNotifier_Backoff(t *testing.T) {
+	n := newTestNotifier(t, "http://localhost:1", Config{Backoff: time.Second, MaxBackoff: 10 * time.Second})
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 10 * time.Second, 100: 10 * time.Second} {
+		d := n.backoff(attempts)
+		require.GreaterOrEqual(t, d, want, "attempt %d", attempts)
+		require.LessOrEqual(t, d, want+want/5, "attempt %d", attempts)
+	}
+}
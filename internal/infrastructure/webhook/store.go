@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"leaderboard-api/internal/domain/milestone"
+)
+
+const fileExt = ".json"
+
+// store - a file per undelivered delivery: <dir>/pending/<id>.json or
+// <dir>/dead/<id>.json, the delivered ones are removed.
+// Every file is written aside and renamed, a crash never leaves half of it.
+type store struct {
+	dir string
+}
+
+func openStore(dir string) (*store, error) {
+	for _, st := range []milestone.Status{milestone.StatusPending, milestone.StatusDead} {
+		if err := os.MkdirAll(filepath.Join(dir, string(st)), 0o755); err != nil {
+			return nil, fmt.Errorf("webhook queue: %w", err)
+		}
+	}
+
+	return &store{dir: dir}, nil
+}
+
+func (s *store) path(st milestone.Status, id string) string {
+	return filepath.Join(s.dir, string(st), id+fileExt)
+}
+
+func (s *store) save(d milestone.Delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	path := s.path(d.Status, d.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *store) remove(st milestone.Status, id string) error {
+	if err := os.Remove(s.path(st, id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// move - saves the delivery under its new status and removes the old file.
+func (s *store) move(from milestone.Status, d milestone.Delivery) error {
+	if err := s.save(d); err != nil {
+		return err
+	}
+
+	return s.remove(from, d.ID)
+}
+
+// load - pending and dead deliveries, the leftovers of an interrupted write are skipped.
+func (s *store) load() ([]milestone.Delivery, error) {
+	var ds []milestone.Delivery
+	for _, st := range []milestone.Status{milestone.StatusPending, milestone.StatusDead} {
+		entries, err := os.ReadDir(filepath.Join(s.dir, string(st)))
+		if err != nil {
+			return nil, fmt.Errorf("webhook queue: %w", err)
+		}
+		for _, e := range entries {
+			if !strings.HasSuffix(e.Name(), fileExt) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(s.dir, string(st), e.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("webhook queue: %w", err)
+			}
+			var d milestone.Delivery
+			if err := json.Unmarshal(data, &d); err != nil {
+				return nil, fmt.Errorf("webhook queue %s: %w", e.Name(), err)
+			}
+			d.Status = st
+			ds = append(ds, d)
+		}
+	}
+
+	return ds, nil
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/leader"
+	dto "leaderboard-api/internal/interface/api/rest/dto/admin"
+)
+
+// AdminController - ops endpoints, must not be exposed to the public network.
+type AdminController struct {
+	adminService ports.AdminService
+}
+
+func NewAdminController(m *http.ServeMux, adminService ports.AdminService) *AdminController {
+	ac := &AdminController{
+		adminService: adminService,
+	}
+
+	m.HandleFunc(http.MethodPost+Space+RouteAdmin+RouteSnapshot, ac.PostSnapshot)
+	m.HandleFunc(http.MethodGet+Space+RouteAdmin+RouteModel, ac.GetModel)
+	m.HandleFunc(http.MethodPost+Space+RouteAdmin+RouteRebuild, ac.PostRebuild)
+	m.HandleFunc(http.MethodGet+Space+RouteAdmin+RouteRebuild, ac.GetRebuild)
+
+	return ac
+}
+
+// PostSnapshot - takes a leaderboard snapshot on demand.
+func (ac *AdminController) PostSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap, err := ac.adminService.Snapshot(r.Context())
+	if errors.Is(err, leader.ErrSnapshotDisabled) {
+		http.Error(w, "snapshots are disabled (LEADERBOARD_SNAPSHOT_DIR is not set)", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to take a snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToSnapshot(snap))
+}
+
+// GetModel - the active version of the scoring models.
+func (ac *AdminController) GetModel(w http.ResponseWriter, r *http.Request) {
+	v := ac.adminService.Model(r.Context())
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToModel(v))
+}
+
+// PostRebuild - starts rebuilding the leaderboards from the event history,
+// GET /admin/rebuild reports the progress.
+func (ac *AdminController) PostRebuild(w http.ResponseWriter, r *http.Request) {
+	status, err := ac.adminService.Rebuild(r.Context())
+	switch {
+	case errors.Is(err, leader.ErrRebuildRunning):
+		http.Error(w, "a rebuild is already running", http.StatusConflict)
+		return
+	case errors.Is(err, leader.ErrNoHistory):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to start a rebuild", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(dto.ToRebuild(status))
+}
+
+func (ac *AdminController) GetRebuild(w http.ResponseWriter, r *http.Request) {
+	status := ac.adminService.RebuildStatus(r.Context())
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToRebuild(status))
+}
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/board"
+	dto "leaderboard-api/internal/interface/api/rest/dto/admin"
+)
+
+// BoardController - the named boards, an admin surface as well.
+// Their events and leaderboards are served by the events and leaderboard controllers.
+type BoardController struct {
+	boardService ports.BoardService
+}
+
+func NewBoardController(m *http.ServeMux, boardService ports.BoardService) *BoardController {
+	bc := &BoardController{
+		boardService: boardService,
+	}
+
+	boards := RouteAdmin + RouteBoards
+	m.HandleFunc(http.MethodGet+Space+boards, bc.GetBoards)
+	m.HandleFunc(http.MethodPost+Space+boards, bc.PostBoard)
+	m.HandleFunc(http.MethodGet+Space+boards+Slash+"{"+PathBoard+"}", bc.GetBoard)
+	m.HandleFunc(http.MethodPut+Space+boards+Slash+"{"+PathBoard+"}"+RouteModels, bc.PutModels)
+	m.HandleFunc(http.MethodDelete+Space+boards+Slash+"{"+PathBoard+"}", bc.DeleteBoard)
+
+	return bc
+}
+
+func (bc *BoardController) GetBoards(w http.ResponseWriter, r *http.Request) {
+	bs := bc.boardService.List(r.Context())
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToBoards(bs))
+}
+
+// PostBoard - creates a board and starts its pipeline, the board is served at once.
+func (bc *BoardController) PostBoard(w http.ResponseWriter, r *http.Request) {
+	var req dto.BoardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json request", http.StatusBadRequest)
+		return
+	}
+	b, err := dto.FromBoardRequest(req)
+	if err == nil {
+		b, err = bc.boardService.Create(r.Context(), b)
+	}
+	if err != nil {
+		boardError(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.ToBoard(b))
+}
+
+func (bc *BoardController) GetBoard(w http.ResponseWriter, r *http.Request) {
+	b, err := bc.boardService.Get(r.Context(), r.PathValue(PathBoard))
+	if err != nil {
+		boardError(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToBoard(b))
+}
+
+// PutModels - the body is the models document of the board, it scores the new events.
+func (bc *BoardController) PutModels(w http.ResponseWriter, r *http.Request) {
+	models, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(models) {
+		http.Error(w, "invalid json request", http.StatusBadRequest)
+		return
+	}
+
+	b, err := bc.boardService.SetModels(r.Context(), r.PathValue(PathBoard), models)
+	if err != nil {
+		boardError(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToBoard(b))
+}
+
+// DeleteBoard - drains the board and removes its data, the name is free afterwards.
+func (bc *BoardController) DeleteBoard(w http.ResponseWriter, r *http.Request) {
+	if err := bc.boardService.Delete(r.Context(), r.PathValue(PathBoard)); err != nil {
+		boardError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func boardError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, board.ErrInvalidBoard):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, board.ErrBoardNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, board.ErrBoardExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, board.ErrBoardsClosed):
+		w.Header().Set(HeaderRetryAfter, retryAfter)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "failed to manage the boards", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"leaderboard-api/internal/domain/board"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/milestone"
+	"leaderboard-api/internal/domain/model"
+	"leaderboard-api/internal/domain/season"
+)
+
+func ToSnapshot(s leader.Snapshot) Snapshot {
+	return Snapshot{
+		Path:    s.Path,
+		TakenAt: s.TakenAt,
+		Boards:  s.Boards,
+		Talents: s.Talents,
+		Bytes:   s.Size,
+	}
+}
+
+func ToModel(v model.Version) Model {
+	return Model{
+		Version:  v.Name,
+		LoadedAt: v.LoadedAt,
+		Default:  v.Default,
+		Skills:   v.Skills,
+	}
+}
+
+func ToRebuild(r leader.Rebuild) Rebuild {
+	out := Rebuild{
+		State:     string(r.State),
+		Total:     r.Total,
+		Processed: r.Processed,
+		Error:     r.Error,
+	}
+	if !r.StartedAt.IsZero() {
+		out.StartedAt = &r.StartedAt
+	}
+	if !r.FinishedAt.IsZero() {
+		out.FinishedAt = &r.FinishedAt
+	}
+
+	return out
+}
+
+func ToDelivery(d milestone.Delivery) Delivery {
+	m := d.Milestone
+	out := Delivery{
+		ID:        d.ID,
+		Webhook:   d.Webhook,
+		Status:    string(d.Status),
+		Attempts:  d.Attempts,
+		LastError: d.LastError,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		Milestone: Milestone{
+			Type:      string(m.Type),
+			N:         m.N,
+			Skill:     m.Skill,
+			TalentID:  m.TalentID,
+			Score:     m.Score,
+			PrevScore: m.PrevScore,
+			Rank:      m.Rank,
+			PrevRank:  m.PrevRank,
+			Seq:       m.Seq,
+			At:        m.At,
+		},
+	}
+	if d.Status == milestone.StatusPending && !d.NextAttempt.IsZero() {
+		out.NextAttemptAt = &d.NextAttempt
+	}
+
+	return out
+}
+
+func ToDeliveries(ds []milestone.Delivery) []Delivery {
+	out := make([]Delivery, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, ToDelivery(d))
+	}
+
+	return out
+}
+
+func FromBoardRequest(req BoardRequest) (board.Board, error) {
+	b := board.Board{
+		Name:        req.Name,
+		Aggregation: req.Aggregation,
+		AvgLast:     req.AvgLast,
+		TieBreak:    req.TieBreak,
+		RankType:    req.RankType,
+		Models:      req.Models,
+	}
+	if req.DecayHalfLife != "" {
+		d, err := time.ParseDuration(req.DecayHalfLife)
+		if err != nil {
+			return board.Board{}, fmt.Errorf("%w: decay_half_life: %w", board.ErrInvalidBoard, err)
+		}
+		b.DecayHalfLife = d
+	}
+	if req.Season != nil {
+		sc, err := fromSchedule(*req.Season)
+		if err != nil {
+			return board.Board{}, fmt.Errorf("%w: season: %w", board.ErrInvalidBoard, err)
+		}
+		b.Season = sc
+	}
+
+	return b, nil
+}
+
+func fromSchedule(s Schedule) (season.Schedule, error) {
+	sc := season.Schedule{Start: s.Start}
+	var err error
+	if sc.Length, err = time.ParseDuration(s.Length); err != nil {
+		return season.Schedule{}, fmt.Errorf("length: %w", err)
+	}
+	if sc.Length <= 0 {
+		return season.Schedule{}, errors.New("length must be > 0")
+	}
+	if s.Every != "" {
+		if sc.Every, err = time.ParseDuration(s.Every); err != nil {
+			return season.Schedule{}, fmt.Errorf("every: %w", err)
+		}
+	}
+
+	return sc, nil
+}
+
+func ToBoard(b board.Board) Board {
+	out := Board{
+		Name:        b.Name,
+		Aggregation: b.Aggregation,
+		AvgLast:     b.AvgLast,
+		TieBreak:    b.TieBreak,
+		RankType:    b.RankType,
+		Models:      b.Models,
+		CreatedAt:   b.CreatedAt,
+		UpdatedAt:   b.UpdatedAt,
+	}
+	if b.DecayHalfLife > 0 {
+		out.DecayHalfLife = b.DecayHalfLife.String()
+	}
+	if b.Season.Enabled() {
+		out.Season = &Schedule{Start: b.Season.Start, Length: b.Season.Length.String()}
+		if b.Season.Every > 0 {
+			out.Season.Every = b.Season.Every.String()
+		}
+	}
+
+	return out
+}
+
+func ToBoards(bs []board.Board) []Board {
+	out := make([]Board, 0, len(bs))
+	for _, b := range bs {
+		out = append(out, ToBoard(b))
+	}
+
+	return out
+}
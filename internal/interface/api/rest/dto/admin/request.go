@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type BoardRequest struct {
+	Name          string          `json:"name"`
+	Aggregation   string          `json:"aggregation,omitempty"`
+	AvgLast       int             `json:"avg_last,omitempty"`
+	DecayHalfLife string          `json:"decay_half_life,omitempty"`
+	TieBreak      string          `json:"tie_break,omitempty"`
+	RankType      string          `json:"rank_type,omitempty"`
+	Models        json.RawMessage `json:"models,omitempty"`
+	// Season - no seasons when omitted.
+	Season *Schedule `json:"season,omitempty"`
+}
+
+// Schedule - seasons of length from start, one every `every`(length by default).
+type Schedule struct {
+	Start  time.Time `json:"start"`
+	Length string    `json:"length"`
+	Every  string    `json:"every,omitempty"`
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Snapshot struct {
+	Path    string    `json:"path"`
+	TakenAt time.Time `json:"taken_at"`
+	Boards  int       `json:"boards"`
+	Talents int       `json:"talents"`
+	Bytes   int64     `json:"bytes"`
+}
+
+type Model struct {
+	Version  string            `json:"version"`
+	LoadedAt time.Time         `json:"loaded_at"`
+	Default  string            `json:"default"`
+	Skills   map[string]string `json:"skills"`
+}
+
+type Rebuild struct {
+	State      string     `json:"state"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Total      uint64     `json:"total"`
+	Processed  uint64     `json:"processed"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type Delivery struct {
+	ID            string     `json:"id"`
+	Webhook       string     `json:"webhook"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Milestone     Milestone  `json:"milestone"`
+}
+
+type Milestone struct {
+	Type      string    `json:"type"`
+	N         int       `json:"n,omitempty"`
+	Skill     string    `json:"skill,omitempty"`
+	TalentID  string    `json:"talent_id"`
+	Score     float64   `json:"score"`
+	PrevScore float64   `json:"prev_score"`
+	Rank      int       `json:"rank"`
+	PrevRank  int       `json:"prev_rank"`
+	Seq       uint64    `json:"seq,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+type Board struct {
+	Name          string          `json:"name"`
+	Aggregation   string          `json:"aggregation"`
+	AvgLast       int             `json:"avg_last,omitempty"`
+	DecayHalfLife string          `json:"decay_half_life,omitempty"`
+	TieBreak      string          `json:"tie_break,omitempty"`
+	RankType      string          `json:"rank_type,omitempty"`
+	Models        json.RawMessage `json:"models,omitempty"`
+	Season        *Schedule       `json:"season,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
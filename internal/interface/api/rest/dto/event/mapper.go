@@ -1,7 +1,12 @@
 package event
 
 import (
+	"errors"
+
+	"github.com/google/uuid"
+
 	"leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/season"
 )
 
 func FromRequest(r Request) *event.Event {
@@ -13,3 +18,58 @@ func FromRequest(r Request) *event.Event {
 		TS:        r.TS,
 	}
 }
+
+// FromRejection - an event outside of the season fails on its ts.
+func FromRejection(r *season.Rejection) *ValidationError {
+	return &ValidationError{
+		Message: season.ErrOutsideSeason.Error(),
+		Fields:  []FieldError{{Field: "ts", Reason: r.Reason, Message: r.Message}},
+	}
+}
+
+// BatchEntry - a decoded item of a batch, Err if it's invalid.
+type BatchEntry struct {
+	Request Request
+	Err     error
+}
+
+// ToBatchResponse - results of the valid entries in their order.
+func ToBatchResponse(entries []BatchEntry, results []event.Result) BatchResponse {
+	resp := BatchResponse{Results: make([]BatchItem, len(entries))}
+	next := 0
+	for i, entry := range entries {
+		item := BatchItem{Index: i}
+		if entry.Request.EventID != uuid.Nil {
+			id := entry.Request.EventID
+			item.EventID = &id
+		}
+		if entry.Err != nil {
+			item.Status, item.Reason = string(event.StatusInvalid), entry.Err.Error()
+			var invalid *ValidationError
+			if errors.As(entry.Err, &invalid) {
+				item.Errors = invalid.Fields
+			}
+		} else {
+			item.Status, item.Reason = string(results[next].Status), results[next].Reason
+			var rejected *season.Rejection
+			if errors.As(results[next].Err, &rejected) {
+				item.Errors = FromRejection(rejected).Fields
+			}
+			next++
+		}
+
+		switch event.Status(item.Status) {
+		case event.StatusAccepted:
+			resp.Accepted++
+		case event.StatusDuplicate:
+			resp.Duplicate++
+		case event.StatusInvalid:
+			resp.Invalid++
+		case event.StatusRetry:
+			resp.Retry++
+		}
+		resp.Results[i] = item
+	}
+
+	return resp
+}
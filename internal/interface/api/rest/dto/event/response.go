@@ -1 +1,46 @@
 package event
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// FieldError - a failed validation rule of a field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// ValidationError - every failed field of an event, the 400 body.
+type ValidationError struct {
+	Message string       `json:"error"`
+	Fields  []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type BatchItem struct {
+	// Index - position of the event in the batch(array element or NDJSON line), from 0.
+	Index   int        `json:"index"`
+	EventID *uuid.UUID `json:"event_id,omitempty"`
+	Status  string     `json:"status"`
+	Reason  string     `json:"reason,omitempty"`
+	// Errors - the failed fields of an invalid event.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type BatchResponse struct {
+	Accepted  int         `json:"accepted"`
+	Duplicate int         `json:"duplicate"`
+	Invalid   int         `json:"invalid"`
+	Retry     int         `json:"retry"`
+	Results   []BatchItem `json:"results"`
+}
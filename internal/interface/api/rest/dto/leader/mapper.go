@@ -0,0 +1,69 @@
+package leader
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"leaderboard-api/internal/domain/leader"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor - the wire form of leader.Cursor.
+// Clients must treat the encoded value as opaque.
+type cursor struct {
+	Key      float64 `json:"k"`
+	Achieved int64   `json:"a,omitempty"`
+	TalentID string  `json:"t"`
+}
+
+func ToPage(p leader.Page) Page {
+	return Page{
+		Leaders:    p.Leaders,
+		NextCursor: EncodeCursor(p.Next),
+	}
+}
+
+func ToUpdate(u leader.Update) Update {
+	if u.Snapshot != nil {
+		return Update{Type: UpdateSnapshot, ID: u.ID, Leaders: &u.Snapshot}
+	}
+	changes := make([]Change, len(u.Changes))
+	for i, c := range u.Changes {
+		changes[i] = Change{Skill: c.Skill, TalentID: c.TalentID, Score: c.Score, Rank: c.Rank, PrevRank: c.PrevRank}
+	}
+
+	return Update{Type: UpdateChange, ID: u.ID, Changes: changes}
+}
+
+func ToClosed(err error) Update {
+	u := Update{Type: UpdateClosed}
+	if err != nil {
+		u.Error = err.Error()
+	}
+
+	return u
+}
+
+func EncodeCursor(c *leader.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	b, _ := json.Marshal(cursor{Key: c.Key, Achieved: c.Achieved, TalentID: c.TalentID})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*leader.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.TalentID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &leader.Cursor{Key: c.Key, Achieved: c.Achieved, TalentID: c.TalentID}, nil
+}
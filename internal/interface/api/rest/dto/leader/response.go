@@ -0,0 +1,40 @@
+package leader
+
+import (
+	"leaderboard-api/internal/domain/leader"
+)
+
+type Page struct {
+	Leaders    leader.Leaders `json:"leaders"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	// RankType - ordinal, dense or competition, absent on the archived standings.
+	RankType string `json:"rank_type,omitempty"`
+}
+
+// Update types of the leaderboard stream.
+const (
+	UpdateSnapshot = "snapshot"
+	UpdateChange   = "change"
+	// UpdateClosed - the last message, Error tells why the server ended the stream.
+	UpdateClosed = "closed"
+)
+
+// Update - a message of the leaderboard stream, a snapshot replaces the view
+// of the subscriber, the changes are applied to it.
+type Update struct {
+	Type string `json:"type"`
+	ID   uint64 `json:"id,omitempty"`
+	// Leaders - set on a snapshot only, even an empty one.
+	Leaders *leader.Leaders `json:"leaders,omitempty"`
+	Changes []Change        `json:"changes,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Change - prev_rank 0 - new on the board.
+type Change struct {
+	Skill    string  `json:"skill,omitempty"`
+	TalentID string  `json:"talent_id"`
+	Score    float64 `json:"score"`
+	Rank     int     `json:"rank"`
+	PrevRank int     `json:"prev_rank"`
+}
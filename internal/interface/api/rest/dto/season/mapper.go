@@ -0,0 +1,30 @@
+package season
+
+import (
+	"leaderboard-api/internal/domain/season"
+)
+
+func ToSeason(i season.Info) Season {
+	s := Season{
+		ID:      i.ID,
+		Start:   i.Start,
+		End:     i.End,
+		State:   string(i.State),
+		Talents: i.Talents,
+	}
+	if !i.ArchivedAt.IsZero() {
+		at := i.ArchivedAt
+		s.ArchivedAt = &at
+	}
+
+	return s
+}
+
+func ToSeasons(is []season.Info) []Season {
+	out := make([]Season, 0, len(is))
+	for _, i := range is {
+		out = append(out, ToSeason(i))
+	}
+
+	return out
+}
@@ -0,0 +1,15 @@
+package season
+
+import (
+	"time"
+)
+
+type Season struct {
+	ID    int       `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// State - running, ended(not archived yet) or archived.
+	State      string     `json:"state"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	Talents    int        `json:"talents"`
+}
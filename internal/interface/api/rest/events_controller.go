@@ -1,30 +1,80 @@
 package rest
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 
 	"leaderboard-api/internal/application/ports"
+	domain "leaderboard-api/internal/domain/event"
+	"leaderboard-api/internal/domain/season"
 	"leaderboard-api/internal/interface/api/rest/dto/event"
+	"leaderboard-api/internal/interface/api/rest/validation"
 )
 
+// retryAfter - seconds a producer should wait after 429/503, the queue
+// is drained by the scorer pool in well under a second when it's healthy.
+const retryAfter = "1"
+
+var errTooManyEvents = errors.New("too many events in the batch")
+
+// BatchConfig - limits of POST /events:batch.
+type BatchConfig struct {
+	MaxEvents int
+	MaxBytes  int64
+}
+
 type EventsController struct {
 	eventService ports.EventService
+	boardService ports.BoardService
+	validator    *validation.EventValidator
+	batch        BatchConfig
 }
 
-func NewEventController(m *http.ServeMux, eventService ports.EventService) *EventsController {
+func NewEventController(
+	m *http.ServeMux,
+	eventService ports.EventService,
+	boardService ports.BoardService,
+	validator *validation.EventValidator,
+	batch BatchConfig,
+) *EventsController {
 	ec := &EventsController{
 		eventService: eventService,
+		boardService: boardService,
+		validator:    validator,
+		batch:        batch,
 	}
 
 	m.HandleFunc(http.MethodPost+Space+RouteEvents, ec.PostEventHandler)
+	m.HandleFunc(http.MethodPost+Space+RouteEventsBatch, ec.PostBatchHandler)
 	m.HandleFunc(http.MethodGet+Space+RouteSeed, ec.SeedHandler)
 
+	board := RouteBoards + Slash + "{" + PathBoard + "}"
+	m.HandleFunc(http.MethodPost+Space+board+RouteEvents, ec.PostBoardEventHandler)
+	m.HandleFunc(http.MethodPost+Space+board+RouteEventsBatch, ec.PostBoardBatchHandler)
+
 	return ec
 }
 
 func (ec *EventsController) PostEventHandler(w http.ResponseWriter, r *http.Request) {
+	ec.postEvent(w, r, ec.eventService)
+}
+
+// PostBoardEventHandler - an event of a named board.
+func (ec *EventsController) PostBoardEventHandler(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := ec.boardEvents(w, r); ok {
+		ec.postEvent(w, r, svc)
+	}
+}
+
+func (ec *EventsController) postEvent(w http.ResponseWriter, r *http.Request, svc ports.EventService) {
 	var req event.Request
 	// for big performance and to avoid reflection under the hood
 	// better to use codegen for marshal/unmarshal for example "easyjson" pkg
@@ -32,14 +82,30 @@ func (ec *EventsController) PostEventHandler(w http.ResponseWriter, r *http.Requ
 		http.Error(w, "invalid json request", http.StatusBadRequest)
 		return
 	}
-	// example
-	//if err := validate(req); err != nil {
-	//	http.Error(w, "validation error", http.StatusBadRequest)
-	//	return
-	//}
+	if err := ec.validator.Validate(req); err != nil {
+		w.Header().Set(HeaderContentType, ContentTypeJSON)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
 
-	duplicate, err := ec.eventService.Create(r.Context(), event.FromRequest(req))
-	if err != nil {
+	duplicate, err := svc.Create(r.Context(), event.FromRequest(req))
+	var rejected *season.Rejection
+	switch {
+	case errors.As(err, &rejected):
+		w.Header().Set(HeaderContentType, ContentTypeJSON)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(event.FromRejection(rejected))
+		return
+	case errors.Is(err, domain.ErrQueueFull):
+		w.Header().Set(HeaderRetryAfter, retryAfter)
+		http.Error(w, "too many events, retry later", http.StatusTooManyRequests)
+		return
+	case errors.Is(err, domain.ErrQueueTimeout), errors.Is(err, domain.ErrQueueClosed), errors.Is(err, context.DeadlineExceeded):
+		w.Header().Set(HeaderRetryAfter, retryAfter)
+		http.Error(w, "the service is overloaded, retry later", http.StatusServiceUnavailable)
+		return
+	case err != nil:
 		http.Error(w, "failed to create an event", http.StatusInternalServerError)
 		return
 	}
@@ -51,6 +117,130 @@ func (ec *EventsController) PostEventHandler(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusOK)
 }
 
+// PostBatchHandler - a JSON array of events or NDJSON(Content-Type: application/x-ndjson),
+// every event is deduplicated and accepted on its own, the response has a result per event.
+// An invalid event doesn't fail the batch, a malformed body or a too big one does.
+func (ec *EventsController) PostBatchHandler(w http.ResponseWriter, r *http.Request) {
+	ec.postBatch(w, r, ec.eventService)
+}
+
+// PostBoardBatchHandler - a batch of events of a named board.
+func (ec *EventsController) PostBoardBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := ec.boardEvents(w, r); ok {
+		ec.postBatch(w, r, svc)
+	}
+}
+
+func (ec *EventsController) postBatch(w http.ResponseWriter, r *http.Request, svc ports.EventService) {
+	body := http.MaxBytesReader(w, r.Body, ec.batch.MaxBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(HeaderContentType))
+
+	var entries []event.BatchEntry
+	var err error
+	if mediaType == ContentTypeNDJSON {
+		entries, err = ec.decodeNDJSON(body)
+	} else {
+		entries, err = ec.decodeJSONArray(body)
+	}
+	var tooBig *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooBig):
+		http.Error(w, fmt.Sprintf("batch is bigger than %d bytes", ec.batch.MaxBytes), http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, errTooManyEvents):
+		http.Error(w, fmt.Sprintf("batch has more than %d events", ec.batch.MaxEvents), http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		http.Error(w, "invalid batch: "+err.Error(), http.StatusBadRequest)
+		return
+	case len(entries) == 0:
+		http.Error(w, "empty batch", http.StatusBadRequest)
+		return
+	}
+
+	valid := make([]*domain.Event, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Err == nil {
+			valid = append(valid, event.FromRequest(entry.Request))
+		}
+	}
+	resp := event.ToBatchResponse(entries, svc.CreateBatch(r.Context(), valid))
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	if resp.Retry > 0 {
+		w.Header().Set(HeaderRetryAfter, retryAfter)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// boardEvents - the events of the board in the path, 404 for an unknown one.
+func (ec *EventsController) boardEvents(w http.ResponseWriter, r *http.Request) (ports.EventService, bool) {
+	svc, err := ec.boardService.Events(r.Context(), r.PathValue(PathBoard))
+	if err != nil {
+		boardError(w, err)
+		return nil, false
+	}
+
+	return svc, true
+}
+
+func (ec *EventsController) decodeJSONArray(r io.Reader) ([]event.BatchEntry, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, errors.New("a JSON array of events is expected")
+	}
+
+	var entries []event.BatchEntry
+	for dec.More() {
+		if len(entries) == ec.batch.MaxEvents {
+			return nil, errTooManyEvents
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		entries = append(entries, ec.decodeEntry(raw))
+	}
+	// the closing bracket
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// decodeNDJSON - an event per line, blank lines are skipped.
+func (ec *EventsController) decodeNDJSON(r io.Reader) ([]event.BatchEntry, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), int(ec.batch.MaxBytes)+1)
+
+	var entries []event.BatchEntry
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(entries) == ec.batch.MaxEvents {
+			return nil, errTooManyEvents
+		}
+		entries = append(entries, ec.decodeEntry(line))
+	}
+
+	return entries, sc.Err()
+}
+
+func (ec *EventsController) decodeEntry(raw []byte) event.BatchEntry {
+	var req event.Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return event.BatchEntry{Err: fmt.Errorf("invalid json: %w", err)}
+	}
+
+	return event.BatchEntry{Request: req, Err: ec.validator.Validate(req)}
+}
+
 // SeedHandler - En extra endpoint to seed real N events
 func (ec *EventsController) SeedHandler(w http.ResponseWriter, r *http.Request) {
 	cnt := defaultLimit
@@ -2,54 +2,133 @@ package rest
 
 import (
 	"encoding/json"
-	"leaderboard-api/internal/application/ports"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/leader"
+	dto "leaderboard-api/internal/interface/api/rest/dto/leader"
 )
 
 const (
-	defaultLimit = 10
-	maxLimit     = 100
+	defaultLimit  = 10
+	maxLimit      = 100
+	defaultRadius = 5
+	maxRadius     = 50
+
+	invalidWindow = "invalid window (must be all, daily, weekly, monthly or rolling)"
+
+	defaultStreamTop = 10
+	defaultHeartbeat = 15 * time.Second
 )
 
+// StreamConfig - Heartbeat keeps the idle streams alive behind proxies.
+type StreamConfig struct {
+	Heartbeat time.Duration
+}
+
 type LeaderboardController struct {
-	lbService ports.LeaderboardService
+	lbService    ports.LeaderboardService
+	boardService ports.BoardService
+	heartbeat    time.Duration
 }
 
 func NewLeaderboardController(
 	m *http.ServeMux,
 	leaderboard ports.LeaderboardService,
+	boardService ports.BoardService,
+	stream StreamConfig,
 ) *LeaderboardController {
+	if stream.Heartbeat <= 0 {
+		stream.Heartbeat = defaultHeartbeat
+	}
 	ec := &LeaderboardController{
-		lbService: leaderboard,
+		lbService:    leaderboard,
+		boardService: boardService,
+		heartbeat:    stream.Heartbeat,
 	}
 
 	m.HandleFunc(http.MethodGet+Space+RouteLeaderboard, ec.GetBboard)
+	m.HandleFunc(http.MethodGet+Space+RouteLeaderboard+RouteStream, ec.Stream)
+	m.HandleFunc(http.MethodGet+Space+RouteLeaderboard+RouteStream+RouteWebSocket, ec.StreamWebSocket)
 	m.HandleFunc(http.MethodGet+Space+RouteRank+Slash, ec.GetRankByID)
+	m.HandleFunc(http.MethodGet+Space+RouteRank+Slash+"{"+PathID+"}"+RouteAround, ec.GetAround)
+
+	board := RouteBoards + Slash + "{" + PathBoard + "}"
+	m.HandleFunc(http.MethodGet+Space+board+RouteLeaderboard, ec.GetBoardLeaderboard)
+	m.HandleFunc(http.MethodGet+Space+board+RouteRank+Slash+"{"+PathID+"}", ec.GetBoardRankByID)
+	m.HandleFunc(http.MethodGet+Space+board+RouteRank+Slash+"{"+PathID+"}"+RouteAround, ec.GetBoardAround)
 
 	return ec
 }
 
+// GetBboard - supports two ways of paging:
+// "offset" to jump to any page and "cursor"(next_cursor of the previous page)
+// to stream through the whole board without skipping or repeating rows
+// while scores are changing.
 func (lc *LeaderboardController) GetBboard(w http.ResponseWriter, r *http.Request) {
-	limit := defaultLimit
-	if s := r.URL.Query().Get("limit"); s != "" {
+	lc.getBoard(w, r, lc.lbService)
+}
+
+// GetBoardLeaderboard - GetBboard of a named board.
+func (lc *LeaderboardController) GetBoardLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := lc.board(w, r); ok {
+		lc.getBoard(w, r, svc)
+	}
+}
+
+func (lc *LeaderboardController) getBoard(w http.ResponseWriter, r *http.Request, svc ports.LeaderboardService) {
+	scope, ok := scopeFromQuery(r)
+	if !ok {
+		http.Error(w, invalidWindow, http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	q := leader.Query{Scope: scope, Limit: defaultLimit}
+	if s := query.Get("limit"); s != "" {
 		v, err := strconv.Atoi(s)
 		if err != nil || v <= 0 || v > maxLimit {
 			http.Error(w, "invalid limit (must be 1..100)", http.StatusBadRequest)
 			return
 		}
-		limit = v
+		q.Limit = v
+	}
+	if s := query.Get("offset"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			http.Error(w, "invalid offset (must be >= 0)", http.StatusBadRequest)
+			return
+		}
+		q.Offset = v
+	}
+	if s := query.Get("cursor"); s != "" {
+		if query.Has("offset") {
+			http.Error(w, "offset and cursor are mutually exclusive", http.StatusBadRequest)
+			return
+		}
+		c, err := dto.DecodeCursor(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.After = c
 	}
 
-	leaders, err := lc.lbService.GetBboard(r.Context(), limit)
+	page, err := svc.GetBboard(r.Context(), q)
 	if err != nil {
 		http.Error(w, "failed to get a leaderboard", http.StatusInternalServerError)
 		return
 	}
 
+	resp := dto.ToPage(page)
+	resp.RankType = string(svc.RankType(r.Context()))
 	w.Header().Set(HeaderContentType, ContentTypeJSON)
-	if err := json.NewEncoder(w).Encode(leaders); err != nil {
+	w.Header().Set(HeaderRankType, resp.RankType)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
 	}
 }
@@ -66,7 +145,24 @@ func (lc *LeaderboardController) GetRankByID(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	leader, err := lc.lbService.GetRankByID(r.Context(), id)
+	lc.getRank(w, r, lc.lbService, id)
+}
+
+// GetBoardRankByID - GetRankByID of a named board.
+func (lc *LeaderboardController) GetBoardRankByID(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := lc.board(w, r); ok {
+		lc.getRank(w, r, svc, r.PathValue(PathID))
+	}
+}
+
+func (lc *LeaderboardController) getRank(w http.ResponseWriter, r *http.Request, svc ports.LeaderboardService, id string) {
+	scope, ok := scopeFromQuery(r)
+	if !ok {
+		http.Error(w, invalidWindow, http.StatusBadRequest)
+		return
+	}
+
+	leader, err := svc.GetRankByID(r.Context(), scope, id)
 	if err != nil {
 		http.Error(w, "failed to get a rank", http.StatusInternalServerError)
 		return
@@ -77,7 +173,278 @@ func (lc *LeaderboardController) GetRankByID(w http.ResponseWriter, r *http.Requ
 	}
 
 	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.Header().Set(HeaderRankType, string(svc.RankType(r.Context())))
 	if err := json.NewEncoder(w).Encode(leader); err != nil {
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
 	}
 }
+
+// GetAround - the talent's neighborhood: radius leaders above and below.
+func (lc *LeaderboardController) GetAround(w http.ResponseWriter, r *http.Request) {
+	lc.getAround(w, r, lc.lbService)
+}
+
+// GetBoardAround - GetAround of a named board.
+func (lc *LeaderboardController) GetBoardAround(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := lc.board(w, r); ok {
+		lc.getAround(w, r, svc)
+	}
+}
+
+func (lc *LeaderboardController) getAround(w http.ResponseWriter, r *http.Request, svc ports.LeaderboardService) {
+	id := r.PathValue(PathID)
+	if id == "" {
+		http.Error(w, "Invalid or missing ID", http.StatusBadRequest)
+		return
+	}
+	radius := defaultRadius
+	if s := r.URL.Query().Get("radius"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 || v > maxRadius {
+			http.Error(w, "invalid radius (must be 0..50)", http.StatusBadRequest)
+			return
+		}
+		radius = v
+	}
+
+	scope, ok := scopeFromQuery(r)
+	if !ok {
+		http.Error(w, invalidWindow, http.StatusBadRequest)
+		return
+	}
+
+	leaders, err := svc.GetAround(r.Context(), scope, id, radius)
+	if err != nil {
+		http.Error(w, "failed to get a neighborhood", http.StatusInternalServerError)
+		return
+	}
+	if len(leaders) == 0 {
+		http.Error(w, "leader not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.Header().Set(HeaderRankType, string(svc.RankType(r.Context())))
+	if err := json.NewEncoder(w).Encode(leaders); err != nil {
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Stream - Server-Sent Events of the all-time board: a snapshot of the watched view,
+// then its changes. A reconnecting client sends Last-Event-ID and gets the missed
+// changes, or a new snapshot when they are gone.
+func (lc *LeaderboardController) Stream(w http.ResponseWriter, r *http.Request) {
+	watch, ok := watchFromQuery(w, r)
+	if !ok {
+		return
+	}
+	lastID, err := lastEventID(r, r.Header.Get(HeaderLastEventID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub, ok := lc.subscribe(w, r, watch, lastID)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// a stream outlives any write timeout of the server
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set(HeaderContentType, ContentTypeSSE)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(lc.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case u := <-sub.Updates():
+			err = writeSSE(w, dto.ToUpdate(u))
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case <-sub.Done():
+			_ = writeSSE(w, dto.ToClosed(sub.Err()))
+			_ = rc.Flush()
+			return
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// StreamWebSocket - the same stream over a WebSocket, one JSON message per update,
+// a reconnecting client sends ?last_event_id=.
+func (lc *LeaderboardController) StreamWebSocket(w http.ResponseWriter, r *http.Request) {
+	watch, ok := watchFromQuery(w, r)
+	if !ok {
+		return
+	}
+	lastID, err := lastEventID(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub, ok := lc.subscribe(w, r, watch, lastID)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	ws, err := upgradeWebSocket(w, r)
+	if errors.Is(err, errNotWebSocket) {
+		http.Error(w, err.Error(), http.StatusUpgradeRequired)
+		return
+	}
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	// the client is gone when it stops reading
+	gone := make(chan struct{})
+	go func() {
+		ws.readLoop()
+		close(gone)
+	}()
+
+	heartbeat := time.NewTicker(lc.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case u := <-sub.Updates():
+			err = writeWS(ws, dto.ToUpdate(u))
+		case <-heartbeat.C:
+			err = ws.write(wsPing, nil)
+		case <-sub.Done():
+			_ = writeWS(ws, dto.ToClosed(sub.Err()))
+			code := uint16(wsGoingAway)
+			if errors.Is(sub.Err(), leader.ErrSlowConsumer) {
+				code = wsPolicy
+			}
+			_ = ws.close(code, sub.Err().Error())
+			return
+		case <-gone:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// board - the leaderboard of the board in the path, 404 for an unknown one.
+func (lc *LeaderboardController) board(w http.ResponseWriter, r *http.Request) (ports.LeaderboardService, bool) {
+	svc, err := lc.boardService.Leaderboard(r.Context(), r.PathValue(PathBoard))
+	if err != nil {
+		boardError(w, err)
+		return nil, false
+	}
+
+	return svc, true
+}
+
+func (lc *LeaderboardController) subscribe(w http.ResponseWriter, r *http.Request, watch leader.Watch, lastID uint64) (ports.LBSubscription, bool) {
+	sub, err := lc.lbService.Subscribe(r.Context(), watch, lastID)
+	switch {
+	case errors.Is(err, leader.ErrInvalidWatch):
+		http.Error(w, fmt.Sprintf("%s (top 1..%d or up to %d talents)", err, leader.MaxWatchTop, leader.MaxWatchTalents), http.StatusBadRequest)
+		return nil, false
+	case errors.Is(err, leader.ErrStreamClosed):
+		w.Header().Set(HeaderRetryAfter, retryAfter)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return nil, false
+	case err != nil:
+		http.Error(w, "failed to subscribe", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return sub, true
+}
+
+// watchFromQuery - "top" or "talents"(comma separated) of the all-time board
+// of "skill", the top 10 by default.
+func watchFromQuery(w http.ResponseWriter, r *http.Request) (leader.Watch, bool) {
+	query := r.URL.Query()
+	if window, ok := leader.ParseWindow(query.Get("window")); !ok || window != leader.WindowAll {
+		http.Error(w, "streams follow the all-time boards only", http.StatusBadRequest)
+		return leader.Watch{}, false
+	}
+	watch := leader.Watch{Skill: query.Get("skill")}
+	for _, id := range strings.Split(query.Get("talents"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			watch.Talents = append(watch.Talents, id)
+		}
+	}
+	switch s := query.Get("top"); {
+	case s != "":
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "invalid top", http.StatusBadRequest)
+			return leader.Watch{}, false
+		}
+		watch.TopN = v
+	case len(watch.Talents) == 0:
+		watch.TopN = defaultStreamTop
+	}
+
+	return watch, true
+}
+
+// lastEventID - the header, or the "last_event_id" query parameter for the clients
+// that can't set it.
+func lastEventID(r *http.Request, header string) (uint64, error) {
+	s := header
+	if s == "" {
+		s = r.URL.Query().Get("last_event_id")
+	}
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid last event id")
+	}
+
+	return id, nil
+}
+
+func writeSSE(w http.ResponseWriter, u dto.Update) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	if u.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", u.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", u.Type, b)
+
+	return err
+}
+
+func writeWS(ws *wsConn, u dto.Update) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	return ws.write(wsText, b)
+}
+
+// scopeFromQuery - "skill" switches any leaderboard endpoint to the per-skill board
+// and "window" to the board of a time window.
+func scopeFromQuery(r *http.Request) (leader.Scope, bool) {
+	query := r.URL.Query()
+	window, ok := leader.ParseWindow(query.Get("window"))
+
+	return leader.Scope{Skill: query.Get("skill"), Window: window}, ok
+}
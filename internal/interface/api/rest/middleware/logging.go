@@ -21,6 +21,11 @@ func (ww *wrappedWriter) WriteHeader(code int) {
 	ww.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap - lets http.ResponseController flush and hijack the streams.
+func (ww *wrappedWriter) Unwrap() http.ResponseWriter {
+	return ww.ResponseWriter
+}
+
 func RequestLog(logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -6,14 +6,41 @@ const (
 
 	HeaderContentType = "Content-Type"
 	ContentTypeJSON   = "application/json"
+	ContentTypeNDJSON = "application/x-ndjson"
+	HeaderRetryAfter  = "Retry-After"
+	HeaderLastEventID = "Last-Event-ID"
+	ContentTypeSSE    = "text/event-stream"
+	// HeaderRankType - how the tied talents are ranked: ordinal, dense or competition.
+	HeaderRankType = "X-Rank-Type"
 
 	// api
 	RouteEvents      = "/events"
+	RouteEventsBatch = "/events:batch"
 	RouteLeaderboard = "/leaderboard"
 	RouteRank        = "/rank"
+	RouteAround      = "/around"
+	RouteStream      = "/stream"
+	RouteWebSocket   = "/ws"
+
+	RouteBoards  = "/boards"
+	RouteModels  = "/models"
+	RouteSeasons = "/seasons"
+
+	PathID    = "id"
+	PathBoard = "board"
 
 	RouteSeed = "/seed"
 
+	// admin
+	RouteAdmin    = "/admin"
+	RouteSnapshot = "/snapshot"
+	RouteModel    = "/model"
+	RouteRebuild  = "/rebuild"
+
+	RouteWebhooks   = "/webhooks"
+	RouteDeliveries = "/deliveries"
+	RouteRetry      = "/retry"
+
 	// ops
 	RouteHealth  = "/healthz"
 	RouteMetrics = "/metrics"
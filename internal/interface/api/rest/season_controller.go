@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/leader"
+	"leaderboard-api/internal/domain/season"
+	leaderdto "leaderboard-api/internal/interface/api/rest/dto/leader"
+	dto "leaderboard-api/internal/interface/api/rest/dto/season"
+)
+
+// SeasonController - the seasons of the default board and of the named ones.
+type SeasonController struct {
+	seasonService ports.SeasonService
+	boardService  ports.BoardService
+}
+
+func NewSeasonController(
+	m *http.ServeMux,
+	seasonService ports.SeasonService,
+	boardService ports.BoardService,
+) *SeasonController {
+	sc := &SeasonController{
+		seasonService: seasonService,
+		boardService:  boardService,
+	}
+
+	standings := RouteSeasons + Slash + "{" + PathID + "}" + RouteLeaderboard
+	m.HandleFunc(http.MethodGet+Space+RouteSeasons, sc.GetSeasons)
+	m.HandleFunc(http.MethodGet+Space+standings, sc.GetStandings)
+
+	board := RouteBoards + Slash + "{" + PathBoard + "}"
+	m.HandleFunc(http.MethodGet+Space+board+RouteSeasons, sc.GetBoardSeasons)
+	m.HandleFunc(http.MethodGet+Space+board+standings, sc.GetBoardStandings)
+
+	return sc
+}
+
+// GetSeasons - the running season and the archived ones, the latest first.
+func (sc *SeasonController) GetSeasons(w http.ResponseWriter, r *http.Request) {
+	sc.getSeasons(w, r, sc.seasonService)
+}
+
+// GetBoardSeasons - GetSeasons of a named board.
+func (sc *SeasonController) GetBoardSeasons(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := sc.board(w, r); ok {
+		sc.getSeasons(w, r, svc)
+	}
+}
+
+func (sc *SeasonController) getSeasons(w http.ResponseWriter, r *http.Request, svc ports.SeasonService) {
+	infos, err := svc.Seasons(r.Context())
+	if err != nil {
+		seasonError(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	json.NewEncoder(w).Encode(dto.ToSeasons(infos))
+}
+
+// GetStandings - the final standings of an archived season,
+// the global all-time board or the one of the skill.
+func (sc *SeasonController) GetStandings(w http.ResponseWriter, r *http.Request) {
+	sc.getStandings(w, r, sc.seasonService)
+}
+
+// GetBoardStandings - GetStandings of a named board.
+func (sc *SeasonController) GetBoardStandings(w http.ResponseWriter, r *http.Request) {
+	if svc, ok := sc.board(w, r); ok {
+		sc.getStandings(w, r, svc)
+	}
+}
+
+func (sc *SeasonController) getStandings(w http.ResponseWriter, r *http.Request, svc ports.SeasonService) {
+	id, err := strconv.Atoi(r.PathValue(PathID))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid season id (must be > 0)", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	q := leader.Query{Scope: leader.Scope{Skill: query.Get("skill")}, Limit: defaultLimit}
+	if s := query.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 || v > maxLimit {
+			http.Error(w, "invalid limit (must be 1..100)", http.StatusBadRequest)
+			return
+		}
+		q.Limit = v
+	}
+	if s := query.Get("offset"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			http.Error(w, "invalid offset (must be >= 0)", http.StatusBadRequest)
+			return
+		}
+		q.Offset = v
+	}
+
+	page, err := svc.Standings(r.Context(), id, q)
+	if err != nil {
+		seasonError(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	json.NewEncoder(w).Encode(leaderdto.ToPage(page))
+}
+
+// board - the seasons of the board in the path, 404 for an unknown one.
+func (sc *SeasonController) board(w http.ResponseWriter, r *http.Request) (ports.SeasonService, bool) {
+	svc, err := sc.boardService.Seasons(r.Context(), r.PathValue(PathBoard))
+	if errors.Is(err, season.ErrSeasonsDisabled) {
+		seasonError(w, err)
+		return nil, false
+	}
+	if err != nil {
+		boardError(w, err)
+		return nil, false
+	}
+
+	return svc, true
+}
+
+func seasonError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, season.ErrSeasonsDisabled):
+		http.Error(w, "seasons are disabled, the board has no season schedule", http.StatusConflict)
+	case errors.Is(err, season.ErrSeasonNotFound):
+		http.Error(w, "season not found or not archived yet", http.StatusNotFound)
+	default:
+		http.Error(w, "failed to get the seasons", http.StatusInternalServerError)
+	}
+}
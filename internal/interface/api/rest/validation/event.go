@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"leaderboard-api/internal/interface/api/rest/dto/event"
+)
+
+// Rejection reasons, a label of the rejected events metric.
+const (
+	ReasonRequired     = "required"
+	ReasonNotFinite    = "not_finite"
+	ReasonUnknownSkill = "unknown_skill"
+	ReasonOutOfRange   = "out_of_range"
+	ReasonFuture       = "future"
+	ReasonPattern      = "pattern"
+)
+
+// anySkill - the range key of the skills without their own range.
+const anySkill = "*"
+
+type Range struct {
+	Min, Max float64
+}
+
+// Rules - zero values switch a rule off.
+type Rules struct {
+	// Skills - allowed skills, empty - any skill or none.
+	Skills []string
+	// Ranges - raw metric range per skill, "*" for the rest.
+	Ranges map[string]Range
+	// MaxClockSkew - how far in the future ts may be.
+	MaxClockSkew time.Duration
+	TalentID     *regexp.Regexp
+}
+
+// EventValidator - checks dto/event.Request by the rules, every failed
+// field is reported, every rejected event is counted once per reason.
+type EventValidator struct {
+	rules   Rules
+	now     func() time.Time
+	metrics *prometheus.CounterVec
+}
+
+func NewEventValidator(rules Rules, metrics *prometheus.CounterVec) *EventValidator {
+	return &EventValidator{rules: rules, now: time.Now, metrics: metrics}
+}
+
+// Validate - nil or *event.ValidationError.
+func (v *EventValidator) Validate(r event.Request) error {
+	var fields []event.FieldError
+	fail := func(field, reason, format string, args ...any) {
+		fields = append(fields, event.FieldError{Field: field, Reason: reason, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if r.EventID == uuid.Nil {
+		fail("event_id", ReasonRequired, "event_id is required")
+	}
+
+	switch {
+	case r.TalentID == "":
+		fail("talent_id", ReasonRequired, "talent_id is required")
+	case v.rules.TalentID != nil && !v.rules.TalentID.MatchString(r.TalentID):
+		fail("talent_id", ReasonPattern, "talent_id must match %s", v.rules.TalentID)
+	}
+
+	switch {
+	case len(v.rules.Skills) == 0:
+	case r.Skill == "":
+		fail("skill", ReasonRequired, "skill is required")
+	case !slices.Contains(v.rules.Skills, r.Skill):
+		fail("skill", ReasonUnknownSkill, "unknown skill %q", r.Skill)
+	}
+
+	if math.IsNaN(r.RawMetric) || math.IsInf(r.RawMetric, 0) {
+		fail("raw_metric", ReasonNotFinite, "raw_metric must be a finite number")
+	} else if rng, ok := v.rangeOf(r.Skill); ok && (r.RawMetric < rng.Min || r.RawMetric > rng.Max) {
+		fail("raw_metric", ReasonOutOfRange, "raw_metric must be in [%g, %g]", rng.Min, rng.Max)
+	}
+
+	switch {
+	case r.TS.IsZero():
+		fail("ts", ReasonRequired, "ts is required")
+	case v.rules.MaxClockSkew > 0 && r.TS.After(v.now().Add(v.rules.MaxClockSkew)):
+		fail("ts", ReasonFuture, "ts is more than %s in the future", v.rules.MaxClockSkew)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	v.count(fields)
+
+	return &event.ValidationError{Message: "validation failed", Fields: fields}
+}
+
+func (v *EventValidator) rangeOf(skill string) (Range, bool) {
+	if rng, ok := v.rules.Ranges[skill]; ok {
+		return rng, true
+	}
+	rng, ok := v.rules.Ranges[anySkill]
+	return rng, ok
+}
+
+func (v *EventValidator) count(fields []event.FieldError) {
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if !seen[f.Reason] {
+			seen[f.Reason] = true
+			v.metrics.WithLabelValues(f.Reason).Inc()
+		}
+	}
+}
+
+// ParseSkills - comma separated, empty - any skill.
+func ParseSkills(s string) []string {
+	return splitList(s)
+}
+
+// ParseRanges - "skill=min:max" comma separated, "*" for the rest of the skills,
+// e.g. "*=0:100,shoot=0:250".
+func ParseRanges(s string) (map[string]Range, error) {
+	ranges := make(map[string]Range)
+	for _, item := range splitList(s) {
+		skill, bounds, ok := strings.Cut(item, "=")
+		lo, hi, ok2 := strings.Cut(bounds, ":")
+		if !ok || !ok2 || skill == "" {
+			return nil, fmt.Errorf("metric range %q: skill=min:max expected", item)
+		}
+		var rng Range
+		var err error
+		if rng.Min, err = strconv.ParseFloat(lo, 64); err != nil {
+			return nil, fmt.Errorf("metric range %q: %w", item, err)
+		}
+		if rng.Max, err = strconv.ParseFloat(hi, 64); err != nil {
+			return nil, fmt.Errorf("metric range %q: %w", item, err)
+		}
+		if rng.Min > rng.Max {
+			return nil, fmt.Errorf("metric range %q: min is greater than max", item)
+		}
+		ranges[strings.TrimSpace(skill)] = rng
+	}
+
+	return ranges, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
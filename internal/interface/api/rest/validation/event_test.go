@@ -0,0 +1,123 @@
+package validation
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"leaderboard-api/internal/interface/api/rest/dto/event"
+)
+
+var now = time.Date(2025, 8, 28, 9, 15, 0, 0, time.UTC)
+
+func newTestValidator() *EventValidator {
+	v := NewEventValidator(Rules{
+		Skills:       []string{"dribble", "shoot"},
+		Ranges:       map[string]Range{"*": {0, 100}, "shoot": {0, 250}},
+		MaxClockSkew: 5 * time.Minute,
+		TalentID:     regexp.MustCompile(`^t-[0-9]+$`),
+	}, prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rejected"}, []string{"reason"}))
+	v.now = func() time.Time { return now }
+
+	return v
+}
+
+func validRequest() event.Request {
+	return event.Request{EventID: uuid.New(), TalentID: "t-1", RawMetric: 50, Skill: "dribble", TS: now}
+}
+
+func TestEventValidator(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *event.Request)
+		// want - field:reason of every failed field
+		want []string
+	}{
+		{"Valid", func(*event.Request) {}, nil},
+		{"Skill range", func(r *event.Request) { r.Skill, r.RawMetric = "shoot", 200 }, nil},
+		{"Little clock skew", func(r *event.Request) { r.TS = now.Add(time.Minute) }, nil},
+		{"Nil event id", func(r *event.Request) { r.EventID = uuid.Nil }, []string{"event_id:required"}},
+		{"Empty talent", func(r *event.Request) { r.TalentID = "" }, []string{"talent_id:required"}},
+		{"Talent pattern", func(r *event.Request) { r.TalentID = "../t-1" }, []string{"talent_id:pattern"}},
+		{"Unknown skill", func(r *event.Request) { r.Skill = "jump" }, []string{"skill:unknown_skill"}},
+		{"No skill", func(r *event.Request) { r.Skill = "" }, []string{"skill:required"}},
+		{"NaN", func(r *event.Request) { r.RawMetric = math.NaN() }, []string{"raw_metric:not_finite"}},
+		{"Inf", func(r *event.Request) { r.RawMetric = math.Inf(-1) }, []string{"raw_metric:not_finite"}},
+		{"Default range", func(r *event.Request) { r.RawMetric = 101 }, []string{"raw_metric:out_of_range"}},
+		{"Year 3000", func(r *event.Request) { r.TS = time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC) }, []string{"ts:future"}},
+		{"No ts", func(r *event.Request) { r.TS = time.Time{} }, []string{"ts:required"}},
+		{"Every field", func(r *event.Request) { *r = event.Request{RawMetric: -1} }, []string{
+			"event_id:required", "talent_id:required", "skill:required", "raw_metric:out_of_range", "ts:required",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validRequest()
+			tt.modify(&r)
+
+			err := newTestValidator().Validate(r)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			var invalid *event.ValidationError
+			require.True(t, errors.As(err, &invalid))
+			var got []string
+			for _, f := range invalid.Fields {
+				got = append(got, f.Field+":"+f.Reason)
+				require.NotEmpty(t, f.Message)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEventValidator_NoRules(t *testing.T) {
+	v := NewEventValidator(Rules{}, prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rejected"}, []string{"reason"}))
+	r := event.Request{EventID: uuid.New(), TalentID: "any talent", RawMetric: -1e9, TS: time.Now().AddDate(100, 0, 0)}
+	require.NoError(t, v.Validate(r))
+}
+
+func TestEventValidator_Metrics(t *testing.T) {
+	v := newTestValidator()
+	r := validRequest()
+	r.EventID, r.TalentID = uuid.Nil, ""
+	require.Error(t, v.Validate(r))
+	r = validRequest()
+	r.Skill = "jump"
+	require.Error(t, v.Validate(r))
+	require.NoError(t, v.Validate(validRequest()))
+
+	// once per reason of an event
+	require.Equal(t, 1.0, testutil.ToFloat64(v.metrics.WithLabelValues(ReasonRequired)))
+	require.Equal(t, 1.0, testutil.ToFloat64(v.metrics.WithLabelValues(ReasonUnknownSkill)))
+	require.Equal(t, 2, testutil.CollectAndCount(v.metrics))
+}
+
+func TestParseRanges(t *testing.T) {
+	ranges, err := ParseRanges(" *=0:100, shoot=-5:2.5e2 ")
+	require.NoError(t, err)
+	require.Equal(t, map[string]Range{"*": {0, 100}, "shoot": {-5, 250}}, ranges)
+
+	ranges, err = ParseRanges("")
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	for _, s := range []string{"shoot", "shoot=1", "=0:1", "shoot=a:1", "shoot=1:b", "shoot=2:1"} {
+		_, err := ParseRanges(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestParseSkills(t *testing.T) {
+	require.Equal(t, []string{"dribble", "pass"}, ParseSkills(" dribble,,pass "))
+	require.Empty(t, ParseSkills(""))
+}
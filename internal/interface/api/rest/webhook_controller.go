@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"leaderboard-api/internal/application/ports"
+	"leaderboard-api/internal/domain/milestone"
+	dto "leaderboard-api/internal/interface/api/rest/dto/admin"
+)
+
+// WebhookController - the milestone deliveries, an admin surface as well.
+type WebhookController struct {
+	webhookService ports.WebhookService
+}
+
+func NewWebhookController(m *http.ServeMux, webhookService ports.WebhookService) *WebhookController {
+	wc := &WebhookController{
+		webhookService: webhookService,
+	}
+
+	deliveries := RouteAdmin + RouteWebhooks + RouteDeliveries
+	m.HandleFunc(http.MethodGet+Space+deliveries, wc.GetDeliveries)
+	m.HandleFunc(http.MethodGet+Space+deliveries+Slash+"{"+PathID+"}", wc.GetDelivery)
+	m.HandleFunc(http.MethodPost+Space+deliveries+Slash+"{"+PathID+"}"+RouteRetry, wc.PostRetry)
+
+	return wc
+}
+
+// GetDeliveries - the deliveries by status, pending by default.
+func (wc *WebhookController) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	st := milestone.StatusPending
+	if s := r.URL.Query().Get("status"); s != "" {
+		var ok bool
+		if st, ok = milestone.ParseStatus(s); !ok {
+			http.Error(w, "status must be one of pending, delivered, dead", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ds, err := wc.webhookService.Deliveries(r.Context(), st)
+	if err != nil {
+		wc.error(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToDeliveries(ds))
+}
+
+func (wc *WebhookController) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	d, err := wc.webhookService.Delivery(r.Context(), r.PathValue(PathID))
+	if err != nil {
+		wc.error(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.ToDelivery(d))
+}
+
+// PostRetry - queues a dead-lettered delivery again.
+func (wc *WebhookController) PostRetry(w http.ResponseWriter, r *http.Request) {
+	d, err := wc.webhookService.Redrive(r.Context(), r.PathValue(PathID))
+	if err != nil {
+		wc.error(w, err)
+		return
+	}
+
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(dto.ToDelivery(d))
+}
+
+func (wc *WebhookController) error(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, milestone.ErrWebhooksDisabled):
+		http.Error(w, "webhooks are disabled (WEBHOOKS_FILE is not set)", http.StatusConflict)
+	case errors.Is(err, milestone.ErrDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, milestone.ErrNotDead):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "failed to read the webhook deliveries", http.StatusInternalServerError)
+	}
+}